@@ -0,0 +1,156 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormActivityRepository struct {
+	db *gorm.DB
+}
+
+func NewGormActivityRepository(db *gorm.DB) *GormActivityRepository {
+	return &GormActivityRepository{db: db}
+}
+
+// activityOrderLock คีย์ advisory lock ที่ใช้เรียงการบันทึกกิจกรรม
+const activityOrderLock = 4107
+
+// CreateActivity บันทึกกิจกรรมทีละรายการภายใต้ advisory lock ที่ถือไว้จน commit
+// รหัสกิจกรรมจึงถูกจองและ commit ตามลำดับเดียวกัน (ทุก instance ของเซิร์ฟเวอร์) เมื่อเห็นกิจกรรมรหัส N
+// ก็เห็นกิจกรรมที่รหัสน้อยกว่าครบแล้ว ใช้รหัสเป็น cursor ของ sync และ Last-Event-ID ได้โดยไม่พลาดกิจกรรม
+func (r *GormActivityRepository) CreateActivity(activity *entities.Activity) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", activityOrderLock).Error; err != nil {
+			return err
+		}
+		return tx.Create(activity).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create activity: %v", err)
+	}
+	return nil
+}
+
+func (r *GormActivityRepository) GetActivitiesByNoteID(noteID uint, limit int) ([]entities.ActivityEntry, error) {
+	var activities []entities.ActivityEntry
+	if err := r.activityQuery().
+		Where("activities.note_id = ?", noteID).
+		Limit(limit).
+		Scan(&activities).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch activities: %v", err)
+	}
+	return activities, nil
+}
+
+// GetActivitiesByNoteIDs กิจกรรมล่าสุดของโน้ตที่กำหนด รวมกิจกรรมที่ผู้ใช้ทำเองที่ไม่ผูกกับโน้ต
+func (r *GormActivityRepository) GetActivitiesByNoteIDs(noteIDs []uint, actorID uint, limit int) ([]entities.ActivityEntry, error) {
+	query := r.activityQuery()
+	if len(noteIDs) > 0 {
+		query = query.Where("activities.note_id IN ? OR (activities.note_id = 0 AND activities.actor_id = ?)", noteIDs, actorID)
+	} else {
+		query = query.Where("activities.note_id = 0 AND activities.actor_id = ?", actorID)
+	}
+
+	var activities []entities.ActivityEntry
+	if err := query.Limit(limit).Scan(&activities).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch activities: %v", err)
+	}
+	return activities, nil
+}
+
+// GetActivitiesAfter กิจกรรมที่ใหม่กว่า afterID เรียงจากเก่าไปใหม่ ใช้เล่นย้อนหลังเมื่อเชื่อมต่อ SSE ใหม่และใช้ sync
+// นอกจากโน้ตใน noteIDs ยังรวมกิจกรรมที่ทำให้ผู้ใช้เสียสิทธิ์เข้าถึงโน้ต เพื่อให้รู้แม้เข้าถึงโน้ตไม่ได้แล้ว
+// คือการยกเลิกแชร์ให้ผู้ใช้ การยกเลิกแชร์ให้กลุ่มที่ผู้ใช้เป็นสมาชิก และการย้ายโน้ตออกจากเวิร์กสเปซที่ผู้ใช้เป็นสมาชิก
+func (r *GormActivityRepository) GetActivitiesAfter(noteIDs []uint, userID uint, afterID uint, limit int) ([]entities.Activity, error) {
+	related := "(note_id = 0 AND actor_id = ?)" +
+		" OR (entity_type = 'share' AND entity_id = ?)" +
+		" OR (entity_type = 'group_share' AND entity_id IN (SELECT group_id FROM contact_group_members WHERE user_id = ?))" +
+		// Before ของ note.workspace คือรหัสเวิร์กสเปซเดิมในรูป JSON
+		` OR (action = 'note.workspace' AND "before" IN (SELECT CAST(workspace_id AS TEXT) FROM workspace_members WHERE user_id = ?))`
+	args := []interface{}{userID, userID, userID, userID}
+	if len(noteIDs) > 0 {
+		related = "note_id IN ? OR " + related
+		args = append([]interface{}{noteIDs}, args...)
+	}
+
+	var activities []entities.Activity
+	if err := r.db.Where("activity_id > ?", afterID).
+		Where(related, args...).
+		Order("activity_id").Limit(limit).Find(&activities).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch activities: %v", err)
+	}
+	return activities, nil
+}
+
+// GetActivityRecipientIDs ผู้ใช้ที่ควรได้รับกิจกรรมนี้ทันที ใช้กฎเดียวกับ GetActivitiesAfter
+// คือผู้กระทำ ผู้ที่เข้าถึงโน้ตได้ และผู้ที่เสียสิทธิ์เข้าถึงจากกิจกรรมนี้
+func (r *GormActivityRepository) GetActivityRecipientIDs(activity *entities.Activity) ([]uint, error) {
+	var userIDs []uint
+	if err := r.db.Raw(`SELECT user_id FROM notes WHERE note_id = @note
+		UNION SELECT shared_with FROM share_notes WHERE note_id = @note AND status = 'accepted'
+		UNION SELECT contact_group_members.user_id FROM group_shares
+			JOIN contact_group_members ON contact_group_members.group_id = group_shares.group_id
+			JOIN group_share_responses ON group_share_responses.note_id = group_shares.note_id AND group_share_responses.user_id = contact_group_members.user_id
+			WHERE group_shares.note_id = @note AND group_share_responses.status = 'accepted'
+		UNION SELECT workspace_members.user_id FROM notes
+			JOIN workspace_members ON workspace_members.workspace_id = notes.workspace_id
+			WHERE notes.note_id = @note
+		UNION SELECT user_id FROM contact_group_members WHERE @entity_type = 'group_share' AND group_id = @entity
+		UNION SELECT user_id FROM workspace_members WHERE @action = 'note.workspace' AND CAST(workspace_id AS TEXT) = @before`,
+		map[string]interface{}{
+			"note":        activity.NoteID,
+			"entity_type": activity.EntityType,
+			"entity":      activity.EntityID,
+			"action":      activity.Action,
+			"before":      activity.Before,
+		}).Scan(&userIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch activity recipients: %v", err)
+	}
+
+	userIDs = append(userIDs, activity.ActorID)
+	if activity.EntityType == "share" {
+		userIDs = append(userIDs, activity.EntityID)
+	}
+	return userIDs, nil
+}
+
+// GetLatestActivityID รหัสกิจกรรมล่าสุดที่ commit แล้ว ใช้เป็น cursor ของการ sync
+func (r *GormActivityRepository) GetLatestActivityID() (uint, error) {
+	var latest uint
+	if err := r.db.Model(&entities.Activity{}).Select("COALESCE(MAX(activity_id), 0)").Scan(&latest).Error; err != nil {
+		return 0, fmt.Errorf("failed to fetch latest activity: %v", err)
+	}
+	return latest, nil
+}
+
+// CountNoteActivitiesBetween จำนวนกิจกรรมของโน้ตในช่วง (afterID, upToID] ใช้ตรวจ conflict
+func (r *GormActivityRepository) CountNoteActivitiesBetween(noteID uint, afterID uint, upToID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&entities.Activity{}).
+		Where("note_id = ? AND activity_id > ? AND activity_id <= ?", noteID, afterID, upToID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count activities: %v", err)
+	}
+	return count, nil
+}
+
+// activityQuery ดึงกิจกรรมพร้อมอีเมลผู้กระทำ เรียงจากใหม่ไปเก่า
+func (r *GormActivityRepository) activityQuery() *gorm.DB {
+	return r.db.Table("activities").
+		Select("activities.*, users.email AS actor_email").
+		Joins("LEFT JOIN users ON users.user_id = activities.actor_id").
+		Order("activities.activity_id DESC")
+}
+
+// RedactEntityActivities ล้างค่า Before/After ของกิจกรรมทั้งหมดของ entity เช่น ข้อความของความคิดเห็นที่ถูกแก้ไขหรือลบ
+func (r *GormActivityRepository) RedactEntityActivities(entityType string, entityID uint) error {
+	if err := r.db.Model(&entities.Activity{}).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Updates(map[string]interface{}{"before": "", "after": ""}).Error; err != nil {
+		return fmt.Errorf("failed to redact activities: %v", err)
+	}
+	return nil
+}
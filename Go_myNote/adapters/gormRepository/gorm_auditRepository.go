@@ -0,0 +1,31 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormAuditRepository struct {
+	db *gorm.DB
+}
+
+func NewGormAuditRepository(db *gorm.DB) *GormAuditRepository {
+	return &GormAuditRepository{db: db}
+}
+
+func (r *GormAuditRepository) CreateAuditLog(entry *entities.AuditLog) error {
+	if err := r.db.Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to create audit log: %v", err)
+	}
+	return nil
+}
+
+func (r *GormAuditRepository) GetAuditLogsByUserID(userID uint) ([]entities.AuditLog, error) {
+	var logs []entities.AuditLog
+	if err := r.db.Where("user_id = ?", userID).Order("audit_id DESC").Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch audit logs: %v", err)
+	}
+	return logs, nil
+}
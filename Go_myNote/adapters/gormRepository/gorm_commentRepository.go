@@ -0,0 +1,103 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GormCommentRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCommentRepository(db *gorm.DB) *GormCommentRepository {
+	return &GormCommentRepository{db: db}
+}
+
+func (r *GormCommentRepository) CreateComment(comment *entities.Comment) error {
+	if err := r.db.Create(comment).Error; err != nil {
+		return fmt.Errorf("failed to create comment: %v", err)
+	}
+	return nil
+}
+
+func (r *GormCommentRepository) GetCommentByID(commentID uint) (*entities.Comment, error) {
+	var comment entities.Comment
+	if err := r.db.Where("comment_id = ? AND deleted_at = ?", commentID, "").First(&comment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("comment not found")
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetCommentsByNoteID ดึงความคิดเห็นทั้งหมดของโน้ต (รวมที่ถูกลบ เพื่อประกอบเธรด) เรียงจากเก่าไปใหม่
+func (r *GormCommentRepository) GetCommentsByNoteID(noteID uint) ([]entities.CommentEntry, error) {
+	var comments []entities.CommentEntry
+	if err := r.db.Table("comments").
+		Select("comments.*, users.email AS author_email").
+		Joins("LEFT JOIN users ON users.user_id = comments.author_id").
+		Where("comments.note_id = ?", noteID).
+		Order("comments.comment_id").
+		Scan(&comments).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch comments: %v", err)
+	}
+	return comments, nil
+}
+
+func (r *GormCommentRepository) UpdateCommentContent(commentID uint, content string) error {
+	result := r.db.Model(&entities.Comment{}).
+		Where("comment_id = ? AND deleted_at = ?", commentID, "").
+		Updates(map[string]interface{}{
+			"content":    content,
+			"updated_at": time.Now().Format("2006-01-02 15:04:05"),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update comment: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("comment not found")
+	}
+	return nil
+}
+
+func (r *GormCommentRepository) SetCommentResolved(commentID uint, resolved bool, userID uint) error {
+	updates := map[string]interface{}{
+		"resolved":    resolved,
+		"resolved_by": uint(0),
+		"resolved_at": "",
+	}
+	if resolved {
+		updates["resolved_by"] = userID
+		updates["resolved_at"] = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	result := r.db.Model(&entities.Comment{}).Where("comment_id = ? AND deleted_at = ?", commentID, "").Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update comment: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("comment not found")
+	}
+	return nil
+}
+
+// DeleteComment ลบแบบ soft delete และล้างเนื้อหา คำตอบกลับในเธรดยังคงอยู่
+func (r *GormCommentRepository) DeleteComment(commentID uint) error {
+	result := r.db.Model(&entities.Comment{}).
+		Where("comment_id = ? AND deleted_at = ?", commentID, "").
+		Updates(map[string]interface{}{
+			"content":    "",
+			"deleted_at": time.Now().Format("2006-01-02 15:04:05"),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete comment: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("comment not found")
+	}
+	return nil
+}
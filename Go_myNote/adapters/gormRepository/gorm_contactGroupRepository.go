@@ -0,0 +1,130 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormContactGroupRepository struct {
+	db *gorm.DB
+}
+
+func NewGormContactGroupRepository(db *gorm.DB) *GormContactGroupRepository {
+	return &GormContactGroupRepository{db: db}
+}
+
+func (r *GormContactGroupRepository) CreateGroup(group *entities.ContactGroup) error {
+	if err := r.db.Create(group).Error; err != nil {
+		return fmt.Errorf("failed to create group: %v", err)
+	}
+	return nil
+}
+
+func (r *GormContactGroupRepository) GetGroupByID(groupID uint) (*entities.ContactGroup, error) {
+	var group entities.ContactGroup
+	if err := r.db.First(&group, groupID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("group not found")
+		}
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *GormContactGroupRepository) GetGroupsByOwnerID(ownerID uint) ([]entities.ContactGroup, error) {
+	var groups []entities.ContactGroup
+	if err := r.db.Where("owner_id = ?", ownerID).Order("group_id").Find(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch groups: %v", err)
+	}
+	return groups, nil
+}
+
+func (r *GormContactGroupRepository) UpdateGroupName(groupID uint, name string) error {
+	result := r.db.Model(&entities.ContactGroup{}).Where("group_id = ?", groupID).Update("name", name)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update group: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("group not found")
+	}
+	return nil
+}
+
+// DeleteGroup ลบกลุ่มพร้อมสมาชิกและการแชร์โน้ตให้กลุ่ม สมาชิกจะเข้าถึงโน้ตผ่านกลุ่มไม่ได้อีก
+func (r *GormContactGroupRepository) DeleteGroup(groupID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("group_id = ?", groupID).Delete(&entities.GroupShare{}).Error; err != nil {
+			return fmt.Errorf("failed to delete group shares: %v", err)
+		}
+		if err := tx.Where("group_id = ?", groupID).Delete(&entities.ContactGroupMember{}).Error; err != nil {
+			return fmt.Errorf("failed to delete group members: %v", err)
+		}
+		if err := tx.Delete(&entities.ContactGroup{}, groupID).Error; err != nil {
+			return fmt.Errorf("failed to delete group: %v", err)
+		}
+		return nil
+	})
+}
+
+func (r *GormContactGroupRepository) GetMembers(groupID uint) ([]entities.ContactGroupMemberInfo, error) {
+	var members []entities.ContactGroupMemberInfo
+	if err := r.db.Table("contact_group_members").
+		Select("contact_group_members.user_id, users.email, users.username").
+		Joins("JOIN users ON users.user_id = contact_group_members.user_id").
+		Where("contact_group_members.group_id = ?", groupID).
+		Order("contact_group_members.group_member_id").
+		Scan(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch group members: %v", err)
+	}
+	return members, nil
+}
+
+func (r *GormContactGroupRepository) IsMember(groupID uint, userID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&entities.ContactGroupMember{}).Where("group_id = ? AND user_id = ?", groupID, userID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *GormContactGroupRepository) AddMember(member *entities.ContactGroupMember) error {
+	if err := r.db.Create(member).Error; err != nil {
+		return fmt.Errorf("failed to add group member: %v", err)
+	}
+	return nil
+}
+
+func (r *GormContactGroupRepository) RemoveMember(groupID uint, userID uint) error {
+	result := r.db.Where("group_id = ? AND user_id = ?", groupID, userID).Delete(&entities.ContactGroupMember{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove group member: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("member not found")
+	}
+	return nil
+}
+
+// groupSharedNoteQuery โน้ตที่แชร์ให้กลุ่มที่ผู้ใช้เป็นสมาชิก ถ้าระบุ permissions จะกรองตามสิทธิ์ด้วย
+// นับเฉพาะโน้ตที่ผู้ใช้ตอบรับแล้ว (GroupShareResponse) ที่ยังรอตอบรับ ปฏิเสธ หรือออกไปแล้วจะไม่รวม
+func groupSharedNoteQuery(db *gorm.DB, userID uint, permissions []string) *gorm.DB {
+	query := db.Table("group_shares").
+		Joins("JOIN contact_group_members ON contact_group_members.group_id = group_shares.group_id").
+		Where("contact_group_members.user_id = ?", userID).
+		Where("EXISTS (SELECT 1 FROM group_share_responses WHERE group_share_responses.note_id = group_shares.note_id AND group_share_responses.user_id = ? AND group_share_responses.status = ?)", userID, "accepted")
+	if len(permissions) > 0 {
+		query = query.Where("group_shares.permission IN ?", permissions)
+	}
+	return query
+}
+
+// groupSharedNoteIDs ดึง NoteID ทั้งหมดที่ผู้ใช้เข้าถึงได้ผ่านกลุ่ม
+func groupSharedNoteIDs(db *gorm.DB, userID uint) ([]uint, error) {
+	var noteIDs []uint
+	if err := groupSharedNoteQuery(db, userID, nil).Distinct().Pluck("group_shares.note_id", &noteIDs).Error; err != nil {
+		return nil, err
+	}
+	return noteIDs, nil
+}
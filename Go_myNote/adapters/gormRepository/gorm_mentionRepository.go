@@ -0,0 +1,59 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormMentionRepository struct {
+	db *gorm.DB
+}
+
+func NewGormMentionRepository(db *gorm.DB) *GormMentionRepository {
+	return &GormMentionRepository{db: db}
+}
+
+// GetMentionsBySource ดึงการกล่าวถึงของเนื้อหาโน้ต (commentID = 0) หรือของความคิดเห็น
+func (r *GormMentionRepository) GetMentionsBySource(noteID uint, commentID uint) ([]entities.Mention, error) {
+	var mentions []entities.Mention
+	if err := r.db.Where("note_id = ? AND comment_id = ?", noteID, commentID).Find(&mentions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch mentions: %v", err)
+	}
+	return mentions, nil
+}
+
+func (r *GormMentionRepository) CreateMention(mention *entities.Mention) error {
+	if err := r.db.Create(mention).Error; err != nil {
+		return fmt.Errorf("failed to create mention: %v", err)
+	}
+	return nil
+}
+
+func (r *GormMentionRepository) DeleteMentions(noteID uint, commentID uint, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	if err := r.db.Where("note_id = ? AND comment_id = ? AND user_id IN ?", noteID, commentID, userIDs).
+		Delete(&entities.Mention{}).Error; err != nil {
+		return fmt.Errorf("failed to delete mentions: %v", err)
+	}
+	return nil
+}
+
+// GetMentionsForUser การกล่าวถึงผู้ใช้ทั้งหมด เรียงจากใหม่ไปเก่า ไม่รวมโน้ตในถังขยะ
+func (r *GormMentionRepository) GetMentionsForUser(userID uint) ([]entities.MentionEntry, error) {
+	var mentions []entities.MentionEntry
+	if err := r.db.Table("mentions").
+		Select("mentions.*, notes.title AS note_title, users.email AS author_email, COALESCE(comments.content, '') AS comment_content").
+		Joins("JOIN notes ON notes.note_id = mentions.note_id").
+		Joins("LEFT JOIN users ON users.user_id = mentions.author_id").
+		Joins("LEFT JOIN comments ON comments.comment_id = mentions.comment_id").
+		Where("mentions.user_id = ? AND notes.deleted_at = ?", userID, "").
+		Order("mentions.mention_id DESC").
+		Scan(&mentions).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch mentions: %v", err)
+	}
+	return mentions, nil
+}
@@ -0,0 +1,29 @@
+package gormRepository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// MigrateTagNameUniqueness ลบ unique ของ tag_name แบบทั้งระบบจากเวอร์ชันก่อน ต้องเรียกก่อน AutoMigrate
+// ซึ่งจะสร้าง unique index แบบแยกตามผู้ใช้และเวิร์กสเปซแทน
+// ชื่อ constraint ขึ้นกับเวอร์ชันของ GORM ที่สร้างตาราง จึงลบทุกชื่อที่เป็นไปได้
+// ข้อมูลเดิมไม่มีชื่อซ้ำอยู่แล้วเพราะเคยไม่ซ้ำทั้งระบบ จึงสร้าง index ใหม่ได้ทันที
+func MigrateTagNameUniqueness(db *gorm.DB) error {
+	if !db.Migrator().HasTable("tags") {
+		return nil
+	}
+
+	statements := []string{
+		"ALTER TABLE tags DROP CONSTRAINT IF EXISTS uni_tags_tag_name",
+		"ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_tag_name_key",
+		"DROP INDEX IF EXISTS idx_tags_tag_name",
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to drop global tag name constraint: %v", err)
+		}
+	}
+	return nil
+}
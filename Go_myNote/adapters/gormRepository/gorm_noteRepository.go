@@ -3,9 +3,12 @@ package gormRepository
 import (
 	"fmt"
 	"miw/entities"
+	"miw/usecases/repository"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormNoteRepository struct {
@@ -29,9 +32,15 @@ func (r *GormNoteRepository) CreateNote(note *entities.Note) error {
         // จัดการ TodoItems
         if len(note.TodoItems) > 0 {
             todoItems := make([]entities.ToDo, 0)
-            for _, todo := range note.TodoItems {
+            // ID และ ParentID ที่ส่งมาเป็นของรายการต้นฉบับ (เช่นตอนคัดลอกโน้ต) ใช้แปลงเป็นรายการย่อยหลังบันทึกแล้ว
+            sourceIDs := make([]uint, 0)
+            sourceParents := make([]*uint, 0)
+            for i, todo := range note.TodoItems {
+                sourceID, sourceParent := todo.ID, todo.ParentID
                 todo.NoteID = note.NoteID
                 todo.ID = 0 // รีเซ็ต ID
+                todo.Position = i
+                todo.ParentID = nil // ยังไม่มี ID ของรายการอื่นให้อ้างถึง
 
                 // ตรวจสอบว่ารายการไม่มีซ้ำ
                 var count int64
@@ -41,6 +50,8 @@ func (r *GormNoteRepository) CreateNote(note *entities.Note) error {
                 }
                 if count == 0 {
                     todoItems = append(todoItems, todo)
+                    sourceIDs = append(sourceIDs, sourceID)
+                    sourceParents = append(sourceParents, sourceParent)
                 } else {
                     fmt.Printf("Duplicate ToDo found for Content: %s\n", todo.Content)
                 }
@@ -51,6 +62,9 @@ func (r *GormNoteRepository) CreateNote(note *entities.Note) error {
                 if err := tx.Create(&todoItems).Error; err != nil {
                     return fmt.Errorf("failed to create todo items: %v", err)
                 }
+                if err := linkCreatedTodoParents(tx, todoItems, sourceIDs, sourceParents); err != nil {
+                    return err
+                }
             }
         }
 
@@ -60,27 +74,176 @@ func (r *GormNoteRepository) CreateNote(note *entities.Note) error {
 }
 
 
+// linkCreatedTodoParents ตั้ง ParentID ของรายการที่เพิ่งสร้าง โดยแปลง ID ต้นฉบับเป็น ID ใหม่
+// รายการแม่ที่ไม่ได้ถูกสร้างด้วย หรือทำให้เกิดวงวน จะกลายเป็นรายการระดับบนสุด
+func linkCreatedTodoParents(tx *gorm.DB, todoItems []entities.ToDo, sourceIDs []uint, sourceParents []*uint) error {
+	newIDs := make(map[uint]uint)
+	for i, todo := range todoItems {
+		if sourceIDs[i] != 0 {
+			newIDs[sourceIDs[i]] = todo.ID
+		}
+	}
+
+	parents := make(map[uint]uint)
+	for i, todo := range todoItems {
+		if sourceParents[i] == nil {
+			continue
+		}
+		parentID, ok := newIDs[*sourceParents[i]]
+		if !ok {
+			continue
+		}
+		// เดินขึ้นไปตามรายการแม่ที่ตั้งไปแล้ว ถ้าเจอตัวเองแสดงว่าเป็นวงวน
+		cycle := false
+		for ancestor, steps := parentID, 0; ancestor != 0 && steps <= len(parents); ancestor, steps = parents[ancestor], steps+1 {
+			if ancestor == todo.ID {
+				cycle = true
+				break
+			}
+		}
+		if cycle {
+			continue
+		}
+		if err := tx.Model(&entities.ToDo{}).Where("id = ?", todo.ID).Update("parent_id", parentID).Error; err != nil {
+			return fmt.Errorf("failed to link child todo item: %v", err)
+		}
+		parents[todo.ID] = parentID
+	}
+	return nil
+}
+
 func (r *GormNoteRepository) GetAllNoteByUserId(userID uint) ([]entities.Note, error) {
 	var notes []entities.Note
 
 	// Fetch notes owned by the user
-	if err := r.db.Where("user_id = ? AND deleted_at = ?", userID, "").Preload("Tags").Preload("Reminder").Preload("TodoItems").Find(&notes).Error; err != nil {
+	if err := r.db.Where("user_id = ? AND deleted_at = ? AND archived_at = ?", userID, "", "").Preload("Tags").Preload("Reminder").Preload("TodoItems", orderTodoItems).Find(&notes).Error; err != nil {
 		return nil, err
 	}
 
 	// Fetch notes shared with the user
 	var sharedNoteIDs []uint
-	if err := r.db.Model(&entities.ShareNote{}).Where("shared_with = ?", userID).Pluck("note_id", &sharedNoteIDs).Error; err != nil {
+	if err := r.db.Model(&entities.ShareNote{}).Where("shared_with = ? AND status = ?", userID, "accepted").Pluck("note_id", &sharedNoteIDs).Error; err != nil {
+		return nil, err
+	}
+
+	// รวมโน้ตที่แชร์ให้กลุ่มที่ผู้ใช้เป็นสมาชิก
+	groupNoteIDs, err := groupSharedNoteIDs(r.db, userID)
+	if err != nil {
 		return nil, err
 	}
+	sharedNoteIDs = append(sharedNoteIDs, groupNoteIDs...)
+
 	if len(sharedNoteIDs) > 0 {
 		var sharedNotes []entities.Note
-		if err := r.db.Where("note_id IN ? AND deleted_at = ?", sharedNoteIDs, "").Preload("Tags").Preload("Reminder").Preload("TodoItems").Find(&sharedNotes).Error; err != nil {
+		if err := r.db.Where("note_id IN ? AND user_id <> ? AND deleted_at = ? AND archived_at = ?", sharedNoteIDs, userID, "", "").Preload("Tags").Preload("Reminder").Preload("TodoItems", orderTodoItems).Find(&sharedNotes).Error; err != nil {
 			return nil, err
 		}
 		notes = append(notes, sharedNotes...)
 	}
 
+	// Fetch notes in workspaces the user belongs to
+	var workspaceIDs []uint
+	if err := r.db.Model(&entities.WorkspaceMember{}).Where("user_id = ?", userID).Pluck("workspace_id", &workspaceIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(workspaceIDs) > 0 {
+		var workspaceNotes []entities.Note
+		if err := r.db.Where("workspace_id IN ? AND user_id <> ? AND deleted_at = ? AND archived_at = ?", workspaceIDs, userID, "", "").Preload("Tags").Preload("Reminder").Preload("TodoItems", orderTodoItems).Find(&workspaceNotes).Error; err != nil {
+			return nil, err
+		}
+
+		// ข้ามโน้ตที่ถูกแชร์มาให้แล้ว เพื่อไม่ให้แสดงซ้ำ
+		seen := make(map[uint]bool, len(sharedNoteIDs))
+		for _, id := range sharedNoteIDs {
+			seen[id] = true
+		}
+		for _, note := range workspaceNotes {
+			if !seen[note.NoteID] {
+				notes = append(notes, note)
+			}
+		}
+	}
+
+	return notes, nil
+}
+
+// noteSortOrders การเรียงลำดับที่ SearchNotes รองรับ
+var noteSortOrders = map[string]string{
+	"":           "notes.updated_at DESC, notes.note_id DESC",
+	"updated_at": "notes.updated_at DESC, notes.note_id DESC",
+	"created_at": "notes.created_at DESC, notes.note_id DESC",
+	"priority":   "notes.priority DESC, notes.updated_at DESC, notes.note_id DESC",
+	"title":      "notes.title, notes.note_id",
+}
+
+// escapeLike ป้องกันไม่ให้ % และ _ ในคำค้นถูกตีความเป็น wildcard
+func escapeLike(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(text)
+}
+
+// SearchNotes ค้นหาโน้ตที่ผู้ใช้เข้าถึงได้ (ของตัวเอง แชร์มา แชร์ให้กลุ่ม และในเวิร์กสเปซ) ตามเงื่อนไขของ query
+// ไม่รวมโน้ตในถังขยะ และไม่รวมโน้ตในคลังถ้าไม่ได้ระบุ include_archived
+func (r *GormNoteRepository) SearchNotes(userID uint, query entities.NoteQuery) ([]entities.Note, error) {
+	sharedNotes := r.db.Model(&entities.ShareNote{}).Select("note_id").Where("shared_with = ? AND status = ?", userID, "accepted")
+	groupNotes := groupSharedNoteQuery(r.db, userID, nil).Select("group_shares.note_id")
+	workspaces := r.db.Model(&entities.WorkspaceMember{}).Select("workspace_id").Where("user_id = ?", userID)
+
+	db := r.db.Model(&entities.Note{}).
+		Where("(notes.user_id = ? OR notes.note_id IN (?) OR notes.note_id IN (?) OR notes.workspace_id IN (?))", userID, sharedNotes, groupNotes, workspaces).
+		Where("notes.deleted_at = ?", "")
+
+	if !query.IncludeArchived {
+		db = db.Where("notes.archived_at = ?", "")
+	}
+
+	if query.Text != "" {
+		pattern := "%" + escapeLike(query.Text) + "%"
+		db = db.Where("(notes.title ILIKE ? OR notes.content ILIKE ? OR EXISTS (SELECT 1 FROM to_dos WHERE to_dos.note_id = notes.note_id AND to_dos.content ILIKE ?))", pattern, pattern, pattern)
+	}
+
+	// แท็กแม่รวมโน้ตที่ติดแท็กย่อยทุกระดับด้วย
+	if len(query.TagIDs) > 0 {
+		tagged := "EXISTS (SELECT 1 FROM note_tags WHERE note_tags.note_id = notes.note_id AND note_tags.tag_id IN (" + tagSubtreeSQL + "))"
+		if query.MatchAllTags {
+			for _, tagID := range query.TagIDs {
+				db = db.Where(tagged, []uint{tagID})
+			}
+		} else {
+			db = db.Where(tagged, query.TagIDs)
+		}
+	}
+
+	if len(query.Colors) > 0 {
+		db = db.Where("notes.color IN ?", query.Colors)
+	}
+	if query.MinPriority != nil {
+		db = db.Where("notes.priority >= ?", *query.MinPriority)
+	}
+	if query.MaxPriority != nil {
+		db = db.Where("notes.priority <= ?", *query.MaxPriority)
+	}
+
+	switch query.TodoStatus {
+	case "open":
+		db = db.Where("EXISTS (SELECT 1 FROM to_dos WHERE to_dos.note_id = notes.note_id AND to_dos.is_done = ?)", false)
+	case "done":
+		db = db.Where("notes.is_all_done = ?", true)
+	case "none":
+		db = db.Where("notes.is_todo = ? AND NOT EXISTS (SELECT 1 FROM to_dos WHERE to_dos.note_id = notes.note_id)", false)
+	}
+
+	if query.ReminderFrom != "" && query.ReminderTo != "" {
+		db = db.Where("EXISTS (SELECT 1 FROM reminders WHERE reminders.note_id = notes.note_id AND reminders.reminder_time BETWEEN ? AND ?)", query.ReminderFrom, query.ReminderTo)
+	}
+
+	var notes []entities.Note
+	if err := db.Order(noteSortOrders[query.SortBy]).
+		Preload("Tags").
+		Preload("Reminder").
+		Preload("TodoItems", orderTodoItems).
+		Find(&notes).Error; err != nil {
+		return nil, fmt.Errorf("failed to search notes: %v", err)
+	}
 	return notes, nil
 }
 
@@ -90,7 +253,7 @@ func (r *GormNoteRepository) GetNoteById(noteID uint) (*entities.Note, error) {
 		Preload("Tags").
 		Preload("Reminder").
 		Preload("Event").
-		Preload("TodoItems"). // เพิ่มการโหลด TodoItems
+		Preload("TodoItems", orderTodoItems). // เพิ่มการโหลด TodoItems
 		First(&note, noteID).Error; err != nil {
 		return nil, err
 	}
@@ -138,27 +301,67 @@ func (r *GormNoteRepository) UpdateNotePriority(noteID uint, userID uint, priori
 
 func (r *GormNoteRepository) UpdateNoteTitleAndContent(note *entities.Note) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		// อัปเดต Note
-		if err := tx.Save(note).Error; err != nil {
+		// อัปเดต Note (รายการ ToDo จัดการแยกด้านล่าง)
+		if err := tx.Omit(clause.Associations).Save(note).Error; err != nil {
 			return fmt.Errorf("failed to update note: %v", err)
 		}
 
-		// ถ้ามี TodoItems ให้จัดการ
+		// ถ้ามี TodoItems ให้ upsert ตาม ID เพื่อให้ ID ของรายการเดิมคงที่
 		if len(note.TodoItems) > 0 {
-			// ลบ TodoItems เก่าที่เชื่อมโยงกับ NoteID
-			if err := tx.Where("note_id = ?", note.NoteID).Delete(&entities.ToDo{}).Error; err != nil {
-				return fmt.Errorf("failed to delete old todo items: %v", err)
+			var existingIDs []uint
+			if err := tx.Model(&entities.ToDo{}).Where("note_id = ?", note.NoteID).Pluck("id", &existingIDs).Error; err != nil {
+				return fmt.Errorf("failed to fetch todo items: %v", err)
+			}
+			existing := make(map[uint]bool)
+			for _, id := range existingIDs {
+				existing[id] = true
 			}
 
-			// ตั้งค่า NoteID และรีเซ็ต ID เป็น 0 สำหรับการเพิ่มใหม่
+			kept := make(map[uint]bool)
+			now := time.Now().Format("2006-01-02 15:04:05")
 			for i := range note.TodoItems {
-				note.TodoItems[i].ID = 0
-				note.TodoItems[i].NoteID = note.NoteID
+				todo := &note.TodoItems[i]
+				todo.NoteID = note.NoteID
+				todo.Position = i
+				todo.UpdatedAt = now
+
+				if existing[todo.ID] && !kept[todo.ID] {
+					kept[todo.ID] = true
+					if err := tx.Model(&entities.ToDo{}).Where("id = ?", todo.ID).Updates(map[string]interface{}{
+						"content":      todo.Content,
+						"is_done":      todo.IsDone,
+						"position":     todo.Position,
+						"due_date":     todo.DueDate,
+						"priority":     todo.Priority,
+						"parent_id":    todo.ParentID,
+						"assignee_id":  todo.AssigneeID,
+						"completed_at": todo.CompletedAt,
+						"updated_at":   now,
+					}).Error; err != nil {
+						return fmt.Errorf("failed to update todo item: %v", err)
+					}
+					continue
+				}
+
+				// รายการใหม่ (หรือ ID ที่ไม่ใช่ของโน้ตนี้)
+				todo.ID = 0
+				todo.CreatedAt = now
+				if err := tx.Create(todo).Error; err != nil {
+					return fmt.Errorf("failed to create new todo items: %v", err)
+				}
 			}
 
-			// เพิ่ม TodoItems ใหม่
-			if err := tx.Create(&note.TodoItems).Error; err != nil {
-				return fmt.Errorf("failed to create new todo items: %v", err)
+			// ลบรายการที่ไม่อยู่ในรายการใหม่
+			var removed []uint
+			for _, id := range existingIDs {
+				if !kept[id] {
+					removed = append(removed, id)
+				}
+			}
+			if len(removed) > 0 {
+				if err := tx.Where("note_id = ? AND id IN ?", note.NoteID, removed).Delete(&entities.ToDo{}).Error; err != nil {
+					return fmt.Errorf("failed to delete old todo items: %v", err)
+				}
 			}
 		}
 
@@ -182,6 +385,10 @@ func (r *GormNoteRepository) UpdateNoteStatus(noteID uint, userID uint, isTodo *
 	}
 	if isAllDone != nil {
 		updates["is_all_done"] = *isAllDone
+		updates["completed_at"] = ""
+		if *isAllDone {
+			updates["completed_at"] = time.Now().Format("2006-01-02 15:04:05")
+		}
 	}
 
 	// เพิ่ม updated_at
@@ -202,22 +409,159 @@ func (r *GormNoteRepository) UpdateNoteStatus(noteID uint, userID uint, isTodo *
 }
 
 func (r *GormNoteRepository) UpdateTodoStatus(noteID uint, todoID uint, isDone bool) error {
-    result := r.db.Model(&entities.ToDo{}).
-        Where("id = ? AND note_id = ?", todoID, noteID).
-        Updates(map[string]interface{}{
-            "is_done":    isDone,
-            "updated_at": time.Now().Format("2006-01-02 15:04:05"),
-        })
+    var todo entities.ToDo
+    if err := r.db.Where("id = ? AND note_id = ?", todoID, noteID).First(&todo).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return fmt.Errorf("todo not found or does not belong to the note")
+        }
+        return err
+    }
 
-    if result.Error != nil {
-        return result.Error
+    // สถานะเดิมไม่ต้องอัปเดต เพื่อไม่ให้เวลาที่เสร็จเปลี่ยน
+    if todo.IsDone == isDone {
+        return nil
     }
 
-    if result.RowsAffected == 0 {
-        return fmt.Errorf("todo not found or does not belong to the note")
+    now := time.Now().Format("2006-01-02 15:04:05")
+    completedAt := ""
+    if isDone {
+        completedAt = now
     }
 
-    return nil
+    return r.db.Model(&entities.ToDo{}).
+        Where("id = ?", todoID).
+        Updates(map[string]interface{}{
+            "is_done":      isDone,
+            "completed_at": completedAt,
+            "updated_at":   now,
+        }).Error
+}
+
+// orderTodoItems เรียงรายการ ToDo ตามตำแหน่งที่ผู้ใช้จัดไว้
+func orderTodoItems(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
+
+func (r *GormNoteRepository) GetTodoItem(noteID uint, todoID uint) (*entities.ToDo, error) {
+	var todo entities.ToDo
+	if err := r.db.Where("id = ? AND note_id = ?", todoID, noteID).First(&todo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("todo item not found")
+		}
+		return nil, err
+	}
+	return &todo, nil
+}
+
+func (r *GormNoteRepository) CreateTodoItem(todo *entities.ToDo) error {
+	if err := r.db.Create(todo).Error; err != nil {
+		return fmt.Errorf("failed to create todo item: %v", err)
+	}
+	return nil
+}
+
+func (r *GormNoteRepository) UpdateTodoItem(todo *entities.ToDo) error {
+	if err := r.db.Save(todo).Error; err != nil {
+		return fmt.Errorf("failed to update todo item: %v", err)
+	}
+	return nil
+}
+
+func (r *GormNoteRepository) DeleteTodoItems(noteID uint, todoIDs []uint) error {
+	if len(todoIDs) == 0 {
+		return nil
+	}
+	if err := r.db.Where("note_id = ? AND id IN ?", noteID, todoIDs).Delete(&entities.ToDo{}).Error; err != nil {
+		return fmt.Errorf("failed to delete todo items: %v", err)
+	}
+	return nil
+}
+
+// ReorderTodoItems ตั้งตำแหน่งตามลำดับของ todoIDs
+func (r *GormNoteRepository) ReorderTodoItems(noteID uint, todoIDs []uint) error {
+	now := time.Now().Format("2006-01-02 15:04:05")
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i, todoID := range todoIDs {
+			if err := tx.Model(&entities.ToDo{}).Where("id = ? AND note_id = ?", todoID, noteID).
+				Updates(map[string]interface{}{"position": i, "updated_at": now}).Error; err != nil {
+				return fmt.Errorf("failed to reorder todo items: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// SetAllTodoItemsDone ทำเครื่องหมายหรือยกเลิกทุกรายการ ToDo ของโน้ต ไม่แตะสถานะของโน้ต (ใช้ SyncNoteCompletion ต่อ)
+func (r *GormNoteRepository) SetAllTodoItemsDone(noteID uint, isDone bool) error {
+	now := time.Now().Format("2006-01-02 15:04:05")
+	completedAt := ""
+	if isDone {
+		completedAt = now
+	}
+	if err := r.db.Model(&entities.ToDo{}).Where("note_id = ? AND is_done = ?", noteID, !isDone).
+		Updates(map[string]interface{}{"is_done": isDone, "completed_at": completedAt, "updated_at": now}).Error; err != nil {
+		return fmt.Errorf("failed to update todo items: %v", err)
+	}
+	return nil
+}
+
+// SyncNoteCompletion คำนวณ is_all_done จากรายการ ToDo คืนค่า true ถ้าโน้ตเพิ่งเสร็จครบในครั้งนี้
+// โน้ตที่ไม่มีรายการ ToDo เหลือแล้วจะถูกล้างสถานะเสร็จ ผู้เรียกจึงควรเรียกเฉพาะโน้ตที่มีหรือเคยมีรายการ ToDo
+func (r *GormNoteRepository) SyncNoteCompletion(noteID uint) (bool, error) {
+	completed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// ล็อกแถวของโน้ตไว้ คำขอที่เปลี่ยนรายการพร้อมกันจะได้ไม่รายงานว่าโน้ตเสร็จซ้ำ
+		var note entities.Note
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("note_id", "is_all_done", "completed_at").First(&note, noteID).Error; err != nil {
+			return err
+		}
+
+		var total, done int64
+		if err := tx.Model(&entities.ToDo{}).Where("note_id = ?", noteID).Count(&total).Error; err != nil {
+			return fmt.Errorf("failed to count todo items: %v", err)
+		}
+		if total == 0 {
+			// เปลี่ยนจากรายการ ToDo เป็นเนื้อหาธรรมดาแล้ว ล้างสถานะเสร็จที่ค้างอยู่
+			if !note.IsAllDone && note.CompletedAt == "" {
+				return nil
+			}
+			if err := tx.Model(&entities.Note{}).Where("note_id = ?", noteID).
+				Updates(map[string]interface{}{"is_all_done": false, "completed_at": ""}).Error; err != nil {
+				return fmt.Errorf("failed to update note status: %v", err)
+			}
+			return nil
+		}
+		if err := tx.Model(&entities.ToDo{}).Where("note_id = ? AND is_done = ?", noteID, true).Count(&done).Error; err != nil {
+			return fmt.Errorf("failed to count todo items: %v", err)
+		}
+
+		allDone := done == total
+		if allDone == note.IsAllDone {
+			return nil
+		}
+		completedAt := ""
+		if allDone {
+			completedAt = time.Now().Format("2006-01-02 15:04:05")
+		}
+		// เปลี่ยนเฉพาะเมื่อสถานะยังเป็นค่าเดิม คำขอที่เปลี่ยนได้จริงเท่านั้นที่รายงานว่าเสร็จ
+		result := tx.Model(&entities.Note{}).Where("note_id = ? AND is_all_done = ?", noteID, !allDone).
+			Updates(map[string]interface{}{"is_all_done": allDone, "completed_at": completedAt})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update note status: %v", result.Error)
+		}
+		completed = allDone && result.RowsAffected > 0
+		return nil
+	})
+	return completed, err
+}
+
+// GetAcceptedShareUserIDs ผู้ที่ตอบรับการแชร์โน้ตโดยตรงแล้ว
+func (r *GormNoteRepository) GetAcceptedShareUserIDs(noteID uint) ([]uint, error) {
+	var userIDs []uint
+	if err := r.db.Model(&entities.ShareNote{}).Where("note_id = ? AND status = ?", noteID, "accepted").Pluck("shared_with", &userIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch shared users: %v", err)
+	}
+	return userIDs, nil
 }
 
 func (r *GormNoteRepository) DeleteNoteById(noteID uint) error {
@@ -258,20 +602,13 @@ func (r *GormNoteRepository) RestoreNoteById(noteID uint) error {
 }
 
 func (r *GormNoteRepository) AddTagToNote(noteID uint, tagID uint, userID uint) error {
-	// ตรวจสอบว่า Note เป็นของ User หรือไม่
-	var note entities.Note
-	if err := r.db.Where("note_id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
-		return fmt.Errorf("note not found or does not belong to the user")
-	}
-
-	// ตรวจสอบว่า Tag เป็นของ User หรือไม่
-	var tag entities.Tag
-	if err := r.db.Where("tag_id = ? AND user_id = ?", tagID, userID).First(&tag).Error; err != nil {
-		return fmt.Errorf("tag not found or does not belong to the user")
+	note, tag, err := r.findNoteAndTagForUser(noteID, tagID, userID)
+	if err != nil {
+		return err
 	}
 
 	// เพิ่ม Tag เข้า Note
-	if err := r.db.Model(&note).Association("Tags").Append(&tag); err != nil {
+	if err := r.db.Model(note).Association("Tags").Append(tag); err != nil {
 		return fmt.Errorf("failed to add tag to note: %v", err)
 	}
 
@@ -279,33 +616,57 @@ func (r *GormNoteRepository) AddTagToNote(noteID uint, tagID uint, userID uint)
 }
 
 func (r *GormNoteRepository) RemoveTagFromNote(noteID uint, tagID uint, userID uint) error {
-	// ตรวจสอบว่า Note เป็นของ User หรือไม่
-	var note entities.Note
-	if err := r.db.Where("note_id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
-		return fmt.Errorf("note not found or does not belong to the user")
-	}
-
-	// ตรวจสอบว่า Tag เป็นของ User หรือไม่
-	var tag entities.Tag
-	if err := r.db.Where("tag_id = ? AND user_id = ?", tagID, userID).First(&tag).Error; err != nil {
-		return fmt.Errorf("tag not found or does not belong to the user")
+	note, tag, err := r.findNoteAndTagForUser(noteID, tagID, userID)
+	if err != nil {
+		return err
 	}
 
 	// ลบ Tag ออกจาก Note
-	if err := r.db.Model(&note).Association("Tags").Delete(&tag); err != nil {
+	if err := r.db.Model(note).Association("Tags").Delete(tag); err != nil {
 		return fmt.Errorf("failed to remove tag from note: %v", err)
 	}
 
 	return nil
 }
 
+// findNoteAndTagForUser ตรวจสอบสิทธิ์ก่อนจัดการแท็กของโน้ต
+// โน้ตต้องเป็นของ User หรืออยู่ในเวิร์กสเปซที่ User แก้ไขได้
+// แท็กต้องเป็นของ User หรือเป็นแท็กของเวิร์กสเปซเดียวกับโน้ต
+func (r *GormNoteRepository) findNoteAndTagForUser(noteID uint, tagID uint, userID uint) (*entities.Note, *entities.Tag, error) {
+	var note entities.Note
+	if err := r.db.Where("note_id = ?", noteID).First(&note).Error; err != nil {
+		return nil, nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+	if note.UserID != userID {
+		isEditor, err := isWorkspaceMemberOfNote(r.db, noteID, userID, workspaceEditorRoles)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !isEditor {
+			return nil, nil, fmt.Errorf("note not found or does not belong to the user")
+		}
+	}
+
+	var tag entities.Tag
+	if err := r.db.Where("tag_id = ?", tagID).First(&tag).Error; err != nil {
+		return nil, nil, fmt.Errorf("tag not found or does not belong to the user")
+	}
+	isOwnTag := tag.UserID == userID && tag.WorkspaceID == nil
+	isWorkspaceTag := tag.WorkspaceID != nil && note.WorkspaceID != nil && *tag.WorkspaceID == *note.WorkspaceID
+	if !isOwnTag && !isWorkspaceTag {
+		return nil, nil, fmt.Errorf("tag not found or does not belong to the user")
+	}
+
+	return &note, &tag, nil
+}
+
 func (r *GormNoteRepository) GetNoteByIdAndUser(noteID uint, userID uint) (*entities.Note, error) {
 	var note entities.Note
 	if err := r.db.Where("note_id = ? AND user_id = ?", noteID, userID).
 		Preload("Tags").
 		Preload("Reminder").
 		Preload("Event").
-		Preload("TodoItems").
+		Preload("TodoItems", orderTodoItems).
 		First(&note).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("note not found or does not belong to the user")
@@ -317,7 +678,7 @@ func (r *GormNoteRepository) GetNoteByIdAndUser(noteID uint, userID uint) (*enti
 
 func (r *GormNoteRepository) IsNoteOwnedByUser(noteID uint, userID uint) (bool, error) {
 	var count int64
-	err := r.db.Model(&entities.Note{}).Where("id = ? AND user_id = ?", noteID, userID).Count(&count).Error
+	err := r.db.Model(&entities.Note{}).Where("note_id = ? AND user_id = ?", noteID, userID).Count(&count).Error
 	if err != nil {
 		return false, err
 	}
@@ -335,13 +696,114 @@ func (r *GormNoteRepository) IsUserAllowedToAccessNote(noteID uint, userID uint)
 		return true, nil
 	}
 
-	// ตรวจสอบว่า Note ถูกแชร์ให้ User หรือไม่
-	err = r.db.Model(&entities.ShareNote{}).Where("note_id = ? AND shared_with = ?", noteID, userID).Count(&count).Error
+	// ตรวจสอบว่า Note ถูกแชร์ให้ User และผู้ใช้ตอบรับแล้วหรือไม่
+	err = r.db.Model(&entities.ShareNote{}).Where("note_id = ? AND shared_with = ? AND status = ?", noteID, userID, "accepted").Count(&count).Error
 	if err != nil {
 		return false, err
 	}
+	if count > 0 {
+		return true, nil
+	}
 
-	return count > 0, nil
+	// ตรวจสอบว่า Note ถูกแชร์ให้กลุ่มที่ User เป็นสมาชิกหรือไม่
+	err = groupSharedNoteQuery(r.db, userID, nil).Where("group_shares.note_id = ?", noteID).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	// ตรวจสอบว่า User เป็นสมาชิกของเวิร์กสเปซที่ Note อยู่หรือไม่
+	return isWorkspaceMemberOfNote(r.db, noteID, userID, nil)
+}
+
+// GetAccessibleNoteIDs รหัสโน้ตทุกใบที่ผู้ใช้เข้าถึงได้ ทั้งของตัวเอง ที่แชร์มา (ตรงหรือผ่านกลุ่ม) และในเวิร์กสเปซ
+// รวมโน้ตที่อยู่ในถังขยะหรือในคลังด้วย เพื่อให้ผู้ร่วมแก้ไขได้รับกิจกรรมเมื่อเจ้าของย้ายโน้ตลงถังขยะหรือเก็บเข้าคลัง
+func (r *GormNoteRepository) GetAccessibleNoteIDs(userID uint) ([]uint, error) {
+	var noteIDs []uint
+	if err := r.db.Model(&entities.Note{}).Where("user_id = ?", userID).Pluck("note_id", &noteIDs).Error; err != nil {
+		return nil, err
+	}
+
+	var sharedNoteIDs []uint
+	if err := r.db.Model(&entities.ShareNote{}).Where("shared_with = ? AND status = ?", userID, "accepted").Pluck("note_id", &sharedNoteIDs).Error; err != nil {
+		return nil, err
+	}
+	noteIDs = append(noteIDs, sharedNoteIDs...)
+
+	groupNoteIDs, err := groupSharedNoteIDs(r.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	noteIDs = append(noteIDs, groupNoteIDs...)
+
+	var workspaceNoteIDs []uint
+	if err := r.db.Table("notes").
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = notes.workspace_id").
+		Where("workspace_members.user_id = ?", userID).
+		Pluck("notes.note_id", &workspaceNoteIDs).Error; err != nil {
+		return nil, err
+	}
+	noteIDs = append(noteIDs, workspaceNoteIDs...)
+
+	seen := make(map[uint]bool, len(noteIDs))
+	unique := noteIDs[:0]
+	for _, id := range noteIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
+
+func (r *GormNoteRepository) UpdateNoteWorkspace(noteID uint, workspaceID *uint) error {
+	result := r.db.Model(&entities.Note{}).
+		Where("note_id = ?", noteID).
+		Updates(map[string]interface{}{
+			"workspace_id": workspaceID,
+			"updated_at":   time.Now().Format("2006-01-02 15:04:05"),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to move note: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("note not found")
+	}
+	return nil
+}
+
+// GetArchivedNotesByUserID โน้ตของผู้ใช้ที่เก็บเข้าคลังไว้ (ไม่รวมที่อยู่ในถังขยะ)
+func (r *GormNoteRepository) GetArchivedNotesByUserID(userID uint) ([]entities.Note, error) {
+	var notes []entities.Note
+	if err := r.db.Where("user_id = ? AND deleted_at = ? AND archived_at <> ?", userID, "", "").
+		Preload("Tags").
+		Preload("Reminder").
+		Preload("TodoItems", orderTodoItems).
+		Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// UpdateNoteArchivedAt เก็บโน้ตเข้าคลัง หรือส่งค่าว่างเพื่อนำออกจากคลัง
+func (r *GormNoteRepository) UpdateNoteArchivedAt(noteID uint, archivedAt string) error {
+	result := r.db.Model(&entities.Note{}).Where("note_id = ? AND deleted_at = ?", noteID, "").Update("archived_at", archivedAt)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update note: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("note not found")
+	}
+	return nil
+}
+
+// Transaction เรียก fn ด้วย repository ที่ผูกกับ transaction เดียวกัน ถ้า fn คืน error จะ rollback ทั้งหมด
+func (r *GormNoteRepository) Transaction(fn func(txRepo repository.NoteRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&GormNoteRepository{db: tx})
+	})
 }
 
 func (r *GormNoteRepository) GetDeletedNotesByUserID(userID uint) ([]entities.Note, error) {
@@ -350,7 +812,7 @@ func (r *GormNoteRepository) GetDeletedNotesByUserID(userID uint) ([]entities.No
 	if err := r.db.Where("user_id = ? AND deleted_at != ?", userID, "").
     Preload("Tags").
     Preload("Reminder").
-    Preload("TodoItems").
+    Preload("TodoItems", orderTodoItems).
     Find(&notes).Error; err != nil {
     return nil, err
 }
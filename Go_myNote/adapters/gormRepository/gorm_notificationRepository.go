@@ -0,0 +1,80 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GormNotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewGormNotificationRepository(db *gorm.DB) *GormNotificationRepository {
+	return &GormNotificationRepository{db: db}
+}
+
+func (r *GormNotificationRepository) CreateNotification(notification *entities.Notification) error {
+	if err := r.db.Create(notification).Error; err != nil {
+		return fmt.Errorf("failed to create notification: %v", err)
+	}
+	return nil
+}
+
+// GetNotificationsByUserID การแจ้งเตือนของผู้ใช้ เรียงจากใหม่ไปเก่า
+func (r *GormNotificationRepository) GetNotificationsByUserID(userID uint, unreadOnly bool, limit int) ([]entities.Notification, error) {
+	var notifications []entities.Notification
+	query := r.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+	if err := query.Order("notification_id DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch notifications: %v", err)
+	}
+	return notifications, nil
+}
+
+func (r *GormNotificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&entities.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %v", err)
+	}
+	return count, nil
+}
+
+func (r *GormNotificationRepository) MarkAsRead(notificationID uint, userID uint) error {
+	var notification entities.Notification
+	if err := r.db.Where("notification_id = ? AND user_id = ?", notificationID, userID).First(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("notification not found")
+		}
+		return err
+	}
+	if notification.IsRead {
+		return nil
+	}
+
+	if err := r.db.Model(&notification).Updates(map[string]interface{}{
+		"is_read": true,
+		"read_at": time.Now().Format("2006-01-02 15:04:05"),
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update notification: %v", err)
+	}
+	return nil
+}
+
+// MarkAllAsRead คืนจำนวนการแจ้งเตือนที่ถูกเปลี่ยนเป็นอ่านแล้ว
+func (r *GormNotificationRepository) MarkAllAsRead(userID uint) (int64, error) {
+	result := r.db.Model(&entities.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{
+			"is_read": true,
+			"read_at": time.Now().Format("2006-01-02 15:04:05"),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to update notifications: %v", result.Error)
+	}
+	return result.RowsAffected, nil
+}
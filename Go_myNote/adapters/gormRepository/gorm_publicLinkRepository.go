@@ -0,0 +1,78 @@
+package gormRepository
+
+import (
+	"errors"
+	"fmt"
+	"miw/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GormPublicLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewGormPublicLinkRepository(db *gorm.DB) *GormPublicLinkRepository {
+	return &GormPublicLinkRepository{db: db}
+}
+
+func (r *GormPublicLinkRepository) CreatePublicLink(link *entities.PublicLink) error {
+	if err := r.db.Create(link).Error; err != nil {
+		return fmt.Errorf("failed to create public link: %v", err)
+	}
+	return nil
+}
+
+func (r *GormPublicLinkRepository) GetPublicLinkByID(linkID uint) (*entities.PublicLink, error) {
+	var link entities.PublicLink
+	if err := r.db.First(&link, linkID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("link not found")
+		}
+		return nil, fmt.Errorf("failed to fetch public link: %v", err)
+	}
+	return &link, nil
+}
+
+func (r *GormPublicLinkRepository) GetPublicLinkBySlug(slug string) (*entities.PublicLink, error) {
+	var link entities.PublicLink
+	if err := r.db.Where("slug = ?", slug).First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("link not found")
+		}
+		return nil, fmt.Errorf("failed to fetch public link: %v", err)
+	}
+	return &link, nil
+}
+
+func (r *GormPublicLinkRepository) GetPublicLinksByNoteID(noteID uint) ([]entities.PublicLink, error) {
+	var links []entities.PublicLink
+	if err := r.db.Where("note_id = ?", noteID).Order("link_id DESC").Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch public links: %v", err)
+	}
+	return links, nil
+}
+
+func (r *GormPublicLinkRepository) RevokePublicLink(linkID uint) error {
+	result := r.db.Model(&entities.PublicLink{}).
+		Where("link_id = ? AND revoked_at = ?", linkID, "").
+		Update("revoked_at", time.Now().Format("2006-01-02 15:04:05"))
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke public link: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("link not found or already revoked")
+	}
+	return nil
+}
+
+// เพิ่มจำนวนการเข้าชมด้วยคำสั่งเดียว เพื่อไม่ให้นับหายเมื่อมีคนเปิดพร้อมกัน
+func (r *GormPublicLinkRepository) IncrementViewCount(linkID uint) error {
+	if err := r.db.Model(&entities.PublicLink{}).
+		Where("link_id = ?", linkID).
+		Update("view_count", gorm.Expr("view_count + 1")).Error; err != nil {
+		return fmt.Errorf("failed to update view count: %v", err)
+	}
+	return nil
+}
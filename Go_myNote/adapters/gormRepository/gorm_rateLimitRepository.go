@@ -0,0 +1,44 @@
+package gormRepository
+
+import (
+	"errors"
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormRateLimitRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRateLimitRepository(db *gorm.DB) *GormRateLimitRepository {
+	return &GormRateLimitRepository{db: db}
+}
+
+// ดึงสถานะของ key ถ้ายังไม่เคยมีให้คืนค่าว่างกลับไป
+func (r *GormRateLimitRepository) GetRateLimit(key string) (*entities.RateLimit, error) {
+	var rateLimit entities.RateLimit
+	if err := r.db.Where("key = ?", key).First(&rateLimit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &entities.RateLimit{Key: key}, nil
+		}
+		return nil, fmt.Errorf("failed to fetch rate limit: %v", err)
+	}
+	return &rateLimit, nil
+}
+
+func (r *GormRateLimitRepository) SaveRateLimit(rateLimit *entities.RateLimit) error {
+	if err := r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(rateLimit).Error; err != nil {
+		return fmt.Errorf("failed to save rate limit: %v", err)
+	}
+	return nil
+}
+
+func (r *GormRateLimitRepository) DeleteRateLimit(key string) error {
+	if err := r.db.Where("key = ?", key).Delete(&entities.RateLimit{}).Error; err != nil {
+		return fmt.Errorf("failed to delete rate limit: %v", err)
+	}
+	return nil
+}
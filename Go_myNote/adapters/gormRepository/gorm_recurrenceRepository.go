@@ -0,0 +1,76 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormRecurrenceRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRecurrenceRepository(db *gorm.DB) *GormRecurrenceRepository {
+	return &GormRecurrenceRepository{db: db}
+}
+
+func (r *GormRecurrenceRepository) GetRecurrenceByID(recurrenceID uint) (*entities.NoteRecurrence, error) {
+	var recurrence entities.NoteRecurrence
+	if err := r.db.First(&recurrence, recurrenceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("recurrence not found")
+		}
+		return nil, err
+	}
+	return &recurrence, nil
+}
+
+func (r *GormRecurrenceRepository) GetRecurrenceByNoteID(noteID uint) (*entities.NoteRecurrence, error) {
+	var recurrence entities.NoteRecurrence
+	if err := r.db.Where("note_id = ?", noteID).First(&recurrence).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("recurrence not found")
+		}
+		return nil, err
+	}
+	return &recurrence, nil
+}
+
+func (r *GormRecurrenceRepository) GetAllRecurrences() ([]entities.NoteRecurrence, error) {
+	var recurrences []entities.NoteRecurrence
+	if err := r.db.Find(&recurrences).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch recurrences: %v", err)
+	}
+	return recurrences, nil
+}
+
+// SaveRecurrence สร้างใหม่ถ้ายังไม่มี RecurrenceID ไม่เช่นนั้นบันทึกทับ
+func (r *GormRecurrenceRepository) SaveRecurrence(recurrence *entities.NoteRecurrence) error {
+	if err := r.db.Save(recurrence).Error; err != nil {
+		return fmt.Errorf("failed to save recurrence: %v", err)
+	}
+	return nil
+}
+
+func (r *GormRecurrenceRepository) DeleteRecurrence(recurrenceID uint) error {
+	if err := r.db.Delete(&entities.NoteRecurrence{}, recurrenceID).Error; err != nil {
+		return fmt.Errorf("failed to delete recurrence: %v", err)
+	}
+	return nil
+}
+
+func (r *GormRecurrenceRepository) CreateInstance(instance *entities.RecurrenceInstance) error {
+	if err := r.db.Create(instance).Error; err != nil {
+		return fmt.Errorf("failed to save recurrence history: %v", err)
+	}
+	return nil
+}
+
+func (r *GormRecurrenceRepository) GetInstancesByRecurrenceID(recurrenceID uint) ([]entities.RecurrenceInstance, error) {
+	var instances []entities.RecurrenceInstance
+	if err := r.db.Where("recurrence_id = ?", recurrenceID).Order("instance_id DESC").Find(&instances).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch recurrence history: %v", err)
+	}
+	return instances, nil
+}
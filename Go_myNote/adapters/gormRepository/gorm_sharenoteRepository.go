@@ -3,7 +3,10 @@ package gormRepository
 import (
     "fmt"
     "gorm.io/gorm"
+    "gorm.io/gorm/clause"
     "miw/entities"
+    "strconv"
+    "time"
 )
 
 type GormShareNoteRepository struct {
@@ -26,22 +29,32 @@ func (r *GormShareNoteRepository) GetUserByEmail(email string) (*entities.User,
     return &user, nil
 }
 
-// Share a note with a user
+// Share a note with a user (รอผู้รับตอบรับ)
 func (r *GormShareNoteRepository) ShareNoteWithUser(noteID, sharedWith uint) error {
-    share := entities.ShareNote{
-        NoteID:     noteID,
-        SharedWith: sharedWith,
-    }
-    if err := r.db.Create(&share).Error; err != nil {
-        return fmt.Errorf("failed to share note: %v", err)
-    }
-    return nil
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        // ถ้าผู้รับเคยปฏิเสธไว้ ให้ลบของเดิมก่อนแชร์ใหม่
+        if err := tx.Where("note_id = ? AND shared_with = ? AND status = ?", noteID, sharedWith, "declined").Delete(&entities.ShareNote{}).Error; err != nil {
+            return fmt.Errorf("failed to clear declined share: %v", err)
+        }
+
+        share := entities.ShareNote{
+            NoteID:     noteID,
+            SharedWith: sharedWith,
+            Permission: "editor",
+            Status:     "pending",
+            CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
+        }
+        if err := tx.Create(&share).Error; err != nil {
+            return fmt.Errorf("failed to share note: %v", err)
+        }
+        return nil
+    })
 }
 
-// Check if a note is shared with a user
+// Check if a note is shared with a user (รอตอบรับหรือตอบรับแล้ว)
 func (r *GormShareNoteRepository) IsNoteSharedWithUser(noteID, userID uint) (bool, error) {
     var count int64
-    if err := r.db.Model(&entities.ShareNote{}).Where("note_id = ? AND shared_with = ?", noteID, userID).Count(&count).Error; err != nil {
+    if err := r.db.Model(&entities.ShareNote{}).Where("note_id = ? AND shared_with = ? AND status <> ?", noteID, userID, "declined").Count(&count).Error; err != nil {
         return false, fmt.Errorf("failed to check shared status: %v", err)
     }
     return count > 0, nil
@@ -58,12 +71,26 @@ func (r *GormShareNoteRepository) IsUserAllowedToEdit(noteID uint, userID uint)
         return true, nil
     }
 
-    err = r.db.Model(&entities.ShareNote{}).Where("note_id = ? AND shared_with = ?", noteID, userID).Count(&count).Error
+    // ผู้ที่ได้รับแชร์แบบ viewer แก้ไขไม่ได้
+    err = r.db.Model(&entities.ShareNote{}).Where("note_id = ? AND shared_with = ? AND permission <> ? AND status = ?", noteID, userID, "viewer", "accepted").Count(&count).Error
     if err != nil {
         return false, err
     }
+    if count > 0 {
+        return true, nil
+    }
 
-    return count > 0, nil
+    // สมาชิกกลุ่มที่ได้รับแชร์แบบ editor แก้ไขได้
+    err = groupSharedNoteQuery(r.db, userID, []string{"editor"}).Where("group_shares.note_id = ?", noteID).Count(&count).Error
+    if err != nil {
+        return false, err
+    }
+    if count > 0 {
+        return true, nil
+    }
+
+    // สมาชิกเวิร์กสเปซที่ไม่ใช่ viewer แก้ไขโน้ตในเวิร์กสเปซได้
+    return isWorkspaceMemberOfNote(r.db, noteID, userID, workspaceEditorRoles)
 }
 
 func (r *GormShareNoteRepository) ShareNoteWithEmail(noteID uint, ownerID uint, email string) ([]map[string]string, error) {
@@ -159,10 +186,11 @@ func (r *GormShareNoteRepository) GetSharedEmailsByNoteID(noteID uint) ([]map[st
 
     // ดึงข้อมูลอีเมลของผู้ที่แชร์โน้ตด้วย
     var sharedUsers []struct {
-        Email string
+        Email  string
+        Status string
     }
     err = r.db.Table("users").
-        Select("users.email").
+        Select("users.email, share_notes.status").
         Joins("JOIN share_notes ON users.user_id = share_notes.shared_with").
         Where("share_notes.note_id = ?", noteID).
         Find(&sharedUsers).Error
@@ -171,14 +199,344 @@ func (r *GormShareNoteRepository) GetSharedEmailsByNoteID(noteID uint) ([]map[st
     }
 
     // รวมอีเมลเจ้าของพร้อมระบุว่าเป็น "owner"
-    sharedEmails = append(sharedEmails, map[string]string{"email": ownerEmail, "type": "owner"})
+    sharedEmails = append(sharedEmails, map[string]string{"email": ownerEmail, "type": "owner", "status": "accepted"})
 
-    // รวมอีเมลของผู้ใช้ที่แชร์
+    // รวมอีเมลของผู้ใช้ที่แชร์ พร้อมสถานะการตอบรับ
     for _, user := range sharedUsers {
-        sharedEmails = append(sharedEmails, map[string]string{"email": user.Email, "type": "shared"})
+        sharedEmails = append(sharedEmails, map[string]string{"email": user.Email, "type": "shared", "status": user.Status})
+    }
+
+    // รวมอีเมลที่ได้รับคำเชิญแต่ยังไม่ได้สมัครสมาชิก
+    var pendingEmails []string
+    err = r.db.Model(&entities.ShareInvitation{}).
+        Where("note_id = ? AND accepted_at = ?", noteID, "").
+        Pluck("email", &pendingEmails).Error
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch pending invitations: %v", err)
+    }
+    for _, email := range pendingEmails {
+        sharedEmails = append(sharedEmails, map[string]string{"email": email, "type": "invited", "status": "pending"})
+    }
+
+    // รวมกลุ่มที่ได้รับแชร์ โดยใช้ชื่อกลุ่มแทนอีเมล
+    var groups []struct {
+        GroupID    uint
+        Name       string
+        Permission string
+    }
+    err = r.db.Table("group_shares").
+        Select("contact_groups.group_id, contact_groups.name, group_shares.permission").
+        Joins("JOIN contact_groups ON contact_groups.group_id = group_shares.group_id").
+        Where("group_shares.note_id = ?", noteID).
+        Find(&groups).Error
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch group shares: %v", err)
+    }
+    for _, group := range groups {
+        sharedEmails = append(sharedEmails, map[string]string{
+            "group_id":   strconv.FormatUint(uint64(group.GroupID), 10),
+            "group_name": group.Name,
+            "type":       "group",
+            "status":     "accepted",
+        })
     }
 
     return sharedEmails, nil
 }
 
+// TransferNoteOwnership โอนโน้ตให้ผู้ร่วมแก้ไข เจ้าของเดิมจะกลายเป็นผู้ได้รับแชร์แบบ editor
+// แท็กของเจ้าของเดิมบนโน้ตจะถูกจับคู่กับแท็กชื่อเดียวกันของเจ้าของใหม่ (สร้างให้ถ้ายังไม่มี)
+func (r *GormShareNoteRepository) TransferNoteOwnership(noteID uint, oldOwnerID uint, newOwnerID uint) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        // ลบการแชร์ของเจ้าของใหม่ และเพิ่มเจ้าของเดิมเป็นผู้ได้รับแชร์
+        if err := tx.Where("note_id = ? AND shared_with = ?", noteID, newOwnerID).Delete(&entities.ShareNote{}).Error; err != nil {
+            return fmt.Errorf("failed to remove share of new owner: %v", err)
+        }
+        share := entities.ShareNote{
+            NoteID:     noteID,
+            SharedWith: oldOwnerID,
+            Permission: "editor",
+            Status:     "accepted",
+            CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
+        }
+        if err := tx.Create(&share).Error; err != nil {
+            return fmt.Errorf("failed to share note with previous owner: %v", err)
+        }
+
+        // เปลี่ยนเจ้าของโน้ต
+        result := tx.Model(&entities.Note{}).
+            Where("note_id = ? AND user_id = ?", noteID, oldOwnerID).
+            Updates(map[string]interface{}{
+                "user_id":    newOwnerID,
+                "updated_at": time.Now().Format("2006-01-02 15:04:05"),
+            })
+        if result.Error != nil {
+            return fmt.Errorf("failed to transfer note: %v", result.Error)
+        }
+        if result.RowsAffected == 0 {
+            return fmt.Errorf("note not found or does not belong to the owner")
+        }
+
+        // จับคู่แท็กส่วนตัวของเจ้าของเดิมไปยังแท็กของเจ้าของใหม่ แท็กของเวิร์กสเปซใช้ต่อได้เลย
+        return remapPersonalNoteTags(tx, []uint{noteID}, oldOwnerID, newOwnerID)
+    })
+}
+
+func (r *GormShareNoteRepository) CreateInvitation(invitation *entities.ShareInvitation) error {
+    if err := r.db.Create(invitation).Error; err != nil {
+        return fmt.Errorf("failed to create invitation: %v", err)
+    }
+    return nil
+}
+
+func (r *GormShareNoteRepository) GetInvitationByID(invitationID uint) (*entities.ShareInvitation, error) {
+    var invitation entities.ShareInvitation
+    if err := r.db.First(&invitation, invitationID).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return nil, fmt.Errorf("invitation not found")
+        }
+        return nil, err
+    }
+    return &invitation, nil
+}
+
+func (r *GormShareNoteRepository) GetInvitationByToken(token string) (*entities.ShareInvitation, error) {
+    var invitation entities.ShareInvitation
+    if err := r.db.Where("token = ?", token).First(&invitation).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return nil, fmt.Errorf("invitation not found")
+        }
+        return nil, err
+    }
+    return &invitation, nil
+}
+
+func (r *GormShareNoteRepository) GetPendingInvitationsByNoteID(noteID uint) ([]entities.ShareInvitation, error) {
+    var invitations []entities.ShareInvitation
+    if err := r.db.Where("note_id = ? AND accepted_at = ?", noteID, "").Order("invitation_id").Find(&invitations).Error; err != nil {
+        return nil, fmt.Errorf("failed to fetch invitations: %v", err)
+    }
+    return invitations, nil
+}
+
+func (r *GormShareNoteRepository) HasPendingInvitation(noteID uint, email string) (bool, error) {
+    var count int64
+    if err := r.db.Model(&entities.ShareInvitation{}).
+        Where("note_id = ? AND LOWER(email) = LOWER(?) AND accepted_at = ?", noteID, email, "").
+        Count(&count).Error; err != nil {
+        return false, fmt.Errorf("failed to check invitation: %v", err)
+    }
+    return count > 0, nil
+}
+
+func (r *GormShareNoteRepository) DeleteInvitation(invitationID uint) error {
+    if err := r.db.Delete(&entities.ShareInvitation{}, invitationID).Error; err != nil {
+        return fmt.Errorf("failed to delete invitation: %v", err)
+    }
+    return nil
+}
+
+// AcceptInvitationsForUser แปลงคำเชิญที่ค้างอยู่ของอีเมลนี้เป็นการแชร์ให้ผู้ใช้ที่เพิ่งสมัคร
+func (r *GormShareNoteRepository) AcceptInvitationsForUser(email string, userID uint) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        var invitations []entities.ShareInvitation
+        if err := tx.Where("LOWER(email) = LOWER(?) AND accepted_at = ?", email, "").Find(&invitations).Error; err != nil {
+            return fmt.Errorf("failed to fetch invitations: %v", err)
+        }
+
+        now := time.Now().Format("2006-01-02 15:04:05")
+        for _, invitation := range invitations {
+            // ข้ามถ้าแชร์ไว้แล้ว
+            var count int64
+            if err := tx.Model(&entities.ShareNote{}).Where("note_id = ? AND shared_with = ? AND status <> ?", invitation.NoteID, userID, "declined").Count(&count).Error; err != nil {
+                return fmt.Errorf("failed to check shared status: %v", err)
+            }
+            if count == 0 {
+                // ผู้ใช้ต้องตอบรับการแชร์เองในกล่องข้อความ
+                share := entities.ShareNote{
+                    NoteID:     invitation.NoteID,
+                    SharedWith: userID,
+                    Permission: "editor",
+                    Status:     "pending",
+                    CreatedAt:  now,
+                }
+                if err := tx.Create(&share).Error; err != nil {
+                    return fmt.Errorf("failed to share note: %v", err)
+                }
+            }
+
+            if err := tx.Model(&entities.ShareInvitation{}).
+                Where("invitation_id = ?", invitation.InvitationID).
+                Update("accepted_at", now).Error; err != nil {
+                return fmt.Errorf("failed to update invitation: %v", err)
+            }
+        }
+
+        return nil
+    })
+}
+
+func (r *GormShareNoteRepository) GetUserByID(userID uint) (*entities.User, error) {
+    var user entities.User
+    if err := r.db.First(&user, userID).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return nil, fmt.Errorf("user not found")
+        }
+        return nil, err
+    }
+    return &user, nil
+}
+
+func (r *GormShareNoteRepository) GetShareByID(shareID uint) (*entities.ShareNote, error) {
+    var share entities.ShareNote
+    if err := r.db.First(&share, shareID).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return nil, fmt.Errorf("share not found")
+        }
+        return nil, err
+    }
+    return &share, nil
+}
+
+func (r *GormShareNoteRepository) GetShare(noteID uint, userID uint) (*entities.ShareNote, error) {
+    var share entities.ShareNote
+    if err := r.db.Where("note_id = ? AND shared_with = ?", noteID, userID).First(&share).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return nil, fmt.Errorf("share not found")
+        }
+        return nil, err
+    }
+    return &share, nil
+}
+
+func (r *GormShareNoteRepository) UpdateShareStatus(shareID uint, status string) error {
+    if err := r.db.Model(&entities.ShareNote{}).Where("share_note_id = ?", shareID).Update("status", status).Error; err != nil {
+        return fmt.Errorf("failed to update share status: %v", err)
+    }
+    return nil
+}
 
+func (r *GormShareNoteRepository) DeleteShare(shareID uint) error {
+    if err := r.db.Delete(&entities.ShareNote{}, shareID).Error; err != nil {
+        return fmt.Errorf("failed to delete share: %v", err)
+    }
+    return nil
+}
+
+// GetIncomingShares ดึงโน้ตที่คนอื่นแชร์ให้ผู้ใช้ตามสถานะ
+func (r *GormShareNoteRepository) GetIncomingShares(userID uint, status string) ([]entities.IncomingShare, error) {
+    var shares []entities.IncomingShare
+    if err := r.db.Table("share_notes").
+        Select("share_notes.share_note_id, share_notes.note_id, notes.title AS note_title, notes.user_id AS owner_id, users.email AS owner_email, share_notes.permission, share_notes.status, share_notes.created_at").
+        Joins("JOIN notes ON notes.note_id = share_notes.note_id").
+        Joins("JOIN users ON users.user_id = notes.user_id").
+        Where("share_notes.shared_with = ? AND share_notes.status = ? AND notes.deleted_at = ?", userID, status, "").
+        Order("share_notes.share_note_id DESC").
+        Scan(&shares).Error; err != nil {
+        return nil, fmt.Errorf("failed to fetch incoming shares: %v", err)
+    }
+
+    // รวมโน้ตที่แชร์ผ่านกลุ่ม สถานะมาจากการตอบรับของผู้ใช้ ยังไม่มีคือรอตอบรับ
+    var groupShares []entities.IncomingShare
+    query := r.db.Table("group_shares").
+        Select("group_shares.note_id, notes.title AS note_title, notes.user_id AS owner_id, users.email AS owner_email, group_shares.permission, ? AS status, group_shares.created_at, group_shares.group_id, contact_groups.name AS group_name", status).
+        Joins("JOIN contact_group_members ON contact_group_members.group_id = group_shares.group_id").
+        Joins("JOIN contact_groups ON contact_groups.group_id = group_shares.group_id").
+        Joins("JOIN notes ON notes.note_id = group_shares.note_id").
+        Joins("JOIN users ON users.user_id = notes.user_id").
+        Joins("LEFT JOIN group_share_responses ON group_share_responses.note_id = group_shares.note_id AND group_share_responses.user_id = contact_group_members.user_id").
+        Where("contact_group_members.user_id = ? AND notes.user_id <> ? AND notes.deleted_at = ?", userID, userID, "")
+    if status == "pending" {
+        query = query.Where("group_share_responses.response_id IS NULL")
+    } else {
+        query = query.Where("group_share_responses.status = ?", status)
+    }
+    if err := query.Order("group_shares.group_share_id DESC").Scan(&groupShares).Error; err != nil {
+        return nil, fmt.Errorf("failed to fetch incoming group shares: %v", err)
+    }
+    return append(shares, groupShares...), nil
+}
+
+// ShareNoteWithGroup แชร์โน้ตให้กลุ่ม สมาชิกแต่ละคนต้องตอบรับก่อนจึงจะเข้าถึงได้
+// สมาชิกที่เคยปฏิเสธหรือออกจากโน้ตนี้จะกลับไปรอตอบรับใหม่
+func (r *GormShareNoteRepository) ShareNoteWithGroup(noteID uint, groupID uint, permission string) error {
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        share := entities.GroupShare{
+            NoteID:     noteID,
+            GroupID:    groupID,
+            Permission: permission,
+            CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
+        }
+        if err := tx.Create(&share).Error; err != nil {
+            return fmt.Errorf("failed to share note with group: %v", err)
+        }
+        if err := tx.Where("note_id = ? AND status = ? AND user_id IN (SELECT user_id FROM contact_group_members WHERE group_id = ?)", noteID, "declined", groupID).
+            Delete(&entities.GroupShareResponse{}).Error; err != nil {
+            return fmt.Errorf("failed to clear declined group shares: %v", err)
+        }
+        return nil
+    })
+}
+
+func (r *GormShareNoteRepository) IsNoteSharedWithGroup(noteID uint, groupID uint) (bool, error) {
+    var count int64
+    if err := r.db.Model(&entities.GroupShare{}).Where("note_id = ? AND group_id = ?", noteID, groupID).Count(&count).Error; err != nil {
+        return false, err
+    }
+    return count > 0, nil
+}
+
+func (r *GormShareNoteRepository) RemoveGroupShare(noteID uint, groupID uint) error {
+    result := r.db.Where("note_id = ? AND group_id = ?", noteID, groupID).Delete(&entities.GroupShare{})
+    if result.Error != nil {
+        return fmt.Errorf("failed to remove group share: %v", result.Error)
+    }
+    if result.RowsAffected == 0 {
+        return fmt.Errorf("group share not found")
+    }
+    return nil
+}
+
+// HasGroupShareAccess ผู้ใช้เห็นโน้ตผ่านกลุ่มใดกลุ่มหนึ่งหรือไม่ (ตอบรับแล้ว)
+func (r *GormShareNoteRepository) HasGroupShareAccess(noteID uint, userID uint) (bool, error) {
+	var count int64
+	if err := groupSharedNoteQuery(r.db, userID, nil).Where("group_shares.note_id = ?", noteID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// pendingGroupShareQuery การแชร์ผ่านกลุ่มที่ผู้ใช้เป็นสมาชิกแต่ยังไม่ได้ตอบรับหรือปฏิเสธ ไม่รวมโน้ตของตัวเอง
+func pendingGroupShareQuery(db *gorm.DB, userID uint) *gorm.DB {
+	return db.Table("group_shares").
+		Joins("JOIN contact_group_members ON contact_group_members.group_id = group_shares.group_id").
+		Joins("JOIN notes ON notes.note_id = group_shares.note_id").
+		Where("contact_group_members.user_id = ? AND notes.user_id <> ?", userID, userID).
+		Where("NOT EXISTS (SELECT 1 FROM group_share_responses WHERE group_share_responses.note_id = group_shares.note_id AND group_share_responses.user_id = ?)", userID)
+}
+
+// HasPendingGroupShare มีโน้ตที่แชร์ผ่านกลุ่มรอผู้ใช้ตอบรับอยู่หรือไม่
+func (r *GormShareNoteRepository) HasPendingGroupShare(noteID uint, userID uint) (bool, error) {
+	var count int64
+	if err := pendingGroupShareQuery(r.db, userID).Where("group_shares.note_id = ?", noteID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SetGroupShareResponse บันทึกการตอบรับหรือปฏิเสธโน้ตที่แชร์ผ่านกลุ่ม ใช้กับทุกกลุ่มที่แชร์โน้ตนี้ให้ผู้ใช้
+func (r *GormShareNoteRepository) SetGroupShareResponse(noteID uint, userID uint, status string) error {
+	response := entities.GroupShareResponse{
+		NoteID:    noteID,
+		UserID:    userID,
+		Status:    status,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "note_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status", "created_at"}),
+	}).Create(&response).Error; err != nil {
+		return fmt.Errorf("failed to respond to group share: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormStatsRepository struct {
+	db *gorm.DB
+}
+
+func NewGormStatsRepository(db *gorm.DB) *GormStatsRepository {
+	return &GormStatsRepository{db: db}
+}
+
+// activeNotes โน้ตของผู้ใช้ที่ไม่อยู่ในถังขยะ
+func (r *GormStatsRepository) activeNotes(userID uint) *gorm.DB {
+	return r.db.Model(&entities.Note{}).Where("notes.user_id = ? AND notes.deleted_at = ?", userID, "")
+}
+
+func (r *GormStatsRepository) CountNotes(userID uint) (int64, error) {
+	var count int64
+	if err := r.activeNotes(userID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count notes: %v", err)
+	}
+	return count, nil
+}
+
+// CountNotesCreatedPerDay created_at เก็บเป็นข้อความ "2006-01-02 15:04:05" จึงใช้ 10 ตัวแรกเป็นวัน
+func (r *GormStatsRepository) CountNotesCreatedPerDay(userID uint, from string) ([]entities.PeriodCount, error) {
+	var counts []entities.PeriodCount
+	if err := r.activeNotes(userID).
+		Select("LEFT(notes.created_at, 10) AS period, COUNT(*) AS count").
+		Where("notes.created_at >= ?", from).
+		Group("period").
+		Order("period").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count notes per day: %v", err)
+	}
+	return counts, nil
+}
+
+func (r *GormStatsRepository) CountNotesCreatedPerWeek(userID uint, from string) ([]entities.PeriodCount, error) {
+	var counts []entities.PeriodCount
+	if err := r.activeNotes(userID).
+		Select("TO_CHAR(DATE_TRUNC('week', CAST(notes.created_at AS timestamp)), 'YYYY-MM-DD') AS period, COUNT(*) AS count").
+		Where("notes.created_at >= ?", from).
+		Group("period").
+		Order("period").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count notes per week: %v", err)
+	}
+	return counts, nil
+}
+
+func (r *GormStatsRepository) CountTodosCompletedPerDay(userID uint, from string) ([]entities.PeriodCount, error) {
+	var counts []entities.PeriodCount
+	if err := r.db.Model(&entities.ToDo{}).
+		Select("LEFT(to_dos.completed_at, 10) AS period, COUNT(*) AS count").
+		Joins("JOIN notes ON notes.note_id = to_dos.note_id").
+		Where("notes.user_id = ? AND notes.deleted_at = ?", userID, "").
+		Where("to_dos.is_done = ? AND to_dos.completed_at >= ?", true, from).
+		Group("period").
+		Order("period").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count completed todos: %v", err)
+	}
+	return counts, nil
+}
+
+// CountOverdueTodos รายการที่ยังไม่เสร็จและเลยวันครบกำหนดแล้ว รวมรายการที่มอบหมายให้ผู้ใช้ในโน้ตของคนอื่น
+func (r *GormStatsRepository) CountOverdueTodos(userID uint, today string) (int64, error) {
+	var count int64
+	if err := r.db.Model(&entities.ToDo{}).
+		Joins("JOIN notes ON notes.note_id = to_dos.note_id").
+		Where("notes.deleted_at = ?", "").
+		Where("(notes.user_id = ? AND to_dos.assignee_id IS NULL) OR to_dos.assignee_id = ?", userID, userID).
+		Where("to_dos.is_done = ? AND to_dos.due_date <> ? AND to_dos.due_date < ?", false, "", today).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count overdue todos: %v", err)
+	}
+	return count, nil
+}
+
+// GetOverdueReminders การแจ้งเตือนแบบไม่ทำซ้ำที่เลยเวลาแล้ว ในโน้ตที่ยังไม่เสร็จ
+func (r *GormStatsRepository) GetOverdueReminders(userID uint, now string) ([]entities.OverdueReminder, error) {
+	var reminders []entities.OverdueReminder
+	if err := r.db.Model(&entities.Reminder{}).
+		Select("reminders.reminder_id, reminders.note_id, notes.title AS note_title, reminders.reminder_time").
+		Joins("JOIN notes ON notes.note_id = reminders.note_id").
+		Where("notes.user_id = ? AND notes.deleted_at = ? AND notes.is_all_done = ?", userID, "", false).
+		Where("reminders.recurring = ? AND reminders.reminder_time < ?", false, now).
+		Order("reminders.reminder_time").
+		Scan(&reminders).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch overdue reminders: %v", err)
+	}
+	return reminders, nil
+}
+
+func (r *GormStatsRepository) CountNotesByPriority(userID uint) ([]entities.PriorityCount, error) {
+	var counts []entities.PriorityCount
+	if err := r.activeNotes(userID).
+		Select("notes.priority AS priority, COUNT(*) AS count").
+		Group("notes.priority").
+		Order("notes.priority DESC").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count notes by priority: %v", err)
+	}
+	return counts, nil
+}
+
+func (r *GormStatsRepository) CountNotesByColor(userID uint) ([]entities.ColorCount, error) {
+	var counts []entities.ColorCount
+	if err := r.activeNotes(userID).
+		Select("notes.color AS color, COUNT(*) AS count").
+		Group("notes.color").
+		Order("count DESC").
+		Scan(&counts).Error; err != nil {
+		return nil, fmt.Errorf("failed to count notes by color: %v", err)
+	}
+	return counts, nil
+}
+
+// GetTopTags แท็กที่ติดกับโน้ตของผู้ใช้มากที่สุด
+func (r *GormStatsRepository) GetTopTags(userID uint, limit int) ([]entities.TagUsage, error) {
+	var tags []entities.TagUsage
+	if err := r.db.Table("note_tags").
+		Select("tags.tag_id, tags.tag_name, COUNT(*) AS count").
+		Joins("JOIN tags ON tags.tag_id = note_tags.tag_id").
+		Joins("JOIN notes ON notes.note_id = note_tags.note_id").
+		Where("notes.user_id = ? AND notes.deleted_at = ?", userID, "").
+		Group("tags.tag_id, tags.tag_name").
+		Order("count DESC, tags.tag_name").
+		Limit(limit).
+		Scan(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch top tags: %v", err)
+	}
+	return tags, nil
+}
+
+func (r *GormStatsRepository) GetShareStats(userID uint) (*entities.ShareStats, error) {
+	var stats entities.ShareStats
+
+	if err := r.db.Model(&entities.ShareNote{}).
+		Select("COUNT(DISTINCT share_notes.note_id) AS notes_shared_by_me, COUNT(DISTINCT share_notes.shared_with) AS collaborators").
+		Joins("JOIN notes ON notes.note_id = share_notes.note_id").
+		Where("notes.user_id = ? AND notes.deleted_at = ? AND share_notes.status = ?", userID, "", "accepted").
+		Scan(&stats).Error; err != nil {
+		return nil, fmt.Errorf("failed to count shared notes: %v", err)
+	}
+
+	if err := r.db.Model(&entities.ShareNote{}).
+		Joins("JOIN notes ON notes.note_id = share_notes.note_id").
+		Where("share_notes.shared_with = ? AND share_notes.status = ? AND notes.deleted_at = ?", userID, "accepted", "").
+		Count(&stats.SharedWithMe).Error; err != nil {
+		return nil, fmt.Errorf("failed to count notes shared with user: %v", err)
+	}
+
+	if err := r.db.Model(&entities.ShareNote{}).
+		Where("shared_with = ? AND status = ?", userID, "pending").
+		Count(&stats.PendingInbox).Error; err != nil {
+		return nil, fmt.Errorf("failed to count pending shares: %v", err)
+	}
+
+	return &stats, nil
+}
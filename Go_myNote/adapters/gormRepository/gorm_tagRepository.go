@@ -1,11 +1,22 @@
 package gormRepository
 
 import (
+	"errors"
 	"miw/entities"
 	"gorm.io/gorm"
 	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// tagSubtreeSQL TagID ของแท็กที่ระบุและแท็กย่อยทุกระดับ
+const tagSubtreeSQL = `WITH RECURSIVE subtree AS (
+	SELECT tag_id FROM tags WHERE tag_id IN ?
+	UNION
+	SELECT tags.tag_id FROM tags JOIN subtree ON tags.parent_id = subtree.tag_id
+) SELECT tag_id FROM subtree`
+
 type GormTagRepository struct {
 	db *gorm.DB
 }
@@ -15,46 +26,128 @@ func NewGormTagRepository(db *gorm.DB) *GormTagRepository {
 }
 
 func (r *GormTagRepository) CreateTag(tag *entities.Tag) error {
-    // ตรวจสอบว่าชื่อแท็กซ้ำสำหรับ User เดียวกันหรือไม่
+    // ตรวจสอบว่าชื่อแท็กซ้ำสำหรับ User เดียวกันหรือไม่ (แท็กของเวิร์กสเปซตรวจภายในเวิร์กสเปซ)
     var existingTag entities.Tag
-    if err := r.db.Where("user_id = ? AND tag_name = ?", tag.UserID, tag.TagName).First(&existingTag).Error; err == nil {
+    if tag.WorkspaceID != nil {
+        if err := r.db.Where("workspace_id = ? AND tag_name = ?", *tag.WorkspaceID, tag.TagName).First(&existingTag).Error; err == nil {
+            return fmt.Errorf("tag name '%s' already exists in this workspace", tag.TagName)
+        }
+    } else if err := r.db.Where("user_id = ? AND workspace_id IS NULL AND tag_name = ?", tag.UserID, tag.TagName).First(&existingTag).Error; err == nil {
         return fmt.Errorf("tag name '%s' already exists for this user", tag.TagName)
     }
 
-    // สร้างแท็กใหม่
-    if err := r.db.Create(tag).Error; err != nil {
-        return fmt.Errorf("failed to create tag: %v", err)
-    }
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        // ชื่อแบบลำดับชั้นจะสร้างแท็กแม่ที่ยังไม่มีให้อัตโนมัติ
+        tag.ParentID = nil
+        if i := strings.LastIndex(tag.TagName, "/"); i >= 0 {
+            parent, err := ensureTagPath(tx, tag.UserID, tag.WorkspaceID, tag.TagName[:i])
+            if err != nil {
+                return err
+            }
+            tag.ParentID = &parent.TagID
+        }
 
-    return nil
+        // สร้างแท็กใหม่
+        if err := tx.Create(tag).Error; err != nil {
+            return fmt.Errorf("failed to create tag: %v", err)
+        }
+        return nil
+    })
 }
 
-func (r *GormTagRepository) GetAllTagsByUserId(userID uint) ([]entities.Tag, error) {
-	var tags []entities.Tag
+// tagScope แท็กส่วนตัวของผู้ใช้ หรือแท็กของเวิร์กสเปซ ชื่อแท็กต้องไม่ซ้ำภายในขอบเขตเดียวกัน
+func tagScope(db *gorm.DB, userID uint, workspaceID *uint) *gorm.DB {
+	if workspaceID != nil {
+		return db.Where("workspace_id = ?", *workspaceID)
+	}
+	return db.Where("user_id = ? AND workspace_id IS NULL", userID)
+}
 
-	// ดึงแท็กที่ผู้ใช้เป็นเจ้าของโดยตรง
-	if err := r.db.Where("user_id = ?", userID).Find(&tags).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch user's own tags: %v", err)
+// ensureTagPath หาแท็กตามเส้นทาง เช่น "work/projects" โดยสร้างระดับที่ยังไม่มี แล้วคืนแท็กระดับสุดท้าย
+func ensureTagPath(db *gorm.DB, userID uint, workspaceID *uint, path string) (*entities.Tag, error) {
+	var parentID *uint
+	var tag entities.Tag
+	segments := strings.Split(path, "/")
+	for i := range segments {
+		name := strings.Join(segments[:i+1], "/")
+		tag = entities.Tag{}
+		err := tagScope(db.Model(&entities.Tag{}), userID, workspaceID).Where("tag_name = ?", name).First(&tag).Error
+		if err == gorm.ErrRecordNotFound {
+			tag = entities.Tag{TagName: name, UserID: userID, WorkspaceID: workspaceID, ParentID: parentID}
+			if err := db.Create(&tag).Error; err != nil {
+				return nil, fmt.Errorf("failed to create parent tag '%s': %w", name, err)
+			}
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to find parent tag '%s': %v", name, err)
+		}
+		tagID := tag.TagID
+		parentID = &tagID
 	}
+	return &tag, nil
+}
 
-	// ดึงโน้ตที่แชร์กับผู้ใช้
-	var sharedNoteIDs []uint
-	if err := r.db.Model(&entities.ShareNote{}).Where("shared_with = ?", userID).Pluck("note_id", &sharedNoteIDs).Error; err != nil {
-		return nil, fmt.Errorf("failed to fetch shared notes: %v", err)
+// remapPersonalNoteTags ย้ายแท็กส่วนตัวของเจ้าของเดิมบนโน้ตไปเป็นแท็กชื่อเดียวกันของเจ้าของใหม่
+// ใช้แท็กที่เจ้าของใหม่มีอยู่แล้วถ้ามี แท็กแบบลำดับชั้นจะสร้างแท็กแม่ให้ด้วย แท็กของเวิร์กสเปซและของผู้อื่นไม่ถูกแตะ
+func remapPersonalNoteTags(tx *gorm.DB, noteIDs []uint, oldOwnerID uint, newOwnerID uint) error {
+	var oldTags []entities.Tag
+	if err := tx.Where("user_id = ? AND workspace_id IS NULL AND tag_id IN (SELECT tag_id FROM note_tags WHERE note_id IN ?)", oldOwnerID, noteIDs).
+		Find(&oldTags).Error; err != nil {
+		return fmt.Errorf("failed to fetch note tags: %v", err)
 	}
 
-	if len(sharedNoteIDs) > 0 {
-		// ดึงแท็กที่เกี่ยวข้องกับโน้ตที่แชร์
-		var sharedTags []entities.Tag
-		if err := r.db.Joins("JOIN note_tags ON tags.tag_id = note_tags.tag_id").
-			Where("note_tags.note_id IN ?", sharedNoteIDs).
-			Find(&sharedTags).Error; err != nil {
-			return nil, fmt.Errorf("failed to fetch tags from shared notes: %v", err)
+	for _, oldTag := range oldTags {
+		newTag, err := ensureTagPath(tx, newOwnerID, nil, oldTag.TagName)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return fmt.Errorf("tag name '%s' already exists for the new owner", oldTag.TagName)
+			}
+			return fmt.Errorf("failed to prepare tag '%s' for new owner: %v", oldTag.TagName, err)
 		}
 
-		// รวมแท็กที่เกี่ยวข้องกับโน้ตที่แชร์
-		tags = append(tags, sharedTags...)
+		if err := tx.Exec("INSERT INTO note_tags (note_id, tag_id) SELECT note_id, ? FROM note_tags WHERE tag_id = ? AND note_id IN ? ON CONFLICT DO NOTHING", newTag.TagID, oldTag.TagID, noteIDs).Error; err != nil {
+			return fmt.Errorf("failed to attach tag '%s': %v", newTag.TagName, err)
+		}
+		if err := tx.Exec("DELETE FROM note_tags WHERE tag_id = ? AND note_id IN ?", oldTag.TagID, noteIDs).Error; err != nil {
+			return fmt.Errorf("failed to detach tag '%s': %v", oldTag.TagName, err)
+		}
 	}
+	return nil
+}
+
+// isUniqueViolation ข้อผิดพลาดจาก unique constraint ของ PostgreSQL
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// tagSubtreeIDs TagID ของแท็กที่ระบุรวมกับแท็กย่อยทุกระดับ
+func tagSubtreeIDs(db *gorm.DB, tagIDs []uint) ([]uint, error) {
+	var ids []uint
+	if err := db.Raw(tagSubtreeSQL, tagIDs).Scan(&ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch child tags: %v", err)
+	}
+	return ids, nil
+}
+
+// GetAllTagsByUserId แท็กส่วนตัวของผู้ใช้และแท็กของเวิร์กสเปซที่ผู้ใช้เป็นสมาชิก
+// แท็กของเจ้าของบนโน้ตที่แชร์มาจะแสดงเฉพาะบนโน้ตนั้น ไม่รวมอยู่ในรายการนี้
+func (r *GormTagRepository) GetAllTagsByUserId(userID uint) ([]entities.Tag, error) {
+	var tags []entities.Tag
+
+	// ดึงแท็กส่วนตัวของผู้ใช้
+	if err := r.db.Where("user_id = ? AND workspace_id IS NULL", userID).Order("tag_name").Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch user's own tags: %v", err)
+	}
+
+	// ดึงแท็กของเวิร์กสเปซที่ผู้ใช้เป็นสมาชิก
+	var workspaceTags []entities.Tag
+	if err := r.db.Joins("JOIN workspace_members ON workspace_members.workspace_id = tags.workspace_id").
+		Where("workspace_members.user_id = ?", userID).
+		Order("tags.workspace_id, tags.tag_name").
+		Find(&workspaceTags).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch workspace tags: %v", err)
+	}
+	tags = append(tags, workspaceTags...)
 
 	return tags, nil
 }
@@ -69,10 +162,11 @@ func (r *GormTagRepository) GetTagsByUser(userID uint) ([]entities.Tag, error) {
 }
 
 
-func (r *GormTagRepository) UpdateTagName(tagID, userID uint, newName string) error {
-    // ตรวจสอบว่าแท็กมีอยู่และเป็นของ User นี้หรือไม่
+// UpdateTagName แท็กของเวิร์กสเปซค้นหาด้วย workspace_id เพราะสมาชิกที่แก้ไขได้ไม่จำเป็นต้องเป็นผู้สร้างแท็ก
+func (r *GormTagRepository) UpdateTagName(tagID, userID uint, workspaceID *uint, newName string) error {
+    // ตรวจสอบว่าแท็กมีอยู่ในขอบเขตของ User หรือเวิร์กสเปซนี้หรือไม่
     var tag entities.Tag
-    if err := r.db.Where("tag_id = ? AND user_id = ?", tagID, userID).First(&tag).Error; err != nil {
+    if err := tagScope(r.db, userID, workspaceID).Where("tag_id = ?", tagID).First(&tag).Error; err != nil {
         if err == gorm.ErrRecordNotFound {
             return fmt.Errorf("tag not found or does not belong to this user")
         }
@@ -81,30 +175,117 @@ func (r *GormTagRepository) UpdateTagName(tagID, userID uint, newName string) er
 
     // ตรวจสอบว่าชื่อใหม่ซ้ำหรือไม่
     var existingTag entities.Tag
-    if err := r.db.Where("user_id = ? AND tag_name = ?", userID, newName).First(&existingTag).Error; err == nil {
+    if tag.WorkspaceID != nil {
+        if err := r.db.Where("workspace_id = ? AND tag_name = ?", *tag.WorkspaceID, newName).First(&existingTag).Error; err == nil {
+            return fmt.Errorf("tag name '%s' already exists in this workspace", newName)
+        }
+    } else if err := r.db.Where("user_id = ? AND workspace_id IS NULL AND tag_name = ?", userID, newName).First(&existingTag).Error; err == nil {
         return fmt.Errorf("tag name '%s' already exists for this user", newName)
     }
 
-    // อัปเดตชื่อแท็ก
-    if err := r.db.Model(&tag).Update("tag_name", newName).Error; err != nil {
-        return fmt.Errorf("failed to update tag name: %v", err)
+    // ย้ายแท็กไปไว้ใต้แท็กย่อยของตัวเองไม่ได้
+    if strings.HasPrefix(newName, tag.TagName+"/") {
+        return fmt.Errorf("a tag cannot be moved under itself")
     }
 
-    return nil
+    return r.db.Transaction(func(tx *gorm.DB) error {
+        descendantIDs, err := tagSubtreeIDs(tx, []uint{tag.TagID})
+        if err != nil {
+            return err
+        }
+
+        // ชื่อใหม่ของแท็กย่อยต้องไม่ชนกับแท็กอื่นที่อยู่นอกลำดับชั้นนี้
+        var descendants []entities.Tag
+        if err := tx.Where("tag_id IN ? AND tag_id <> ?", descendantIDs, tag.TagID).Find(&descendants).Error; err != nil {
+            return fmt.Errorf("failed to fetch child tags: %v", err)
+        }
+        childNames := make([]string, len(descendants))
+        for i, child := range descendants {
+            childNames[i] = newName + strings.TrimPrefix(child.TagName, tag.TagName)
+        }
+        if len(childNames) > 0 {
+            var existingChild entities.Tag
+            err := tagScope(tx.Model(&entities.Tag{}), tag.UserID, tag.WorkspaceID).
+                Where("tag_name IN ? AND tag_id NOT IN ?", childNames, descendantIDs).
+                First(&existingChild).Error
+            if err == nil {
+                if tag.WorkspaceID != nil {
+                    return fmt.Errorf("tag name '%s' already exists in this workspace", existingChild.TagName)
+                }
+                return fmt.Errorf("tag name '%s' already exists for this user", existingChild.TagName)
+            } else if err != gorm.ErrRecordNotFound {
+                return fmt.Errorf("error checking child tag names: %v", err)
+            }
+        }
+
+        var parentID *uint
+        if i := strings.LastIndex(newName, "/"); i >= 0 {
+            parent, err := ensureTagPath(tx, tag.UserID, tag.WorkspaceID, newName[:i])
+            if err != nil {
+                return err
+            }
+            parentID = &parent.TagID
+        }
+
+        // อัปเดตชื่อแท็กและแท็กแม่
+        if err := tx.Model(&tag).Updates(map[string]interface{}{"tag_name": newName, "parent_id": parentID}).Error; err != nil {
+            return fmt.Errorf("failed to update tag name: %v", err)
+        }
+
+        // แท็กย่อยทุกระดับเปลี่ยนชื่อส่วนต้นตาม เช่น work/alpha เป็น job/alpha
+        for i, child := range descendants {
+            if err := tx.Model(&entities.Tag{}).Where("tag_id = ?", child.TagID).Update("tag_name", childNames[i]).Error; err != nil {
+                return fmt.Errorf("failed to rename child tag '%s': %v", child.TagName, err)
+            }
+        }
+        return nil
+    })
+}
+
+// UpdateTagStyle เปลี่ยนสีหรือไอคอนของแท็ก ค่า nil คือไม่เปลี่ยน
+func (r *GormTagRepository) UpdateTagStyle(tagID, userID uint, workspaceID *uint, color, icon *string) error {
+	updates := map[string]interface{}{}
+	if color != nil {
+		updates["color"] = *color
+	}
+	if icon != nil {
+		updates["icon"] = *icon
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	result := tagScope(r.db.Model(&entities.Tag{}), userID, workspaceID).Where("tag_id = ?", tagID).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update tag: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tag not found or does not belong to this user")
+	}
+	return nil
 }
 
 
 
-func (r *GormTagRepository) DeleteTag(tagID, userID uint) error {
-    // ตรวจสอบว่าแท็กมีอยู่และเป็นของ User นี้หรือไม่
+func (r *GormTagRepository) DeleteTag(tagID, userID uint, workspaceID *uint) error {
+    // ตรวจสอบว่าแท็กมีอยู่ในขอบเขตของ User หรือเวิร์กสเปซนี้หรือไม่
     var tag entities.Tag
-    if err := r.db.Where("tag_id = ? AND user_id = ?", tagID, userID).First(&tag).Error; err != nil {
+    if err := tagScope(r.db, userID, workspaceID).Where("tag_id = ?", tagID).First(&tag).Error; err != nil {
         if err == gorm.ErrRecordNotFound {
             return fmt.Errorf("tag not found or does not belong to this user")
         }
         return fmt.Errorf("error finding tag: %v", err)
     }
 
+    // ต้องลบหรือรวมแท็กย่อยก่อน
+    var children int64
+    if err := r.db.Model(&entities.Tag{}).Where("parent_id = ?", tag.TagID).Count(&children).Error; err != nil {
+        return fmt.Errorf("failed to check child tags: %v", err)
+    }
+    if children > 0 {
+        return fmt.Errorf("tag has child tags")
+    }
+
     // ลบแท็ก
     if err := r.db.Delete(&tag).Error; err != nil {
         return fmt.Errorf("failed to delete tag: %v", err)
@@ -123,4 +304,58 @@ func (r *GormTagRepository) GetTagById(tagID uint) (*entities.Tag, error) {
 	return &tag, nil
 }
 
+// MergeTags ย้ายโน้ตทั้งหมดของแท็กต้นทางไปที่แท็กปลายทาง แล้วลบแท็กต้นทาง ทำใน transaction เดียว
+func (r *GormTagRepository) MergeTags(sourceID, targetID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var children int64
+		if err := tx.Model(&entities.Tag{}).Where("parent_id = ?", sourceID).Count(&children).Error; err != nil {
+			return fmt.Errorf("failed to check child tags: %v", err)
+		}
+		if children > 0 {
+			return fmt.Errorf("tag has child tags")
+		}
+		return mergeTagInto(tx, sourceID, targetID)
+	})
+}
+
+// mergeTagInto ย้ายการติดแท็กจากแท็กต้นทางไปแท็กปลายทาง แล้วลบแท็กต้นทาง
+func mergeTagInto(db *gorm.DB, sourceID, targetID uint) error {
+	if err := db.Exec("INSERT INTO note_tags (note_id, tag_id) SELECT note_id, ? FROM note_tags WHERE tag_id = ? ON CONFLICT DO NOTHING", targetID, sourceID).Error; err != nil {
+		return fmt.Errorf("failed to move notes to target tag: %v", err)
+	}
+	if err := db.Exec("DELETE FROM note_tags WHERE tag_id = ?", sourceID).Error; err != nil {
+		return fmt.Errorf("failed to detach source tag: %v", err)
+	}
+	if err := db.Delete(&entities.Tag{}, sourceID).Error; err != nil {
+		return fmt.Errorf("failed to delete source tag: %v", err)
+	}
+	return nil
+}
+
+// detachWorkspaceTags แท็กของเวิร์กสเปซที่ถูกลบกลับเป็นแท็กส่วนตัวของผู้สร้าง
+// ถ้าผู้สร้างมีแท็กส่วนตัวชื่อเดียวกันอยู่แล้วจะรวมเข้ากับแท็กนั้น เพื่อไม่ให้ชื่อซ้ำ
+func detachWorkspaceTags(db *gorm.DB, workspaceID uint) error {
+	var conflicts []struct {
+		WorkspaceTagID uint
+		PersonalTagID  uint
+	}
+	if err := db.Raw(`SELECT w.tag_id AS workspace_tag_id, p.tag_id AS personal_tag_id FROM tags w
+		JOIN tags p ON p.user_id = w.user_id AND p.tag_name = w.tag_name AND p.workspace_id IS NULL
+		WHERE w.workspace_id = ?`, workspaceID).Scan(&conflicts).Error; err != nil {
+		return fmt.Errorf("failed to check workspace tag names: %v", err)
+	}
 
+	for _, conflict := range conflicts {
+		if err := db.Model(&entities.Tag{}).Where("parent_id = ?", conflict.WorkspaceTagID).Update("parent_id", conflict.PersonalTagID).Error; err != nil {
+			return fmt.Errorf("failed to move child tags: %v", err)
+		}
+		if err := mergeTagInto(db, conflict.WorkspaceTagID, conflict.PersonalTagID); err != nil {
+			return err
+		}
+	}
+
+	if err := db.Model(&entities.Tag{}).Where("workspace_id = ?", workspaceID).Update("workspace_id", nil).Error; err != nil {
+		return fmt.Errorf("failed to detach workspace tags: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewGormTemplateRepository(db *gorm.DB) *GormTemplateRepository {
+	return &GormTemplateRepository{db: db}
+}
+
+func (r *GormTemplateRepository) CreateTemplate(template *entities.NoteTemplate) error {
+	if err := r.db.Create(template).Error; err != nil {
+		return fmt.Errorf("failed to create template: %v", err)
+	}
+	return nil
+}
+
+func (r *GormTemplateRepository) GetTemplateByID(templateID uint) (*entities.NoteTemplate, error) {
+	var template entities.NoteTemplate
+	if err := r.db.First(&template, templateID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("template not found")
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *GormTemplateRepository) GetTemplatesByUserID(userID uint) ([]entities.NoteTemplate, error) {
+	var templates []entities.NoteTemplate
+	if err := r.db.Where("user_id = ?", userID).Order("name").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch templates: %v", err)
+	}
+	return templates, nil
+}
+
+func (r *GormTemplateRepository) UpdateTemplate(template *entities.NoteTemplate) error {
+	if err := r.db.Save(template).Error; err != nil {
+		return fmt.Errorf("failed to update template: %v", err)
+	}
+	return nil
+}
+
+func (r *GormTemplateRepository) DeleteTemplate(templateID uint) error {
+	if err := r.db.Delete(&entities.NoteTemplate{}, templateID).Error; err != nil {
+		return fmt.Errorf("failed to delete template: %v", err)
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package gormRepository
 
 import (
+	"fmt"
 	"gorm.io/gorm"
 	"miw/entities"
+	"time"
 )
 
 type GormUserRepository struct {
@@ -47,10 +49,233 @@ func (r *GormUserRepository) GetUserByEmail(email string) (*entities.User, error
 	return &user, nil
 }
 
+// GetUsersByUsername ชื่อผู้ใช้ไม่ unique จึงอาจได้หลายคน
+func (r *GormUserRepository) GetUsersByUsername(username string) ([]entities.User, error) {
+	var users []entities.User
+	if err := r.db.Where("username = ?", username).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 func (r *GormUserRepository) GetUserEmailByID(userID uint) (string, error) {
 	var user entities.User
 	if err := r.db.First(&user, userID).Error; err != nil {
 		return "", err
 	}
 	return user.Email, nil
-}
\ No newline at end of file
+}
+
+// DeleteUser ลบผู้ใช้และข้อมูลทั้งหมดที่เกี่ยวข้องภายใน Transaction เดียว
+// ถ้ากำหนด newOwnerID โน้ตที่แชร์กับผู้ใช้คนนั้นจะถูกโอนให้แทนการลบ
+// คืนค่า ReminderID ที่ถูกลบ เพื่อให้ชั้น Service ยกเลิกการแจ้งเตือนที่ตั้งเวลาไว้
+func (r *GormUserRepository) DeleteUser(userID uint, newOwnerID uint) ([]uint, error) {
+	var reminderIDs []uint
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// โอนโน้ตที่แชร์กับผู้รับช่วงต่อ
+		if newOwnerID != 0 {
+			var transferNoteIDs []uint
+			if err := tx.Model(&entities.ShareNote{}).
+				Joins("JOIN notes ON notes.note_id = share_notes.note_id").
+				Where("notes.user_id = ? AND share_notes.shared_with = ? AND share_notes.status = ?", userID, newOwnerID, "accepted").
+				Pluck("share_notes.note_id", &transferNoteIDs).Error; err != nil {
+				return fmt.Errorf("failed to find notes to transfer: %v", err)
+			}
+
+			if len(transferNoteIDs) > 0 {
+				// แท็กส่วนตัวของเจ้าของเดิมจะถูกลบ จึงย้ายไปเป็นแท็กชื่อเดียวกันของผู้รับช่วงต่อ
+				// แท็กของเวิร์กสเปซและของผู้อื่นบนโน้ตยังอยู่ครบ
+				if err := remapPersonalNoteTags(tx, transferNoteIDs, userID, newOwnerID); err != nil {
+					return err
+				}
+				if err := tx.Where("note_id IN ? AND shared_with = ?", transferNoteIDs, newOwnerID).Delete(&entities.ShareNote{}).Error; err != nil {
+					return fmt.Errorf("failed to remove share of new owner: %v", err)
+				}
+				if err := tx.Model(&entities.Note{}).Where("note_id IN ?", transferNoteIDs).Update("user_id", newOwnerID).Error; err != nil {
+					return fmt.Errorf("failed to transfer notes: %v", err)
+				}
+			}
+		}
+
+		// โน้ตในเวิร์กสเปซของคนอื่นให้เจ้าของเวิร์กสเปซรับช่วงต่อ
+		var workspaceNotes []struct {
+			NoteID  uint
+			OwnerID uint
+		}
+		if err := tx.Table("notes").
+			Select("notes.note_id, workspaces.owner_id").
+			Joins("JOIN workspaces ON workspaces.workspace_id = notes.workspace_id").
+			Where("notes.user_id = ? AND workspaces.owner_id <> ?", userID, userID).
+			Scan(&workspaceNotes).Error; err != nil {
+			return fmt.Errorf("failed to find workspace notes: %v", err)
+		}
+		for _, note := range workspaceNotes {
+			if err := tx.Where("note_id = ? AND shared_with = ?", note.NoteID, note.OwnerID).Delete(&entities.ShareNote{}).Error; err != nil {
+				return fmt.Errorf("failed to remove share of workspace owner: %v", err)
+			}
+			if err := tx.Model(&entities.Note{}).Where("note_id = ?", note.NoteID).Update("user_id", note.OwnerID).Error; err != nil {
+				return fmt.Errorf("failed to hand over workspace note: %v", err)
+			}
+		}
+
+		// ลบเวิร์กสเปซที่ผู้ใช้เป็นเจ้าของ โน้ตของสมาชิกอื่นกลับเป็นโน้ตส่วนตัว
+		var workspaceIDs []uint
+		if err := tx.Model(&entities.Workspace{}).Where("owner_id = ?", userID).Pluck("workspace_id", &workspaceIDs).Error; err != nil {
+			return fmt.Errorf("failed to fetch user's workspaces: %v", err)
+		}
+		if len(workspaceIDs) > 0 {
+			if err := tx.Model(&entities.Note{}).Where("workspace_id IN ?", workspaceIDs).Update("workspace_id", nil).Error; err != nil {
+				return fmt.Errorf("failed to detach workspace notes: %v", err)
+			}
+			for _, workspaceID := range workspaceIDs {
+				if err := detachWorkspaceTags(tx, workspaceID); err != nil {
+					return err
+				}
+			}
+			if err := tx.Where("workspace_id IN ?", workspaceIDs).Delete(&entities.WorkspaceMember{}).Error; err != nil {
+				return fmt.Errorf("failed to delete workspace members: %v", err)
+			}
+			if err := tx.Where("workspace_id IN ?", workspaceIDs).Delete(&entities.Workspace{}).Error; err != nil {
+				return fmt.Errorf("failed to delete workspaces: %v", err)
+			}
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.WorkspaceMember{}).Error; err != nil {
+			return fmt.Errorf("failed to delete workspace memberships: %v", err)
+		}
+
+		// ลบโน้ตทั้งหมดของผู้ใช้ (รวมโน้ตที่อยู่ในถังขยะ) พร้อมข้อมูลที่ผูกอยู่
+		var noteIDs []uint
+		if err := tx.Model(&entities.Note{}).Where("user_id = ?", userID).Pluck("note_id", &noteIDs).Error; err != nil {
+			return fmt.Errorf("failed to fetch user's notes: %v", err)
+		}
+
+		if len(noteIDs) > 0 {
+			if err := tx.Model(&entities.Reminder{}).Where("note_id IN ?", noteIDs).Pluck("reminder_id", &reminderIDs).Error; err != nil {
+				return fmt.Errorf("failed to fetch reminders: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.Reminder{}).Error; err != nil {
+				return fmt.Errorf("failed to delete reminders: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.ToDo{}).Error; err != nil {
+				return fmt.Errorf("failed to delete todo items: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.Event{}).Error; err != nil {
+				return fmt.Errorf("failed to delete events: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.ShareNote{}).Error; err != nil {
+				return fmt.Errorf("failed to delete shares: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.PublicLink{}).Error; err != nil {
+				return fmt.Errorf("failed to delete public links: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.ShareInvitation{}).Error; err != nil {
+				return fmt.Errorf("failed to delete invitations: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.GroupShare{}).Error; err != nil {
+				return fmt.Errorf("failed to delete group shares: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.GroupShareResponse{}).Error; err != nil {
+				return fmt.Errorf("failed to delete group share responses: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.Activity{}).Error; err != nil {
+				return fmt.Errorf("failed to delete activities: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.Comment{}).Error; err != nil {
+				return fmt.Errorf("failed to delete comments: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.Mention{}).Error; err != nil {
+				return fmt.Errorf("failed to delete mentions: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.Notification{}).Error; err != nil {
+				return fmt.Errorf("failed to delete notifications: %v", err)
+			}
+			if err := tx.Where("recurrence_id IN (SELECT recurrence_id FROM note_recurrences WHERE note_id IN ?)", noteIDs).Delete(&entities.RecurrenceInstance{}).Error; err != nil {
+				return fmt.Errorf("failed to delete recurrence history: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.NoteRecurrence{}).Error; err != nil {
+				return fmt.Errorf("failed to delete recurrences: %v", err)
+			}
+			if err := tx.Exec("DELETE FROM note_tags WHERE note_id IN ?", noteIDs).Error; err != nil {
+				return fmt.Errorf("failed to delete note tags: %v", err)
+			}
+			if err := tx.Where("note_id IN ?", noteIDs).Delete(&entities.Note{}).Error; err != nil {
+				return fmt.Errorf("failed to delete notes: %v", err)
+			}
+		}
+
+		// ลบกลุ่มผู้ติดต่อของผู้ใช้ และลบผู้ใช้ออกจากกลุ่มของคนอื่น
+		if err := tx.Where("group_id IN (SELECT group_id FROM contact_groups WHERE owner_id = ?)", userID).Delete(&entities.GroupShare{}).Error; err != nil {
+			return fmt.Errorf("failed to delete group shares: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.GroupShareResponse{}).Error; err != nil {
+			return fmt.Errorf("failed to delete group share responses: %v", err)
+		}
+		if err := tx.Where("group_id IN (SELECT group_id FROM contact_groups WHERE owner_id = ?) OR user_id = ?", userID, userID).Delete(&entities.ContactGroupMember{}).Error; err != nil {
+			return fmt.Errorf("failed to delete group members: %v", err)
+		}
+		if err := tx.Where("owner_id = ?", userID).Delete(&entities.ContactGroup{}).Error; err != nil {
+			return fmt.Errorf("failed to delete contact groups: %v", err)
+		}
+
+		// กิจกรรมที่ไม่ผูกกับโน้ตของผู้ใช้ (เช่น สร้างแท็ก) ไม่มีใครเห็นอีกแล้ว
+		if err := tx.Where("note_id = 0 AND actor_id = ?", userID).Delete(&entities.Activity{}).Error; err != nil {
+			return fmt.Errorf("failed to delete activities: %v", err)
+		}
+
+		// ความคิดเห็นบนโน้ตของคนอื่นลบแบบ soft delete เพื่อไม่ให้เธรดขาด
+		if err := tx.Model(&entities.Comment{}).Where("author_id = ? AND deleted_at = ?", userID, "").
+			Updates(map[string]interface{}{"content": "", "deleted_at": time.Now().Format("2006-01-02 15:04:05")}).Error; err != nil {
+			return fmt.Errorf("failed to delete comments: %v", err)
+		}
+
+		if err := tx.Where("user_id = ? OR author_id = ?", userID, userID).Delete(&entities.Mention{}).Error; err != nil {
+			return fmt.Errorf("failed to delete mentions: %v", err)
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.Notification{}).Error; err != nil {
+			return fmt.Errorf("failed to delete notifications: %v", err)
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.NoteTemplate{}).Error; err != nil {
+			return fmt.Errorf("failed to delete templates: %v", err)
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.SavedView{}).Error; err != nil {
+			return fmt.Errorf("failed to delete views: %v", err)
+		}
+
+		if err := tx.Model(&entities.ToDo{}).Where("assignee_id = ?", userID).Update("assignee_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to unassign todo items: %v", err)
+		}
+
+		// ลบผู้ใช้ออกจากโน้ตที่คนอื่นแชร์มาให้
+		if err := tx.Where("shared_with = ?", userID).Delete(&entities.ShareNote{}).Error; err != nil {
+			return fmt.Errorf("failed to delete incoming shares: %v", err)
+		}
+
+		// แท็กย่อยของผู้ใช้อื่นในเวิร์กสเปซที่อยู่ใต้แท็กของผู้ใช้นี้ จะกลายเป็นแท็กระดับบนสุด
+		if err := tx.Exec("UPDATE tags SET parent_id = NULL WHERE user_id <> ? AND parent_id IN (SELECT tag_id FROM tags WHERE user_id = ?)", userID, userID).Error; err != nil {
+			return fmt.Errorf("failed to detach child tags: %v", err)
+		}
+
+		// ลบแท็กของผู้ใช้ (รวมถึงที่ติดอยู่กับโน้ตของคนอื่น)
+		if err := tx.Exec("DELETE FROM note_tags WHERE tag_id IN (SELECT tag_id FROM tags WHERE user_id = ?)", userID).Error; err != nil {
+			return fmt.Errorf("failed to delete tag links: %v", err)
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&entities.Tag{}).Error; err != nil {
+			return fmt.Errorf("failed to delete tags: %v", err)
+		}
+
+		if err := tx.Delete(&entities.User{}, userID).Error; err != nil {
+			return fmt.Errorf("failed to delete user: %v", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reminderIDs, nil
+}
@@ -0,0 +1,56 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+
+	"gorm.io/gorm"
+)
+
+type GormViewRepository struct {
+	db *gorm.DB
+}
+
+func NewGormViewRepository(db *gorm.DB) *GormViewRepository {
+	return &GormViewRepository{db: db}
+}
+
+func (r *GormViewRepository) CreateView(view *entities.SavedView) error {
+	if err := r.db.Create(view).Error; err != nil {
+		return fmt.Errorf("failed to create view: %v", err)
+	}
+	return nil
+}
+
+func (r *GormViewRepository) GetViewByID(viewID uint) (*entities.SavedView, error) {
+	var view entities.SavedView
+	if err := r.db.First(&view, viewID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("view not found")
+		}
+		return nil, err
+	}
+	return &view, nil
+}
+
+func (r *GormViewRepository) GetViewsByUserID(userID uint) ([]entities.SavedView, error) {
+	var views []entities.SavedView
+	if err := r.db.Where("user_id = ?", userID).Order("name").Find(&views).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch views: %v", err)
+	}
+	return views, nil
+}
+
+func (r *GormViewRepository) UpdateView(view *entities.SavedView) error {
+	if err := r.db.Save(view).Error; err != nil {
+		return fmt.Errorf("failed to update view: %v", err)
+	}
+	return nil
+}
+
+func (r *GormViewRepository) DeleteView(viewID uint) error {
+	if err := r.db.Delete(&entities.SavedView{}, viewID).Error; err != nil {
+		return fmt.Errorf("failed to delete view: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package gormRepository
+
+import (
+	"fmt"
+	"miw/entities"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GormWorkspaceRepository struct {
+	db *gorm.DB
+}
+
+func NewGormWorkspaceRepository(db *gorm.DB) *GormWorkspaceRepository {
+	return &GormWorkspaceRepository{db: db}
+}
+
+// CreateWorkspace สร้างเวิร์กสเปซและเพิ่มผู้สร้างเป็น owner
+func (r *GormWorkspaceRepository) CreateWorkspace(workspace *entities.Workspace) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workspace).Error; err != nil {
+			return fmt.Errorf("failed to create workspace: %v", err)
+		}
+
+		member := entities.WorkspaceMember{
+			WorkspaceID: workspace.WorkspaceID,
+			UserID:      workspace.OwnerID,
+			Role:        "owner",
+			CreatedAt:   workspace.CreatedAt,
+		}
+		if err := tx.Create(&member).Error; err != nil {
+			return fmt.Errorf("failed to add workspace owner: %v", err)
+		}
+		return nil
+	})
+}
+
+func (r *GormWorkspaceRepository) GetWorkspaceByID(workspaceID uint) (*entities.Workspace, error) {
+	var workspace entities.Workspace
+	if err := r.db.First(&workspace, workspaceID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("workspace not found")
+		}
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// GetWorkspacesByUserID ดึงเวิร์กสเปซทั้งหมดที่ผู้ใช้เป็นสมาชิก
+func (r *GormWorkspaceRepository) GetWorkspacesByUserID(userID uint) ([]entities.Workspace, error) {
+	var workspaces []entities.Workspace
+	if err := r.db.Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.workspace_id").
+		Where("workspace_members.user_id = ?", userID).
+		Order("workspaces.workspace_id").
+		Find(&workspaces).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch workspaces: %v", err)
+	}
+	return workspaces, nil
+}
+
+func (r *GormWorkspaceRepository) UpdateWorkspaceName(workspaceID uint, name string) error {
+	result := r.db.Model(&entities.Workspace{}).
+		Where("workspace_id = ?", workspaceID).
+		Updates(map[string]interface{}{
+			"name":       name,
+			"updated_at": time.Now().Format("2006-01-02 15:04:05"),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update workspace: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("workspace not found")
+	}
+	return nil
+}
+
+// DeleteWorkspace ลบเวิร์กสเปซ โน้ตและแท็กในเวิร์กสเปซจะกลับเป็นของส่วนตัวของผู้สร้าง
+func (r *GormWorkspaceRepository) DeleteWorkspace(workspaceID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.Note{}).Where("workspace_id = ?", workspaceID).Update("workspace_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to detach workspace notes: %v", err)
+		}
+		if err := detachWorkspaceTags(tx, workspaceID); err != nil {
+			return err
+		}
+		if err := tx.Where("workspace_id = ?", workspaceID).Delete(&entities.WorkspaceMember{}).Error; err != nil {
+			return fmt.Errorf("failed to delete workspace members: %v", err)
+		}
+		if err := tx.Delete(&entities.Workspace{}, workspaceID).Error; err != nil {
+			return fmt.Errorf("failed to delete workspace: %v", err)
+		}
+		return nil
+	})
+}
+
+func (r *GormWorkspaceRepository) GetMember(workspaceID uint, userID uint) (*entities.WorkspaceMember, error) {
+	var member entities.WorkspaceMember
+	if err := r.db.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).First(&member).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("member not found")
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *GormWorkspaceRepository) GetMembers(workspaceID uint) ([]entities.WorkspaceMemberInfo, error) {
+	var members []entities.WorkspaceMemberInfo
+	if err := r.db.Table("workspace_members").
+		Select("workspace_members.user_id, users.email, users.username, workspace_members.role, workspace_members.created_at").
+		Joins("JOIN users ON users.user_id = workspace_members.user_id").
+		Where("workspace_members.workspace_id = ?", workspaceID).
+		Order("workspace_members.workspace_member_id").
+		Scan(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch workspace members: %v", err)
+	}
+	return members, nil
+}
+
+func (r *GormWorkspaceRepository) AddMember(member *entities.WorkspaceMember) error {
+	if err := r.db.Create(member).Error; err != nil {
+		return fmt.Errorf("failed to add workspace member: %v", err)
+	}
+	return nil
+}
+
+func (r *GormWorkspaceRepository) UpdateMemberRole(workspaceID uint, userID uint, role string) error {
+	result := r.db.Model(&entities.WorkspaceMember{}).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Update("role", role)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update member role: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("member not found")
+	}
+	return nil
+}
+
+func (r *GormWorkspaceRepository) RemoveMember(workspaceID uint, userID uint) error {
+	result := r.db.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).Delete(&entities.WorkspaceMember{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove member: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("member not found")
+	}
+	return nil
+}
+
+func (r *GormWorkspaceRepository) GetWorkspaceNotes(workspaceID uint) ([]entities.Note, error) {
+	var notes []entities.Note
+	if err := r.db.Where("workspace_id = ? AND deleted_at = ?", workspaceID, "").
+		Preload("Tags").
+		Preload("Reminder").
+		Preload("TodoItems", orderTodoItems).
+		Find(&notes).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch workspace notes: %v", err)
+	}
+	return notes, nil
+}
+
+// workspaceEditorRoles บทบาทในเวิร์กสเปซที่แก้ไขโน้ตได้
+var workspaceEditorRoles = []string{"owner", "admin", "editor"}
+
+// isWorkspaceMemberOfNote ตรวจสอบว่าผู้ใช้เป็นสมาชิกเวิร์กสเปซของโน้ต ถ้าระบุ roles จะตรวจบทบาทด้วย
+func isWorkspaceMemberOfNote(db *gorm.DB, noteID uint, userID uint, roles []string) (bool, error) {
+	query := db.Table("notes").
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = notes.workspace_id").
+		Where("notes.note_id = ? AND workspace_members.user_id = ?", noteID, userID)
+	if len(roles) > 0 {
+		query = query.Where("workspace_members.role IN ?", roles)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,48 @@
+package httpHandler
+
+import (
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpActivityHandler struct {
+	activityUseCase service.ActivityUseCase
+}
+
+func NewHttpActivityHandler(useCase service.ActivityUseCase) *HttpActivityHandler {
+	return &HttpActivityHandler{activityUseCase: useCase}
+}
+
+// GetNoteActivityHandler ไทม์ไลน์กิจกรรมของโน้ต รองรับ ?limit=
+func (h *HttpActivityHandler) GetNoteActivityHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.ParseUint(c.Params("noteid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	activities, err := h.activityUseCase.GetNoteActivity(uint(noteID), userID, c.QueryInt("limit"))
+	if err != nil {
+		if err.Error() == "you are not authorized to view this note" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve activity"})
+	}
+
+	return c.JSON(fiber.Map{"activities": activities})
+}
+
+// GetRecentActivityHandler กิจกรรมล่าสุดในโน้ตทั้งหมดของผู้ใช้ รองรับ ?limit=
+func (h *HttpActivityHandler) GetRecentActivityHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	activities, err := h.activityUseCase.GetRecentActivity(userID, c.QueryInt("limit"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve activity"})
+	}
+
+	return c.JSON(fiber.Map{"activities": activities})
+}
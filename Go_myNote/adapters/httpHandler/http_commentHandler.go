@@ -0,0 +1,154 @@
+package httpHandler
+
+import (
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpCommentHandler struct {
+	commentUseCase service.CommentUseCase
+}
+
+func NewHttpCommentHandler(useCase service.CommentUseCase) *HttpCommentHandler {
+	return &HttpCommentHandler{commentUseCase: useCase}
+}
+
+// commentErrorStatus แปลงข้อผิดพลาดของความคิดเห็นเป็น HTTP status
+func commentErrorStatus(err error) int {
+	switch err.Error() {
+	case "comment not found", "parent comment not found", "todo item not found", "note not found":
+		return fiber.StatusNotFound
+	case "comment content is required", "only top-level comments can be resolved":
+		return fiber.StatusBadRequest
+	case "you are not authorized to view this note", "you are not the author of this comment":
+		return fiber.StatusForbidden
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// GetCommentsHandler ความคิดเห็นของโน้ตแบบเป็นเธรด รองรับ ?todo_id=
+func (h *HttpCommentHandler) GetCommentsHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.ParseUint(c.Params("noteid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	var todoID *uint
+	if raw := c.Query("todo_id"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid todo ID"})
+		}
+		id := uint(parsed)
+		todoID = &id
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	threads, err := h.commentUseCase.GetComments(uint(noteID), userID, todoID)
+	if err != nil {
+		return c.Status(commentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"comments": threads})
+}
+
+func (h *HttpCommentHandler) AddCommentHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.ParseUint(c.Params("noteid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	var request struct {
+		Content  string `json:"content"`
+		TodoID   *uint  `json:"todo_id"`
+		ParentID *uint  `json:"parent_id"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	comment, err := h.commentUseCase.AddComment(uint(noteID), userID, request.Content, request.TodoID, request.ParentID)
+	if err != nil {
+		return c.Status(commentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Comment added successfully",
+		"comment": comment,
+	})
+}
+
+func (h *HttpCommentHandler) UpdateCommentHandler(c *fiber.Ctx) error {
+	commentID, err := strconv.ParseUint(c.Params("commentid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid comment ID"})
+	}
+
+	var request struct {
+		Content string `json:"content"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.commentUseCase.UpdateComment(uint(commentID), userID, request.Content); err != nil {
+		return c.Status(commentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Comment updated successfully"})
+}
+
+func (h *HttpCommentHandler) DeleteCommentHandler(c *fiber.Ctx) error {
+	commentID, err := strconv.ParseUint(c.Params("commentid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid comment ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.commentUseCase.DeleteComment(uint(commentID), userID); err != nil {
+		return c.Status(commentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Comment deleted successfully"})
+}
+
+// ResolveCommentHandler ปิดเธรด ส่ง {"resolved": false} เพื่อเปิดใหม่
+func (h *HttpCommentHandler) ResolveCommentHandler(c *fiber.Ctx) error {
+	commentID, err := strconv.ParseUint(c.Params("commentid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid comment ID"})
+	}
+
+	request := struct {
+		Resolved *bool `json:"resolved"`
+	}{}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+	resolved := true
+	if request.Resolved != nil {
+		resolved = *request.Resolved
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.commentUseCase.ResolveComment(uint(commentID), userID, resolved); err != nil {
+		return c.Status(commentErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if resolved {
+		return c.JSON(fiber.Map{"message": "Comment resolved successfully"})
+	}
+	return c.JSON(fiber.Map{"message": "Comment reopened successfully"})
+}
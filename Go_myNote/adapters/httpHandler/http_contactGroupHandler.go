@@ -0,0 +1,163 @@
+package httpHandler
+
+import (
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpContactGroupHandler struct {
+	groupUseCase service.ContactGroupUseCase
+}
+
+func NewHttpContactGroupHandler(useCase service.ContactGroupUseCase) *HttpContactGroupHandler {
+	return &HttpContactGroupHandler{groupUseCase: useCase}
+}
+
+// contactGroupErrorStatus แปลงข้อผิดพลาดของกลุ่มเป็น HTTP status
+func contactGroupErrorStatus(err error) int {
+	switch err.Error() {
+	case "group not found", "member not found", "user not found":
+		return fiber.StatusNotFound
+	case "group name is required", "cannot add the owner to the group":
+		return fiber.StatusBadRequest
+	case "user is already a member of this group":
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+func (h *HttpContactGroupHandler) CreateGroupHandler(c *fiber.Ctx) error {
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	ownerID := c.Locals("user_id").(uint)
+
+	group, err := h.groupUseCase.CreateGroup(ownerID, request.Name)
+	if err != nil {
+		return c.Status(contactGroupErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Group created successfully",
+		"group":   group,
+	})
+}
+
+func (h *HttpContactGroupHandler) GetGroupsHandler(c *fiber.Ctx) error {
+	ownerID := c.Locals("user_id").(uint)
+
+	groups, err := h.groupUseCase.GetGroups(ownerID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve groups"})
+	}
+
+	return c.JSON(fiber.Map{"groups": groups})
+}
+
+func (h *HttpContactGroupHandler) GetGroupHandler(c *fiber.Ctx) error {
+	groupID, err := strconv.ParseUint(c.Params("groupid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid group ID"})
+	}
+
+	ownerID := c.Locals("user_id").(uint)
+
+	group, members, err := h.groupUseCase.GetGroup(uint(groupID), ownerID)
+	if err != nil {
+		return c.Status(contactGroupErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"group":   group,
+		"members": members,
+	})
+}
+
+func (h *HttpContactGroupHandler) RenameGroupHandler(c *fiber.Ctx) error {
+	groupID, err := strconv.ParseUint(c.Params("groupid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid group ID"})
+	}
+
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	ownerID := c.Locals("user_id").(uint)
+
+	if err := h.groupUseCase.RenameGroup(uint(groupID), ownerID, request.Name); err != nil {
+		return c.Status(contactGroupErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Group updated successfully"})
+}
+
+func (h *HttpContactGroupHandler) DeleteGroupHandler(c *fiber.Ctx) error {
+	groupID, err := strconv.ParseUint(c.Params("groupid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid group ID"})
+	}
+
+	ownerID := c.Locals("user_id").(uint)
+
+	if err := h.groupUseCase.DeleteGroup(uint(groupID), ownerID); err != nil {
+		return c.Status(contactGroupErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Group deleted successfully"})
+}
+
+func (h *HttpContactGroupHandler) AddMemberHandler(c *fiber.Ctx) error {
+	groupID, err := strconv.ParseUint(c.Params("groupid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid group ID"})
+	}
+
+	var request struct {
+		Email string `json:"email"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	ownerID := c.Locals("user_id").(uint)
+
+	members, err := h.groupUseCase.AddMember(uint(groupID), ownerID, request.Email)
+	if err != nil {
+		return c.Status(contactGroupErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Member added successfully",
+		"members": members,
+	})
+}
+
+func (h *HttpContactGroupHandler) RemoveMemberHandler(c *fiber.Ctx) error {
+	groupID, err := strconv.ParseUint(c.Params("groupid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid group ID"})
+	}
+	memberID, err := strconv.ParseUint(c.Params("memberid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid member ID"})
+	}
+
+	ownerID := c.Locals("user_id").(uint)
+
+	if err := h.groupUseCase.RemoveMember(uint(groupID), ownerID, uint(memberID)); err != nil {
+		return c.Status(contactGroupErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Member removed successfully"})
+}
@@ -0,0 +1,84 @@
+package httpHandler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"miw/usecases/service"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpEventHandler struct {
+	eventUseCase service.EventUseCase
+}
+
+func NewHttpEventHandler(useCase service.EventUseCase) *HttpEventHandler {
+	return &HttpEventHandler{eventUseCase: useCase}
+}
+
+// StreamEventsHandler ส่งเหตุการณ์การเปลี่ยนแปลงของโน้ตแบบ server-sent events
+// ต่อจากเหตุการณ์ล่าสุดได้ด้วย header Last-Event-ID (หรือ ?last_event_id=)
+func (h *HttpEventHandler) StreamEventsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	lastEventID := c.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var afterID uint64
+	if lastEventID != "" {
+		parsed, err := strconv.ParseUint(lastEventID, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Last-Event-ID"})
+		}
+		afterID = parsed
+	}
+
+	events, cancel, err := h.eventUseCase.StreamEvents(userID, uint(afterID))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to open event stream"})
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		ticker := time.NewTicker(streamKeepAlive)
+		defer ticker.Stop()
+
+		// ส่ง comment แรกทันทีเพื่อให้ไคลเอนต์รู้ว่าเชื่อมต่อสำเร็จ
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.EventID, event.Type, data)
+			case <-ticker.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+			// เขียนไม่สำเร็จแปลว่าผู้ใช้ปิดการเชื่อมต่อแล้ว
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
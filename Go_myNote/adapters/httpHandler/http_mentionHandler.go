@@ -0,0 +1,27 @@
+package httpHandler
+
+import (
+	"miw/usecases/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpMentionHandler struct {
+	mentionUseCase service.MentionUseCase
+}
+
+func NewHttpMentionHandler(useCase service.MentionUseCase) *HttpMentionHandler {
+	return &HttpMentionHandler{mentionUseCase: useCase}
+}
+
+// GetMyMentionsHandler รายการที่ผู้ใช้ถูกกล่าวถึงในโน้ตและความคิดเห็น
+func (h *HttpMentionHandler) GetMyMentionsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	mentions, err := h.mentionUseCase.GetMyMentions(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve mentions"})
+	}
+
+	return c.JSON(fiber.Map{"mentions": mentions})
+}
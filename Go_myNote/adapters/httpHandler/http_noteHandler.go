@@ -11,6 +11,7 @@ import (
 type NoteResponse struct {
 	NoteID    uint                `json:"note_id"`
 	UserID    uint                `json:"user_id"`
+	WorkspaceID *uint             `json:"workspace_id,omitempty"` // ซ่อนถ้าเป็นโน้ตส่วนตัว
 	Title     string              `json:"title"`
 	Content   string              `json:"content,omitempty"` // ซ่อนถ้าไม่มีค่า
 	Color     string              `json:"color"`
@@ -21,6 +22,8 @@ type NoteResponse struct {
 	CreatedAt string              `json:"created_at"`
 	UpdatedAt string              `json:"updated_at"`
 	DeletedAt string              `json:"deleted_at,omitempty"` // ซ่อนถ้าไม่มีค่า
+	ArchivedAt string             `json:"archived_at,omitempty"` // ซ่อนถ้าไม่มีค่า
+	CompletedAt string            `json:"completed_at,omitempty"` // ซ่อนถ้ายังไม่เสร็จ
 	Tags	  []NoteTagResponse   `json:"tags"`
 	Reminder  []entities.Reminder `json:"reminder"`
 	Event     interface{}         `json:"event"`
@@ -28,6 +31,8 @@ type NoteResponse struct {
 type NoteTagResponse struct {
 	TagID   uint   `json:"tag_id"`
 	TagName string `json:"tag_name"`
+	Color   string `json:"color,omitempty"`
+	Icon    string `json:"icon,omitempty"`
 }
 
 type ReminderResponse struct {
@@ -38,9 +43,15 @@ type ReminderResponse struct {
 }
 
 type ToDoResponse struct {
-	ID      uint   `json:"id"`
-	Content string `json:"content"`
-	IsDone  bool   `json:"is_done"`
+	ID          uint   `json:"id"`
+	Content     string `json:"content"`
+	IsDone      bool   `json:"is_done"`
+	Position    int    `json:"position"`
+	DueDate     string `json:"due_date,omitempty"` // ซ่อนถ้าไม่มีกำหนด
+	Priority    int    `json:"priority"`
+	ParentID    *uint  `json:"parent_id,omitempty"`
+	AssigneeID  *uint  `json:"assignee_id,omitempty"`
+	CompletedAt string `json:"completed_at,omitempty"`
 }
 
 type HttpNoteHandler struct {
@@ -76,6 +87,12 @@ func (h *HttpNoteHandler) CreateNoteHandler(c *fiber.Ctx) error {
 
 	// เรียกใช้ฟังก์ชันสร้างโน้ต
 	if err := h.noteUseCase.CreateNote(note); err != nil {
+		if err.Error() == "you are not allowed to add notes to this workspace" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err.Error() == "invalid due date format" || err.Error() == "assignee does not have access to this note" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).SendString("Could not create note")
 	}
 
@@ -99,7 +116,27 @@ func (h *HttpNoteHandler) GetAllNoteHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).SendString("Notes not found for this user")
 	}
 
-	// แปลงผลลัพธ์เป็น JSON Response
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"notes": toNoteResponses(notes),
+	})
+}
+
+func toTodoResponse(todo entities.ToDo) ToDoResponse {
+	return ToDoResponse{
+		ID:          todo.ID,
+		Content:     todo.Content,
+		IsDone:      todo.IsDone,
+		Position:    todo.Position,
+		DueDate:     todo.DueDate,
+		Priority:    todo.Priority,
+		ParentID:    todo.ParentID,
+		AssigneeID:  todo.AssigneeID,
+		CompletedAt: todo.CompletedAt,
+	}
+}
+
+// toNoteResponses แปลงรายการโน้ตเป็นรูปแบบที่ส่งกลับให้ client
+func toNoteResponses(notes []entities.Note) []NoteResponse {
 	var response []NoteResponse
 	for _, note := range notes {
 		// แปลง Tags จาก entities.Tag เป็น NoteTagResponse
@@ -108,22 +145,21 @@ func (h *HttpNoteHandler) GetAllNoteHandler(c *fiber.Ctx) error {
 			tagResponses = append(tagResponses, NoteTagResponse{
 				TagID:   tag.TagID,
 				TagName: tag.TagName,
+				Color:   tag.Color,
+				Icon:    tag.Icon,
 			})
 		}
 
 		// แปลง TodoItems จาก entities.ToDo เป็น ToDoResponse
 		var todoResponses []ToDoResponse
 		for _, todo := range note.TodoItems {
-			todoResponses = append(todoResponses, ToDoResponse{
-				ID:      todo.ID,
-				Content: todo.Content,
-				IsDone:  todo.IsDone,
-			})
+			todoResponses = append(todoResponses, toTodoResponse(todo))
 		}
 
 		response = append(response, NoteResponse{
 			NoteID:    note.NoteID,
 			UserID:    note.UserID,
+			WorkspaceID: note.WorkspaceID,
 			Title:     note.Title,
 			Content:   note.Content,
 			Color:     note.Color,
@@ -134,15 +170,15 @@ func (h *HttpNoteHandler) GetAllNoteHandler(c *fiber.Ctx) error {
 			CreatedAt: note.CreatedAt,
 			UpdatedAt: note.UpdatedAt,
 			DeletedAt: note.DeletedAt,
+			ArchivedAt: note.ArchivedAt,
+			CompletedAt: note.CompletedAt,
 			Tags:      tagResponses, // เปลี่ยนจาก tag เป็น tagResponses
 			Reminder:  note.Reminder,
 			Event:     note.Event,
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"notes": response,
-	})
+	return response
 }
 
 
@@ -208,7 +244,7 @@ func (h *HttpNoteHandler) UpdateTitleAndContentHandler(c *fiber.Ctx) error {
 	data := new(struct {
 		Title     string          `json:"title"`
 		Content   string          `json:"content"`
-		TodoItems []entities.ToDo `json:"todo_items"`
+		TodoItems []entities.TodoItemInput `json:"todo_items"` // ฟิลด์ที่ไม่ได้ส่งมาใช้ค่าเดิม
 	})
 	if err := c.BodyParser(data); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
@@ -227,6 +263,8 @@ func (h *HttpNoteHandler) UpdateTitleAndContentHandler(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Note not found"})
 		case "you are not authorized to update this note":
 			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to update this note"})
+		case "invalid due date format", "assignee does not have access to this note", "parent todo item not found", "todo item cannot be nested under itself":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 		default:
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update title/content"})
 		}
@@ -238,49 +276,9 @@ func (h *HttpNoteHandler) UpdateTitleAndContentHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve updated note"})
 	}
 
-	// แปลงผลลัพธ์เป็น JSON Response
-	var response []NoteResponse
-	for _, note := range notes {
-		// แปลง Tags จาก entities.Tag เป็น NoteTagResponse
-		var tagResponses []NoteTagResponse
-		for _, tag := range note.Tags {
-			tagResponses = append(tagResponses, NoteTagResponse{
-				TagID:   tag.TagID,
-				TagName: tag.TagName,
-			})
-		}
-
-		var todoResponses []ToDoResponse
-		for _, todo := range note.TodoItems {
-			todoResponses = append(todoResponses, ToDoResponse{
-				ID:      todo.ID,
-				Content: todo.Content,
-				IsDone:  todo.IsDone,
-			})
-		}
-
-		response = append(response, NoteResponse{
-			NoteID:    note.NoteID,
-			UserID:    note.UserID,
-			Title:     note.Title,
-			Content:   note.Content,
-			Color:     note.Color,
-			Priority:  note.Priority,
-			IsTodo:    note.IsTodo,
-			IsAllDone: note.IsAllDone,
-			TodoItems: todoResponses,
-			CreatedAt: note.CreatedAt,
-			UpdatedAt: note.UpdatedAt,
-			DeletedAt: note.DeletedAt,
-			Tags:      tagResponses,
-			Reminder:  note.Reminder,
-			Event:     note.Event,
-		})
-	}
-
 	return c.JSON(fiber.Map{
 		"message": "Title/Content updated successfully",
-		"notes":   response,
+		"notes":   toNoteResponses(notes),
 	})
 }
 
@@ -396,7 +394,7 @@ func (h *HttpNoteHandler) UpdateTodoStatusHandler(c *fiber.Ctx) error {
     }
 
     if err := h.noteUseCase.UpdateTodoStatus(uint(noteID), uint(todoID), userID, data.IsDone); err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+        return c.Status(todoErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
     }
 
     return c.JSON(fiber.Map{"message": "Todo status updated successfully"})
@@ -475,16 +473,14 @@ func (h *HttpNoteHandler) GetDeletedNotesHandler(c *fiber.Ctx) error {
 			tagResponses = append(tagResponses, NoteTagResponse{
 				TagID:   tag.TagID,
 				TagName: tag.TagName,
+				Color:   tag.Color,
+				Icon:    tag.Icon,
 			})
 		}
 
 		var todoResponses []ToDoResponse
 		for _, todo := range note.TodoItems {
-			todoResponses = append(todoResponses, ToDoResponse{
-				ID:      todo.ID,
-				Content: todo.Content,
-				IsDone:  todo.IsDone,
-			})
+			todoResponses = append(todoResponses, toTodoResponse(todo))
 		}
 
 		response = append(response, NoteResponse{
@@ -511,4 +507,283 @@ func (h *HttpNoteHandler) GetDeletedNotesHandler(c *fiber.Ctx) error {
 	})
 }
 
+// MoveNoteToWorkspaceHandler ย้ายโน้ตเข้าเวิร์กสเปซ ส่ง workspace_id เป็น null เพื่อย้ายกลับเป็นโน้ตส่วนตัว
+func (h *HttpNoteHandler) MoveNoteToWorkspaceHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	var request struct {
+		WorkspaceID *uint `json:"workspace_id"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	if err := h.noteUseCase.MoveNoteToWorkspace(uint(noteID), userID, request.WorkspaceID); err != nil {
+		switch err.Error() {
+		case "note not found or does not belong to the user", "you are not allowed to add notes to this workspace":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(fiber.Map{"message": "Note moved successfully"})
+}
+
+// maxBatchOperations จำนวนคำสั่งสูงสุดต่อ POST /note/batch
+const maxBatchOperations = 100
+
+// BatchNoteHandler ทำหลายคำสั่งกับหลายโน้ตในครั้งเดียว ถ้าคำสั่งใดล้มเหลวจะไม่มีคำสั่งใดถูกบันทึก
+func (h *HttpNoteHandler) BatchNoteHandler(c *fiber.Ctx) error {
+	var request struct {
+		Operations []entities.NoteBatchOperation `json:"operations"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if len(request.Operations) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "operations are required"})
+	}
+	if len(request.Operations) > maxBatchOperations {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("at most %d operations are allowed", maxBatchOperations)})
+	}
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	results, err := h.noteUseCase.BatchUpdate(userID, request.Operations)
+	if err != nil {
+		if err.Error() == "batch operation failed" {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error":   err.Error(),
+				"results": results,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to apply operations"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Operations applied successfully",
+		"results": results,
+	})
+}
+
+func (h *HttpNoteHandler) ArchiveNoteHandler(c *fiber.Ctx) error {
+	return h.setArchived(c, true)
+}
+
+func (h *HttpNoteHandler) UnarchiveNoteHandler(c *fiber.Ctx) error {
+	return h.setArchived(c, false)
+}
+
+func (h *HttpNoteHandler) setArchived(c *fiber.Ctx, archive bool) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	if archive {
+		err = h.noteUseCase.ArchiveNote(uint(noteID), userID)
+	} else {
+		err = h.noteUseCase.UnarchiveNote(uint(noteID), userID)
+	}
+	if err != nil {
+		switch err.Error() {
+		case "note not found or does not belong to the user":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		case "note is already archived", "note is not archived":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
 
+	if archive {
+		return c.JSON(fiber.Map{"message": "Note archived successfully"})
+	}
+	return c.JSON(fiber.Map{"message": "Note unarchived successfully"})
+}
+
+func (h *HttpNoteHandler) GetArchivedNotesHandler(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("userid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	notes, err := h.noteUseCase.GetArchivedNotes(uint(userID))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve archived notes"})
+	}
+
+	return c.JSON(fiber.Map{"archived_notes": toNoteResponses(notes)})
+}
+
+// DuplicateNoteHandler คัดลอกโน้ตเป็นโน้ตใหม่ของผู้ใช้ รายการ ToDo จะถูกรีเซ็ตเป็นยังไม่เสร็จ
+func (h *HttpNoteHandler) DuplicateNoteHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	note, err := h.noteUseCase.DuplicateNote(uint(noteID), userID)
+	if err != nil {
+		switch err.Error() {
+		case "note not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case "you are not authorized to view this note":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Note duplicated successfully",
+		"note":    toNoteResponses([]entities.Note{*note})[0],
+	})
+}
+
+// todoErrorStatus แปลงข้อผิดพลาดของรายการ ToDo เป็น HTTP status
+func todoErrorStatus(err error) int {
+	switch err.Error() {
+	case "note not found", "todo item not found":
+		return fiber.StatusNotFound
+	case "you are not authorized to update this note":
+		return fiber.StatusForbidden
+	case "todo content is required", "note cannot have both content and todo_items", "invalid due date format",
+		"assignee does not have access to this note", "parent todo item not found", "todo item cannot be nested under itself",
+		"todo_ids must list every todo item of the note":
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// AddTodoItemHandler เพิ่มรายการ ToDo ต่อท้าย body {"content", "due_date", "priority", "parent_id", "assignee_id"}
+func (h *HttpNoteHandler) AddTodoItemHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	todo := new(entities.ToDo)
+	if err := c.BodyParser(todo); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.noteUseCase.AddTodoItem(uint(noteID), userID, todo); err != nil {
+		return c.Status(todoErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Todo item added successfully",
+		"todo":    toTodoResponse(*todo),
+	})
+}
+
+func (h *HttpNoteHandler) UpdateTodoItemHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	todoID, err := strconv.Atoi(c.Params("todoid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid todo ID"})
+	}
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var update entities.TodoItemUpdate
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	todo, err := h.noteUseCase.UpdateTodoItem(uint(noteID), uint(todoID), userID, update)
+	if err != nil {
+		return c.Status(todoErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Todo item updated successfully",
+		"todo":    toTodoResponse(*todo),
+	})
+}
+
+// DeleteTodoItemHandler ลบรายการ ToDo พร้อมรายการย่อย
+func (h *HttpNoteHandler) DeleteTodoItemHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	todoID, err := strconv.Atoi(c.Params("todoid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid todo ID"})
+	}
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	if err := h.noteUseCase.DeleteTodoItem(uint(noteID), uint(todoID), userID); err != nil {
+		return c.Status(todoErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Todo item deleted successfully"})
+}
+
+// ReorderTodoItemsHandler body {"todo_ids": [3, 1, 2]} ต้องมีทุกรายการของโน้ต
+func (h *HttpNoteHandler) ReorderTodoItemsHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	var request struct {
+		TodoIDs []uint `json:"todo_ids"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if err := h.noteUseCase.ReorderTodoItems(uint(noteID), userID, request.TodoIDs); err != nil {
+		return c.Status(todoErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Todo items reordered successfully"})
+}
@@ -3,6 +3,7 @@ package httpHandler
 import (
 	"miw/usecases/service"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -53,6 +54,9 @@ func (h *ShareNoteHandler) ShareNoteHandler(c *fiber.Ctx) error {
 	// แชร์โน้ตและรับรายการอีเมลที่แชร์
 	sharedEmails, err := h.shareNoteUseCase.ShareNoteWithEmail(request.NoteID, ownerID, request.Email)
 	if err != nil {
+		if err.Error() == "invalid email" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -90,3 +94,262 @@ func (h *ShareNoteHandler) RemoveShareHandler(c *fiber.Ctx) error {
 		"shared_emails": sharedEmails,
 	})
 }
+
+func (h *ShareNoteHandler) TransferOwnershipHandler(c *fiber.Ctx) error {
+	var request struct {
+		NoteID uint   `json:"note_id"`
+		Email  string `json:"email"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	ownerID := c.Locals("user_id").(uint)
+
+	// โอนโน้ตและรับรายการอีเมลที่แชร์หลังโอน
+	sharedEmails, err := h.shareNoteUseCase.TransferOwnership(request.NoteID, ownerID, request.Email)
+	if err != nil {
+		switch {
+		case err.Error() == "note not found or does not belong to the user":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		case err.Error() == "new owner must be a collaborator of this note", err.Error() == "cannot transfer note to the owner":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "email not found"):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "email not found"})
+		case strings.HasSuffix(err.Error(), "already exists for the new owner"):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"message":       "Note ownership transferred successfully",
+		"shared_emails": sharedEmails,
+	})
+}
+
+func (h *ShareNoteHandler) GetInvitationsHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.ParseUint(c.Params("noteid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	ownerID := c.Locals("user_id").(uint)
+
+	invitations, err := h.shareNoteUseCase.GetPendingInvitations(uint(noteID), ownerID)
+	if err != nil {
+		if err.Error() == "note not found or does not belong to the user" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve invitations"})
+	}
+
+	return c.JSON(fiber.Map{
+		"invitations": invitations,
+	})
+}
+
+func (h *ShareNoteHandler) RevokeInvitationHandler(c *fiber.Ctx) error {
+	invitationID, err := strconv.ParseUint(c.Params("invitationid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid invitation ID"})
+	}
+
+	ownerID := c.Locals("user_id").(uint)
+
+	if err := h.shareNoteUseCase.RevokeInvitation(uint(invitationID), ownerID); err != nil {
+		switch err.Error() {
+		case "invitation not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Invitation not found"})
+		case "note not found or does not belong to the user":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		case "invitation has already been accepted":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(fiber.Map{"message": "Invitation revoked successfully"})
+}
+
+// GetInvitationInfoHandler ไม่ต้องล็อกอิน ใช้แสดงข้อมูลคำเชิญในหน้าสมัครสมาชิก
+func (h *ShareNoteHandler) GetInvitationInfoHandler(c *fiber.Ctx) error {
+	info, err := h.shareNoteUseCase.GetInvitationInfo(c.Params("token"))
+	if err != nil {
+		if err.Error() == "invitation not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Invitation not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve invitation"})
+	}
+
+	return c.JSON(info)
+}
+
+// GetIncomingSharesHandler กล่องข้อความของผู้รับ ใช้ ?status=pending|accepted|declined
+func (h *ShareNoteHandler) GetIncomingSharesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	shares, err := h.shareNoteUseCase.GetIncomingShares(userID, c.Query("status"))
+	if err != nil {
+		if err.Error() == "invalid status" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve shares"})
+	}
+
+	return c.JSON(shares)
+}
+
+func (h *ShareNoteHandler) AcceptShareHandler(c *fiber.Ctx) error {
+	return h.respondToShare(c, true)
+}
+
+func (h *ShareNoteHandler) DeclineShareHandler(c *fiber.Ctx) error {
+	return h.respondToShare(c, false)
+}
+
+func (h *ShareNoteHandler) respondToShare(c *fiber.Ctx, accept bool) error {
+	shareID, err := strconv.ParseUint(c.Params("shareid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid share ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.shareNoteUseCase.RespondToShare(uint(shareID), userID, accept); err != nil {
+		switch err.Error() {
+		case "share not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Share not found"})
+		case "share has already been answered":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	if accept {
+		return c.JSON(fiber.Map{"message": "Share accepted successfully"})
+	}
+	return c.JSON(fiber.Map{"message": "Share declined successfully"})
+}
+
+// LeaveSharedNoteHandler ผู้รับออกจากโน้ตที่ถูกแชร์มา
+func (h *ShareNoteHandler) LeaveSharedNoteHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.ParseUint(c.Params("noteid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.shareNoteUseCase.LeaveSharedNote(uint(noteID), userID); err != nil {
+		if err.Error() == "share not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Share not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Left the note successfully"})
+}
+
+func (h *ShareNoteHandler) ShareNoteWithGroupHandler(c *fiber.Ctx) error {
+	var request struct {
+		NoteID     uint   `json:"note_id"`
+		GroupID    uint   `json:"group_id"`
+		Permission string `json:"permission"` // editor (ค่าเริ่มต้น) หรือ viewer
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	ownerID := c.Locals("user_id").(uint)
+
+	sharedEmails, err := h.shareNoteUseCase.ShareNoteWithGroup(request.NoteID, ownerID, request.GroupID, request.Permission)
+	if err != nil {
+		switch err.Error() {
+		case "invalid permission":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case "note not found or does not belong to the user":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		case "group not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case "this group has already been shared with the note":
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"message":      "Note shared with group successfully",
+		"share_emails": sharedEmails,
+	})
+}
+
+func (h *ShareNoteHandler) AcceptGroupShareHandler(c *fiber.Ctx) error {
+	return h.respondToGroupShare(c, true)
+}
+
+func (h *ShareNoteHandler) DeclineGroupShareHandler(c *fiber.Ctx) error {
+	return h.respondToGroupShare(c, false)
+}
+
+// respondToGroupShare ตอบรับหรือปฏิเสธโน้ตที่แชร์ผ่านกลุ่ม อ้างอิงด้วย note_id เพราะหนึ่งโน้ตอาจแชร์ผ่านหลายกลุ่ม
+func (h *ShareNoteHandler) respondToGroupShare(c *fiber.Ctx, accept bool) error {
+	noteID, err := strconv.ParseUint(c.Params("noteid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.shareNoteUseCase.RespondToGroupShare(uint(noteID), userID, accept); err != nil {
+		if err.Error() == "share not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Share not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if accept {
+		return c.JSON(fiber.Map{"message": "Share accepted successfully"})
+	}
+	return c.JSON(fiber.Map{"message": "Share declined successfully"})
+}
+
+func (h *ShareNoteHandler) RemoveGroupShareHandler(c *fiber.Ctx) error {
+	var request struct {
+		NoteID  uint `json:"note_id"`
+		GroupID uint `json:"group_id"`
+	}
+
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	ownerID := c.Locals("user_id").(uint)
+
+	if err := h.shareNoteUseCase.RemoveGroupShare(request.NoteID, ownerID, request.GroupID); err != nil {
+		switch err.Error() {
+		case "note not found or does not belong to the user":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		case "group share not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	sharedEmails, err := h.shareNoteUseCase.GetSharedEmailsByNoteID(request.NoteID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":       "Group share removed successfully",
+		"shared_emails": sharedEmails,
+	})
+}
@@ -0,0 +1,127 @@
+package httpHandler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"miw/usecases/service"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamKeepAlive ส่ง comment ว่างเป็นระยะเพื่อไม่ให้ proxy ตัดการเชื่อมต่อ SSE
+const streamKeepAlive = 25 * time.Second
+
+type HttpNotificationHandler struct {
+	notificationUseCase service.NotificationUseCase
+}
+
+func NewHttpNotificationHandler(useCase service.NotificationUseCase) *HttpNotificationHandler {
+	return &HttpNotificationHandler{notificationUseCase: useCase}
+}
+
+// GetNotificationsHandler รายการแจ้งเตือน รองรับ ?unread=true และ ?limit=
+func (h *HttpNotificationHandler) GetNotificationsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	notifications, err := h.notificationUseCase.GetNotifications(userID, c.QueryBool("unread"), c.QueryInt("limit"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve notifications"})
+	}
+
+	return c.JSON(fiber.Map{"notifications": notifications})
+}
+
+func (h *HttpNotificationHandler) GetUnreadCountHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	count, err := h.notificationUseCase.GetUnreadCount(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count notifications"})
+	}
+
+	return c.JSON(fiber.Map{"unread_count": count})
+}
+
+func (h *HttpNotificationHandler) MarkAsReadHandler(c *fiber.Ctx) error {
+	notificationID, err := strconv.ParseUint(c.Params("notificationid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid notification ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.notificationUseCase.MarkAsRead(uint(notificationID), userID); err != nil {
+		if err.Error() == "notification not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update notification"})
+	}
+
+	return c.JSON(fiber.Map{"message": "Notification marked as read"})
+}
+
+func (h *HttpNotificationHandler) MarkAllAsReadHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	count, err := h.notificationUseCase.MarkAllAsRead(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update notifications"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "All notifications marked as read",
+		"updated": count,
+	})
+}
+
+// StreamNotificationsHandler ส่งการแจ้งเตือนใหม่แบบ server-sent events จนกว่าผู้ใช้จะปิดการเชื่อมต่อ
+func (h *HttpNotificationHandler) StreamNotificationsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	notifications, cancel := h.notificationUseCase.Subscribe(userID)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		ticker := time.NewTicker(streamKeepAlive)
+		defer ticker.Stop()
+
+		// แจ้งจำนวนที่ยังไม่อ่านตอนเริ่มต้น เพื่อให้หน้าเว็บแสดง badge ได้ทันที
+		if count, err := h.notificationUseCase.GetUnreadCount(userID); err == nil {
+			fmt.Fprintf(w, "event: unread_count\ndata: %d\n\n", count)
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+
+		for {
+			select {
+			case notification, ok := <-notifications:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(notification)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "id: %d\nevent: notification\ndata: %s\n\n", notification.NotificationID, data)
+			case <-ticker.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+			// เขียนไม่สำเร็จแปลว่าผู้ใช้ปิดการเชื่อมต่อแล้ว
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
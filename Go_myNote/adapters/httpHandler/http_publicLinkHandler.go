@@ -0,0 +1,224 @@
+package httpHandler
+
+import (
+	"bytes"
+	"html/template"
+	"miw/entities"
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PublicLinkResponse struct {
+	LinkID      uint   `json:"link_id"`
+	NoteID      uint   `json:"note_id"`
+	Slug        string `json:"slug"`
+	URL         string `json:"url"`
+	HasPassword bool   `json:"has_password"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+	ViewCount   int    `json:"view_count"`
+	CreatedAt   string `json:"created_at"`
+	RevokedAt   string `json:"revoked_at,omitempty"`
+}
+
+// หน้า HTML สำหรับแสดงโน้ตแบบอ่านอย่างเดียว
+var publicNoteTemplate = template.Must(template.New("public_note").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .Note}}{{.Note.Title}}{{else}}MyNote{{end}}</title>
+<style>
+body { font-family: sans-serif; background: #f5f5f5; margin: 0; padding: 2rem; }
+.note { max-width: 640px; margin: 0 auto; padding: 1.5rem; border-radius: 8px; background: #fff; box-shadow: 0 1px 4px rgba(0,0,0,.1); }
+.content { white-space: pre-wrap; }
+ul { list-style: none; padding: 0; }
+li.done span { text-decoration: line-through; color: #888; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<div class="note"{{if .Note}}{{if .Note.Color}} style="background: {{.Note.Color}}"{{end}}{{end}}>
+{{if .Note}}
+<h1>{{.Note.Title}}</h1>
+{{if .Note.Content}}<div class="content">{{.Note.Content}}</div>{{end}}
+{{if .Note.TodoItems}}
+<ul>
+{{range .Note.TodoItems}}<li{{if .IsDone}} class="done"{{end}}><input type="checkbox" disabled{{if .IsDone}} checked{{end}}> <span>{{.Content}}</span></li>
+{{end}}
+</ul>
+{{end}}
+{{else}}
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+{{if .AskPassword}}
+<form method="post">
+<label>Password <input type="password" name="password" autofocus></label>
+<button type="submit">View note</button>
+</form>
+{{end}}
+{{end}}
+</div>
+</body>
+</html>
+`))
+
+type HttpPublicLinkHandler struct {
+	publicLinkUseCase service.PublicLinkUseCase
+}
+
+func NewHttpPublicLinkHandler(useCase service.PublicLinkUseCase) *HttpPublicLinkHandler {
+	return &HttpPublicLinkHandler{publicLinkUseCase: useCase}
+}
+
+func (h *HttpPublicLinkHandler) CreatePublicLinkHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	data := new(struct {
+		ExpiresAt string `json:"expires_at"`
+		Password  string `json:"password"`
+	})
+	if err := c.BodyParser(data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	link, err := h.publicLinkUseCase.CreatePublicLink(uint(noteID), userID, data.ExpiresAt, data.Password)
+	if err != nil {
+		switch err.Error() {
+		case "note not found or does not belong to the user":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to share this note"})
+		case "invalid expiry time format", "expiry time must be in the future":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to create public link"})
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Public link created successfully",
+		"link":    toPublicLinkResponse(c, link),
+	})
+}
+
+func (h *HttpPublicLinkHandler) GetPublicLinksHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.Atoi(c.Params("noteid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	links, err := h.publicLinkUseCase.GetPublicLinks(uint(noteID), userID)
+	if err != nil {
+		if err.Error() == "note not found or does not belong to the user" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to view links of this note"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch public links"})
+	}
+
+	response := make([]PublicLinkResponse, 0, len(links))
+	for i := range links {
+		response = append(response, toPublicLinkResponse(c, &links[i]))
+	}
+
+	return c.JSON(fiber.Map{"links": response})
+}
+
+func (h *HttpPublicLinkHandler) RevokePublicLinkHandler(c *fiber.Ctx) error {
+	linkID, err := strconv.Atoi(c.Params("linkid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid link ID"})
+	}
+
+	userID, ok := c.Locals("user_id").(uint)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+	}
+
+	if err := h.publicLinkUseCase.RevokePublicLink(uint(linkID), userID); err != nil {
+		switch err.Error() {
+		case "link not found", "link not found or already revoked":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Link not found"})
+		case "note not found or does not belong to the user":
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not authorized to revoke this link"})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke public link"})
+		}
+	}
+
+	return c.JSON(fiber.Map{"message": "Public link revoked successfully"})
+}
+
+// ViewPublicNoteHandler แสดงโน้ตแบบอ่านอย่างเดียวโดยไม่ต้องล็อกอิน
+// รหัสผ่านส่งมาทาง body "password" (ฟอร์มหรือ JSON) หรือ header "X-Link-Password"
+// ไม่รับทาง query เพื่อไม่ให้รหัสผ่านติดไปกับ URL ใน log หรือประวัติเบราว์เซอร์
+func (h *HttpPublicLinkHandler) ViewPublicNoteHandler(c *fiber.Ctx) error {
+	var body struct {
+		Password string `json:"password" form:"password"`
+	}
+	if c.Method() == fiber.MethodPost {
+		_ = c.BodyParser(&body)
+	}
+	password := body.Password
+	if password == "" {
+		password = c.Get("X-Link-Password")
+	}
+
+	note, err := h.publicLinkUseCase.ViewPublicNote(c.Params("slug"), password)
+	if err != nil {
+		switch err.Error() {
+		case "password required":
+			return renderPublicNote(c, fiber.StatusUnauthorized, nil, "", true)
+		case "invalid password":
+			return renderPublicNote(c, fiber.StatusUnauthorized, nil, "Password is incorrect", true)
+		case "link has expired":
+			return renderPublicNote(c, fiber.StatusGone, nil, "This link has expired", false)
+		case "link not found":
+			return renderPublicNote(c, fiber.StatusNotFound, nil, "This link does not exist or has been revoked", false)
+		default:
+			return renderPublicNote(c, fiber.StatusInternalServerError, nil, "Could not load this note", false)
+		}
+	}
+
+	return renderPublicNote(c, fiber.StatusOK, note, "", false)
+}
+
+func renderPublicNote(c *fiber.Ctx, status int, note *entities.Note, message string, askPassword bool) error {
+	var buf bytes.Buffer
+	err := publicNoteTemplate.Execute(&buf, struct {
+		Note        *entities.Note
+		Error       string
+		AskPassword bool
+	}{note, message, askPassword})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString("Could not render note")
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Status(status).Send(buf.Bytes())
+}
+
+func toPublicLinkResponse(c *fiber.Ctx, link *entities.PublicLink) PublicLinkResponse {
+	return PublicLinkResponse{
+		LinkID:      link.LinkID,
+		NoteID:      link.NoteID,
+		Slug:        link.Slug,
+		URL:         c.BaseURL() + "/public/" + link.Slug,
+		HasPassword: link.PasswordHash != "",
+		ExpiresAt:   link.ExpiresAt,
+		ViewCount:   link.ViewCount,
+		CreatedAt:   link.CreatedAt,
+		RevokedAt:   link.RevokedAt,
+	}
+}
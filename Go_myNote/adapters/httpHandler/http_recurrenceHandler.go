@@ -0,0 +1,106 @@
+package httpHandler
+
+import (
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpRecurrenceHandler struct {
+	recurrenceUseCase service.RecurrenceUseCase
+}
+
+func NewHttpRecurrenceHandler(useCase service.RecurrenceUseCase) *HttpRecurrenceHandler {
+	return &HttpRecurrenceHandler{recurrenceUseCase: useCase}
+}
+
+// recurrenceErrorStatus แปลงข้อผิดพลาดของการทำซ้ำเป็น HTTP status
+func recurrenceErrorStatus(err error) int {
+	switch err.Error() {
+	case "recurrence not found":
+		return fiber.StatusNotFound
+	case "recurrence requires a todo note", "invalid recurrence mode", "invalid frequency", "invalid start time format", "recurrence start time is in the past":
+		return fiber.StatusBadRequest
+	case "note not found or does not belong to the user", "you are not authorized to view this note":
+		return fiber.StatusForbidden
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// SetRecurrenceHandler body {"frequency": "weekly", "mode": "reset", "start_at": "2006-01-02 15:04:05"}
+func (h *HttpRecurrenceHandler) SetRecurrenceHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.ParseUint(c.Params("noteid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	var request struct {
+		Frequency string `json:"frequency"`
+		Mode      string `json:"mode"`
+		StartAt   string `json:"start_at"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	recurrence, err := h.recurrenceUseCase.SetRecurrence(uint(noteID), userID, request.Frequency, request.Mode, request.StartAt)
+	if err != nil {
+		return c.Status(recurrenceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Recurrence saved successfully",
+		"recurrence": recurrence,
+	})
+}
+
+func (h *HttpRecurrenceHandler) GetRecurrenceHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.ParseUint(c.Params("noteid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	recurrence, err := h.recurrenceUseCase.GetRecurrence(uint(noteID), userID)
+	if err != nil {
+		return c.Status(recurrenceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"recurrence": recurrence})
+}
+
+func (h *HttpRecurrenceHandler) DeleteRecurrenceHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.ParseUint(c.Params("noteid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.recurrenceUseCase.DeleteRecurrence(uint(noteID), userID); err != nil {
+		return c.Status(recurrenceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Recurrence deleted successfully"})
+}
+
+func (h *HttpRecurrenceHandler) GetHistoryHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.ParseUint(c.Params("noteid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	history, err := h.recurrenceUseCase.GetHistory(uint(noteID), userID)
+	if err != nil {
+		return c.Status(recurrenceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"history": history})
+}
@@ -0,0 +1,27 @@
+package httpHandler
+
+import (
+	"miw/usecases/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpStatsHandler struct {
+	statsUseCase service.StatsUseCase
+}
+
+func NewHttpStatsHandler(useCase service.StatsUseCase) *HttpStatsHandler {
+	return &HttpStatsHandler{statsUseCase: useCase}
+}
+
+// GetStatsHandler สถิติสำหรับแดชบอร์ด รองรับ ?days= (ค่าเริ่มต้น 30 สูงสุด 365)
+func (h *HttpStatsHandler) GetStatsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	stats, err := h.statsUseCase.GetStats(userID, c.QueryInt("days"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve statistics"})
+	}
+
+	return c.JSON(fiber.Map{"stats": stats})
+}
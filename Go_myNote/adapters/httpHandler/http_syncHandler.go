@@ -0,0 +1,103 @@
+package httpHandler
+
+import (
+	"miw/entities"
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxSyncMutations จำนวน mutation สูงสุดต่อคำขอ
+const maxSyncMutations = 500
+
+type HttpSyncHandler struct {
+	syncUseCase service.SyncUseCase
+}
+
+func NewHttpSyncHandler(useCase service.SyncUseCase) *HttpSyncHandler {
+	return &HttpSyncHandler{syncUseCase: useCase}
+}
+
+// syncResultResponse ผลของ mutation พร้อมโน้ตล่าสุดในรูปแบบเดียวกับ GET /note
+type syncResultResponse struct {
+	entities.SyncResult
+	ServerNote *NoteResponse `json:"server_note,omitempty"`
+}
+
+// GetChangesHandler การเปลี่ยนแปลงตั้งแต่ ?cursor= (ไม่ระบุคือดึงทั้งหมด)
+func (h *HttpSyncHandler) GetChangesHandler(c *fiber.Ctx) error {
+	var cursor uint64
+	if raw := c.Query("cursor"); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid cursor"})
+		}
+		cursor = parsed
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	changes, err := h.syncUseCase.GetChanges(userID, uint(cursor))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve changes"})
+	}
+
+	notes := toNoteResponses(changes.Notes)
+	if notes == nil {
+		notes = []NoteResponse{}
+	}
+
+	return c.JSON(fiber.Map{
+		"cursor":     changes.Cursor,
+		"has_more":   changes.HasMore,
+		"full_sync":  changes.FullSync,
+		"notes":      notes,
+		"tags":       changes.Tags,
+		"shares":     changes.Shares,
+		"tombstones": changes.Tombstones,
+	})
+}
+
+// ApplyMutationsHandler รับ mutation ที่ไคลเอนต์ทำระหว่างออฟไลน์ ส่ง cursor ล่าสุดที่ไคลเอนต์ sync ไว้มาด้วย
+// เพื่อตรวจ conflict หลังจากนี้ไคลเอนต์ควรเรียก GET /sync ด้วย cursor เดิมเพื่อดึงการเปลี่ยนแปลง
+func (h *HttpSyncHandler) ApplyMutationsHandler(c *fiber.Ctx) error {
+	var request struct {
+		Cursor    uint                    `json:"cursor"`
+		Mutations []entities.SyncMutation `json:"mutations"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if len(request.Mutations) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "mutations are required"})
+	}
+	if len(request.Mutations) > maxSyncMutations {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "too many mutations"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	results, err := h.syncUseCase.ApplyMutations(userID, request.Cursor, request.Mutations)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to apply mutations"})
+	}
+
+	response := make([]syncResultResponse, 0, len(results))
+	conflicts := 0
+	for _, result := range results {
+		item := syncResultResponse{SyncResult: result}
+		if result.ServerNote != nil {
+			item.ServerNote = &toNoteResponses([]entities.Note{*result.ServerNote})[0]
+		}
+		if result.Status == "conflict" {
+			conflicts++
+		}
+		response = append(response, item)
+	}
+
+	return c.JSON(fiber.Map{
+		"results":   response,
+		"conflicts": conflicts,
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"miw/entities"
 	"miw/usecases/service"
 	"strconv"
+	"strings"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -11,9 +12,29 @@ type TagResponse struct {
 	TagID   uint   `json:"tag_id"`
 	TagName string `json:"tag_name"`
 	UserID  uint   `json:"user_id"` 
+	ParentID *uint `json:"parent_id"`
+	Color   string `json:"color"`
+	Icon    string `json:"icon"`
 	Notes   []uint `json:"notes"`
 }
 
+// toTagResponse แปลง Tag เป็นรูปแบบที่ส่งกลับให้ client โดยส่งเฉพาะ ID ของโน้ต
+func toTagResponse(tag entities.Tag) TagResponse {
+	var noteIDs []uint
+	for _, note := range tag.Notes {
+		noteIDs = append(noteIDs, note.NoteID)
+	}
+	return TagResponse{
+		TagID:    tag.TagID,
+		TagName:  tag.TagName,
+		UserID:   tag.UserID,
+		ParentID: tag.ParentID,
+		Color:    tag.Color,
+		Icon:     tag.Icon,
+		Notes:    noteIDs,
+	}
+}
+
 type HttpTagHandler struct {
 	tagUseCase service.TagUseCase
 }
@@ -38,6 +59,12 @@ func (h *HttpTagHandler) CreateTagHandler(c *fiber.Ctx) error {
 
 	// เรียกใช้ฟังก์ชันสร้างแท็ก
 	if err := h.tagUseCase.CreateTag(tag); err != nil {
+		if err.Error() == "you are not allowed to create tags in this workspace" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err.Error() == "invalid tag name" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -60,16 +87,7 @@ func (h *HttpTagHandler) GetAllTagsHandler(c *fiber.Ctx) error {
 	// สร้าง JSON Response
 	var response []TagResponse
 	for _, tag := range tags {
-		var noteIDs []uint
-		for _, note := range tag.Notes {
-			noteIDs = append(noteIDs, note.NoteID)
-		}
-		response = append(response, TagResponse{
-			TagID:   tag.TagID,
-			TagName: tag.TagName,
-			UserID:  tag.UserID,
-			Notes:   noteIDs,
-		})
+		response = append(response, toTagResponse(tag))
 	}
 
 	return c.Status(fiber.StatusOK).JSON(response)
@@ -97,26 +115,16 @@ func (h *HttpTagHandler) GetTagHandler(c *fiber.Ctx) error {
 		}
 	}
 
-	// สร้าง JSON Response
-	var noteIDs []uint
-	for _, note := range tag.Notes {
-		noteIDs = append(noteIDs, note.NoteID)
-	}
-
-	response := TagResponse{
-		TagID:   tag.TagID,
-		TagName: tag.TagName,
-		UserID:  tag.UserID,
-		Notes:   noteIDs,
-	}
-
-	return c.Status(fiber.StatusOK).JSON(response)
+	return c.Status(fiber.StatusOK).JSON(toTagResponse(*tag))
 }
 
 
 func (h *HttpTagHandler) UpdateTagNameHandler(c *fiber.Ctx) error {
+	// new_tagname เปลี่ยนชื่อหรือย้ายแท็ก เช่น "work/alpha" color และ icon ส่งเฉพาะเมื่อต้องการเปลี่ยน
 	var request struct {
-		NewTagname string `json:"new_tagname"`
+		NewTagname string  `json:"new_tagname"`
+		Color      *string `json:"color"`
+		Icon       *string `json:"icon"`
 	}
 
 	// รับ tag ID จากพารามิเตอร์
@@ -133,17 +141,24 @@ func (h *HttpTagHandler) UpdateTagNameHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
 	}
 
+	if request.NewTagname == "" && request.Color == nil && request.Icon == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid tag name"})
+	}
+
 	// เรียกใช้ service เพื่อแก้ไขชื่อแท็ก
-	if err := h.tagUseCase.UpdateTagName(uint(tagID), userID, request.NewTagname); err != nil {
-		// ใช้ข้อความเปรียบเทียบโดยตรงแทน fmt.Sprintf
-		if err.Error() == "tag not found or does not belong to this user" {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
+	if request.NewTagname != "" {
+		if err := h.tagUseCase.UpdateTagName(uint(tagID), userID, request.NewTagname); err != nil {
+			return c.Status(tagErrorStatus(err)).JSON(fiber.Map{"error": tagErrorMessage(err)})
+		}
+	}
+
+	if request.Color != nil || request.Icon != nil {
+		if err := h.tagUseCase.UpdateTagStyle(uint(tagID), userID, request.Color, request.Icon); err != nil {
+			return c.Status(tagErrorStatus(err)).JSON(fiber.Map{"error": tagErrorMessage(err)})
 		}
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
 	}
-	
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Tag name updated successfully"})
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Tag updated successfully"})
 }
 
 
@@ -162,8 +177,68 @@ func (h *HttpTagHandler) DeleteTagHandler(c *fiber.Ctx) error {
 		if err.Error() == "tag not found or does not belong to this user" {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tag not found"})
 		}
+		if err.Error() == "tag has child tags" {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		if tagErrorStatus(err) == fiber.StatusForbidden {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Tag deleted successfully"})
 }
+
+// tagErrorStatus แปลงข้อผิดพลาดของแท็กเป็น HTTP status ชื่อซ้ำถือเป็น conflict
+func tagErrorStatus(err error) int {
+	switch err.Error() {
+	case "tag not found", "tag not found or does not belong to this user":
+		return fiber.StatusNotFound
+	case "you are not authorized to view this tag", "you are not allowed to edit tags in this workspace":
+		return fiber.StatusForbidden
+	case "invalid tag name", "a tag cannot be moved under itself", "cannot merge a tag into itself", "tags must belong to the same user or workspace":
+		return fiber.StatusBadRequest
+	case "tag has child tags":
+		return fiber.StatusConflict
+	}
+	if strings.Contains(err.Error(), "already exists") {
+		return fiber.StatusConflict
+	}
+	return fiber.StatusInternalServerError
+}
+
+// tagErrorMessage แท็กที่หาไม่พบใช้ข้อความเดียวกับ endpoint เดิม
+func tagErrorMessage(err error) string {
+	if tagErrorStatus(err) == fiber.StatusNotFound {
+		return "Tag not found"
+	}
+	return err.Error()
+}
+
+// MergeTagsHandler รวมแท็กใน URL เข้ากับแท็กปลายทาง body {"target_tag_id": 2}
+// โน้ตทั้งหมดจะย้ายไปใช้แท็กปลายทาง แล้วแท็กใน URL จะถูกลบ
+func (h *HttpTagHandler) MergeTagsHandler(c *fiber.Ctx) error {
+	tagID, err := strconv.ParseUint(c.Params("tagid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid tag ID"})
+	}
+
+	var request struct {
+		TargetTagID uint `json:"target_tag_id"`
+	}
+	if err := c.BodyParser(&request); err != nil || request.TargetTagID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	tag, err := h.tagUseCase.MergeTags(uint(tagID), request.TargetTagID, userID)
+	if err != nil {
+		return c.Status(tagErrorStatus(err)).JSON(fiber.Map{"error": tagErrorMessage(err)})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Tags merged successfully",
+		"tag":     toTagResponse(*tag),
+	})
+}
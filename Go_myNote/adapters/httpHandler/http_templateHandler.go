@@ -0,0 +1,173 @@
+package httpHandler
+
+import (
+	"miw/entities"
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpTemplateHandler struct {
+	templateUseCase service.TemplateUseCase
+}
+
+func NewHttpTemplateHandler(useCase service.TemplateUseCase) *HttpTemplateHandler {
+	return &HttpTemplateHandler{templateUseCase: useCase}
+}
+
+// templateErrorStatus แปลงข้อผิดพลาดของแม่แบบเป็น HTTP status
+func templateErrorStatus(err error) int {
+	switch err.Error() {
+	case "template not found", "note not found":
+		return fiber.StatusNotFound
+	case "template name is required", "template cannot have both content and todo_items", "template must have either content or todo_items",
+		"note cannot have both content and todo_items", "note must have either content or todo_items":
+		return fiber.StatusBadRequest
+	case "you are not authorized to view this note", "you are not allowed to add notes to this workspace":
+		return fiber.StatusForbidden
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+func (h *HttpTemplateHandler) CreateTemplateHandler(c *fiber.Ctx) error {
+	template := new(entities.NoteTemplate)
+	if err := c.BodyParser(template); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	template.UserID = c.Locals("user_id").(uint)
+
+	if err := h.templateUseCase.CreateTemplate(template); err != nil {
+		return c.Status(templateErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Template created successfully",
+		"template": template,
+	})
+}
+
+// SaveNoteAsTemplateHandler บันทึกโน้ตเป็นแม่แบบ ถ้าไม่ส่ง name จะใช้ชื่อโน้ต
+func (h *HttpTemplateHandler) SaveNoteAsTemplateHandler(c *fiber.Ctx) error {
+	noteID, err := strconv.ParseUint(c.Params("noteid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid note ID"})
+	}
+
+	var request struct {
+		Name string `json:"name"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	template, err := h.templateUseCase.SaveNoteAsTemplate(uint(noteID), userID, request.Name)
+	if err != nil {
+		return c.Status(templateErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":  "Template created successfully",
+		"template": template,
+	})
+}
+
+func (h *HttpTemplateHandler) GetTemplatesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	templates, err := h.templateUseCase.GetTemplates(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve templates"})
+	}
+
+	return c.JSON(fiber.Map{"templates": templates})
+}
+
+func (h *HttpTemplateHandler) GetTemplateHandler(c *fiber.Ctx) error {
+	templateID, err := strconv.ParseUint(c.Params("templateid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	template, err := h.templateUseCase.GetTemplate(uint(templateID), userID)
+	if err != nil {
+		return c.Status(templateErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"template": template})
+}
+
+func (h *HttpTemplateHandler) UpdateTemplateHandler(c *fiber.Ctx) error {
+	templateID, err := strconv.ParseUint(c.Params("templateid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template ID"})
+	}
+
+	update := new(entities.NoteTemplate)
+	if err := c.BodyParser(update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	template, err := h.templateUseCase.UpdateTemplate(uint(templateID), userID, update)
+	if err != nil {
+		return c.Status(templateErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Template updated successfully",
+		"template": template,
+	})
+}
+
+func (h *HttpTemplateHandler) DeleteTemplateHandler(c *fiber.Ctx) error {
+	templateID, err := strconv.ParseUint(c.Params("templateid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.templateUseCase.DeleteTemplate(uint(templateID), userID); err != nil {
+		return c.Status(templateErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Template deleted successfully"})
+}
+
+// UseTemplateHandler สร้างโน้ตจากแม่แบบ body {"variables": {"project": "..."}} ใช้แทนค่า {{project}}
+func (h *HttpTemplateHandler) UseTemplateHandler(c *fiber.Ctx) error {
+	templateID, err := strconv.ParseUint(c.Params("templateid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid template ID"})
+	}
+
+	var request struct {
+		Variables map[string]string `json:"variables"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	note, err := h.templateUseCase.InstantiateTemplate(uint(templateID), userID, request.Variables)
+	if err != nil {
+		return c.Status(templateErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Note created successfully",
+		"note":    toNoteResponses([]entities.Note{*note})[0],
+	})
+}
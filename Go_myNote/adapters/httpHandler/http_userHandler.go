@@ -2,6 +2,7 @@ package httpHandler
 
 import (
 	"fmt"
+	"log"
 	"miw/entities"
 	"miw/usecases/service"
 	"strconv"
@@ -12,10 +13,11 @@ import (
 
 type HttpUserHandler struct {
 	userUseCase service.UserUseCase
+	rateLimiter service.RateLimitUseCase
 }
 
-func NewHttpUserHandler(useCase service.UserUseCase) *HttpUserHandler {
-	return &HttpUserHandler{userUseCase: useCase}
+func NewHttpUserHandler(useCase service.UserUseCase, rateLimiter service.RateLimitUseCase) *HttpUserHandler {
+	return &HttpUserHandler{userUseCase: useCase, rateLimiter: rateLimiter}
 }
 
 func (h *HttpUserHandler) Register(c *fiber.Ctx) error {
@@ -31,6 +33,9 @@ func (h *HttpUserHandler) Register(c *fiber.Ctx) error {
 
 	// เรียกใช้ฟังก์ชันสร้างผู้ใช้
 	if err := h.userUseCase.Register(user); err != nil {
+		if service.IsPasswordPolicyError(err) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "This email has already been registered."})
 	}
 
@@ -49,9 +54,17 @@ func (h *HttpUserHandler) Login(c *fiber.Ctx) error {
 	token, err := h.userUseCase.Login(data.Email, data.Password)
 	fmt.Println("Token:", token)
 	if err != nil {
+		if err.Error() == "account is locked" {
+			return c.Status(fiber.StatusLocked).SendString("Account is temporarily locked due to too many failed login attempts")
+		}
 		return c.Status(fiber.StatusUnauthorized).SendString("Email or password is incorrect")
 	}
 
+	// ล็อกอินสำเร็จให้ล้างตัวนับของอีเมลนี้ เพื่อให้การบล็อกแบบทวีคูณเกิดจากการล็อกอินที่ล้มเหลวเท่านั้น
+	if err := h.rateLimiter.Reset(service.EmailRateLimitKey(data.Email)); err != nil {
+		log.Printf("Failed to reset rate limit: %v", err)
+	}
+
 	c.Cookie(&fiber.Cookie{
 		Name:     "jwt",
 		Value:    token,
@@ -107,6 +120,9 @@ func (h *HttpUserHandler) ChangePassword(c *fiber.Ctx) error {
 		if err.Error() == "user not found" {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
 		}
+		if service.IsPasswordPolicyError(err) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Could not reset password"})
 	}
 
@@ -156,3 +172,103 @@ func (h *HttpUserHandler) ChangeUsername(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusBadRequest).SendString("Only 'username' field is allowed")
 }
+
+func (h *HttpUserHandler) UpdateNotificationSettings(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("userid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	var request struct {
+		ShareEmailsOptOut *bool `json:"share_emails_opt_out"`
+	}
+	if err := c.BodyParser(&request); err != nil || request.ShareEmailsOptOut == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "share_emails_opt_out is required"})
+	}
+
+	if err := h.userUseCase.UpdateNotificationSettings(uint(id), *request.ShareEmailsOptOut); err != nil {
+		if err.Error() == "user not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "could not update notification settings"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "notification settings updated successfully",
+	})
+}
+
+func (h *HttpUserHandler) DeleteAccount(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("userid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	data := new(struct {
+		Password        string `json:"password"`
+		TransferToEmail string `json:"transfer_to_email"`
+	})
+	if err := c.BodyParser(data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if data.Password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Password is required"})
+	}
+
+	if err := h.userUseCase.DeleteAccount(uint(id), data.Password, data.TransferToEmail); err != nil {
+		switch err.Error() {
+		case "user not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		case "invalid password":
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Password is incorrect"})
+		case "transfer user not found", "cannot transfer notes to yourself":
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not delete account"})
+		}
+	}
+
+	// ล้าง Cookie ของ JWT หลังลบบัญชี
+	c.ClearCookie("jwt")
+
+	return c.JSON(fiber.Map{"message": "Account deleted successfully"})
+}
+
+func (h *HttpUserHandler) UpdatePassword(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("userid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid ID")
+	}
+
+	data := new(struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+		ConfirmPassword string `json:"confirmPassword"`
+	})
+	if err := c.BodyParser(data); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	// ตรวจสอบว่ากรอกครบและรหัสผ่านใหม่ตรงกับที่ยืนยัน
+	if data.CurrentPassword == "" || data.NewPassword == "" || data.ConfirmPassword == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Password fields cannot be empty"})
+	}
+	if data.NewPassword != data.ConfirmPassword {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Passwords do not match"})
+	}
+
+	if err := h.userUseCase.UpdatePassword(uint(id), data.CurrentPassword, data.NewPassword); err != nil {
+		switch {
+		case err.Error() == "user not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+		case err.Error() == "invalid password":
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Current password is incorrect"})
+		case service.IsPasswordPolicyError(err):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		default:
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not change password"})
+		}
+	}
+
+	return c.JSON(fiber.Map{"message": "Password updated successfully"})
+}
@@ -0,0 +1,146 @@
+package httpHandler
+
+import (
+	"miw/entities"
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpViewHandler struct {
+	viewUseCase service.ViewUseCase
+}
+
+func NewHttpViewHandler(useCase service.ViewUseCase) *HttpViewHandler {
+	return &HttpViewHandler{viewUseCase: useCase}
+}
+
+// viewErrorStatus แปลงข้อผิดพลาดของมุมมองเป็น HTTP status
+func viewErrorStatus(err error) int {
+	switch err.Error() {
+	case "view not found":
+		return fiber.StatusNotFound
+	case "view name is required", "invalid todo status", "invalid sort order", "invalid priority range", "invalid reminder window":
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+func (h *HttpViewHandler) CreateViewHandler(c *fiber.Ctx) error {
+	view := new(entities.SavedView)
+	if err := c.BodyParser(view); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	view.UserID = c.Locals("user_id").(uint)
+
+	if err := h.viewUseCase.CreateView(view); err != nil {
+		return c.Status(viewErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "View created successfully",
+		"view":    view,
+	})
+}
+
+func (h *HttpViewHandler) GetViewsHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	views, err := h.viewUseCase.GetViews(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve views"})
+	}
+
+	return c.JSON(fiber.Map{"views": views})
+}
+
+func (h *HttpViewHandler) GetViewHandler(c *fiber.Ctx) error {
+	viewID, err := strconv.ParseUint(c.Params("viewid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid view ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	view, err := h.viewUseCase.GetView(uint(viewID), userID)
+	if err != nil {
+		return c.Status(viewErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"view": view})
+}
+
+func (h *HttpViewHandler) UpdateViewHandler(c *fiber.Ctx) error {
+	viewID, err := strconv.ParseUint(c.Params("viewid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid view ID"})
+	}
+
+	update := new(entities.SavedView)
+	if err := c.BodyParser(update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	view, err := h.viewUseCase.UpdateView(uint(viewID), userID, update)
+	if err != nil {
+		return c.Status(viewErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "View updated successfully",
+		"view":    view,
+	})
+}
+
+func (h *HttpViewHandler) DeleteViewHandler(c *fiber.Ctx) error {
+	viewID, err := strconv.ParseUint(c.Params("viewid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid view ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.viewUseCase.DeleteView(uint(viewID), userID); err != nil {
+		return c.Status(viewErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "View deleted successfully"})
+}
+
+// ExecuteViewHandler โน้ตที่ตรงกับเงื่อนไขของมุมมอง
+func (h *HttpViewHandler) ExecuteViewHandler(c *fiber.Ctx) error {
+	viewID, err := strconv.ParseUint(c.Params("viewid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid view ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	notes, err := h.viewUseCase.ExecuteView(uint(viewID), userID)
+	if err != nil {
+		return c.Status(viewErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"notes": toNoteResponses(notes)})
+}
+
+// SearchNotesHandler ค้นหาโน้ตด้วยเงื่อนไขใน body รูปแบบเดียวกับ query ของมุมมอง โดยไม่ต้องบันทึก
+func (h *HttpViewHandler) SearchNotesHandler(c *fiber.Ctx) error {
+	var query entities.NoteQuery
+	if err := c.BodyParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	notes, err := h.viewUseCase.SearchNotes(userID, query)
+	if err != nil {
+		return c.Status(viewErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"notes": toNoteResponses(notes)})
+}
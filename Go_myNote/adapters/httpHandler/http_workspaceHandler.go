@@ -0,0 +1,216 @@
+package httpHandler
+
+import (
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HttpWorkspaceHandler struct {
+	workspaceUseCase service.WorkspaceUseCase
+}
+
+func NewHttpWorkspaceHandler(useCase service.WorkspaceUseCase) *HttpWorkspaceHandler {
+	return &HttpWorkspaceHandler{workspaceUseCase: useCase}
+}
+
+// workspaceErrorStatus แปลงข้อผิดพลาดของเวิร์กสเปซเป็น HTTP status
+func workspaceErrorStatus(err error) int {
+	switch err.Error() {
+	case "workspace not found", "member not found", "user not found":
+		return fiber.StatusNotFound
+	case "you are not a member of this workspace",
+		"you are not allowed to manage this workspace",
+		"only the workspace owner can delete the workspace",
+		"only the workspace owner can assign admins",
+		"cannot change the workspace owner":
+		return fiber.StatusForbidden
+	case "workspace name is required", "invalid role":
+		return fiber.StatusBadRequest
+	case "user is already a member of this workspace":
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+func (h *HttpWorkspaceHandler) CreateWorkspaceHandler(c *fiber.Ctx) error {
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	workspace, err := h.workspaceUseCase.CreateWorkspace(request.Name, userID)
+	if err != nil {
+		return c.Status(workspaceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":   "Workspace created successfully",
+		"workspace": workspace,
+	})
+}
+
+func (h *HttpWorkspaceHandler) GetWorkspacesHandler(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	workspaces, err := h.workspaceUseCase.GetWorkspaces(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve workspaces"})
+	}
+
+	return c.JSON(fiber.Map{"workspaces": workspaces})
+}
+
+func (h *HttpWorkspaceHandler) GetWorkspaceHandler(c *fiber.Ctx) error {
+	workspaceID, err := strconv.ParseUint(c.Params("workspaceid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid workspace ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	workspace, members, err := h.workspaceUseCase.GetWorkspace(uint(workspaceID), userID)
+	if err != nil {
+		return c.Status(workspaceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{
+		"workspace": workspace,
+		"members":   members,
+	})
+}
+
+func (h *HttpWorkspaceHandler) UpdateWorkspaceHandler(c *fiber.Ctx) error {
+	workspaceID, err := strconv.ParseUint(c.Params("workspaceid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid workspace ID"})
+	}
+
+	var request struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.workspaceUseCase.UpdateWorkspaceName(uint(workspaceID), userID, request.Name); err != nil {
+		return c.Status(workspaceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Workspace updated successfully"})
+}
+
+func (h *HttpWorkspaceHandler) DeleteWorkspaceHandler(c *fiber.Ctx) error {
+	workspaceID, err := strconv.ParseUint(c.Params("workspaceid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid workspace ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.workspaceUseCase.DeleteWorkspace(uint(workspaceID), userID); err != nil {
+		return c.Status(workspaceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Workspace deleted successfully"})
+}
+
+func (h *HttpWorkspaceHandler) GetWorkspaceNotesHandler(c *fiber.Ctx) error {
+	workspaceID, err := strconv.ParseUint(c.Params("workspaceid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid workspace ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	notes, err := h.workspaceUseCase.GetWorkspaceNotes(uint(workspaceID), userID)
+	if err != nil {
+		return c.Status(workspaceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"notes": toNoteResponses(notes)})
+}
+
+func (h *HttpWorkspaceHandler) AddMemberHandler(c *fiber.Ctx) error {
+	workspaceID, err := strconv.ParseUint(c.Params("workspaceid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid workspace ID"})
+	}
+
+	var request struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if request.Role == "" {
+		request.Role = "editor"
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	members, err := h.workspaceUseCase.AddMember(uint(workspaceID), userID, request.Email, request.Role)
+	if err != nil {
+		return c.Status(workspaceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Member added successfully",
+		"members": members,
+	})
+}
+
+func (h *HttpWorkspaceHandler) UpdateMemberRoleHandler(c *fiber.Ctx) error {
+	workspaceID, err := strconv.ParseUint(c.Params("workspaceid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid workspace ID"})
+	}
+	memberID, err := strconv.ParseUint(c.Params("memberid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid member ID"})
+	}
+
+	var request struct {
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.workspaceUseCase.UpdateMemberRole(uint(workspaceID), userID, uint(memberID), request.Role); err != nil {
+		return c.Status(workspaceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Member role updated successfully"})
+}
+
+// RemoveMemberHandler ลบสมาชิก หรือออกจากเวิร์กสเปซเมื่อ memberid เป็นของตัวเอง
+func (h *HttpWorkspaceHandler) RemoveMemberHandler(c *fiber.Ctx) error {
+	workspaceID, err := strconv.ParseUint(c.Params("workspaceid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid workspace ID"})
+	}
+	memberID, err := strconv.ParseUint(c.Params("memberid"), 10, 32)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid member ID"})
+	}
+
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.workspaceUseCase.RemoveMember(uint(workspaceID), userID, uint(memberID)); err != nil {
+		return c.Status(workspaceErrorStatus(err)).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"message": "Member removed successfully"})
+}
@@ -0,0 +1,43 @@
+package memoryRepository
+
+import (
+	"miw/entities"
+	"sync"
+)
+
+// MemoryRateLimitRepository เก็บสถานะ rate limit ไว้ในหน่วยความจำ เหมาะกับเซิร์ฟเวอร์ตัวเดียว
+type MemoryRateLimitRepository struct {
+	mu     sync.Mutex
+	limits map[string]entities.RateLimit
+}
+
+func NewMemoryRateLimitRepository() *MemoryRateLimitRepository {
+	return &MemoryRateLimitRepository{limits: make(map[string]entities.RateLimit)}
+}
+
+func (r *MemoryRateLimitRepository) GetRateLimit(key string) (*entities.RateLimit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rateLimit, ok := r.limits[key]
+	if !ok {
+		return &entities.RateLimit{Key: key}, nil
+	}
+	return &rateLimit, nil
+}
+
+func (r *MemoryRateLimitRepository) SaveRateLimit(rateLimit *entities.RateLimit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.limits[rateLimit.Key] = *rateLimit
+	return nil
+}
+
+func (r *MemoryRateLimitRepository) DeleteRateLimit(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.limits, key)
+	return nil
+}
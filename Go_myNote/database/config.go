@@ -3,6 +3,7 @@ package database
 import (
 	"log"
 	"os"
+	"strconv"
 	"github.com/joho/godotenv"
 )
 
@@ -14,6 +15,12 @@ type Config struct {
 	DBName     string
 	DBSchema   string
 	JWTSecret  string
+	// RateLimitStore เลือกที่เก็บสถานะ rate limit: "memory" (ค่าเริ่มต้น) หรือ "postgres"
+	RateLimitStore string
+	// ค่าของ password policy
+	PasswordMinLength    int
+	PasswordHashCost     int
+	BreachedPasswordFile string
 }
 
 func LoadConfig() *Config {
@@ -30,5 +37,18 @@ func LoadConfig() *Config {
 		DBName:     os.Getenv("DB_NAME"),
 		DBSchema:   os.Getenv("DB_SCHEMA"),
 		JWTSecret:  os.Getenv("JWT_SECRET"),
+		RateLimitStore: os.Getenv("RATE_LIMIT_STORE"),
+		PasswordMinLength:    getEnvInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordHashCost:     getEnvInt("PASSWORD_HASH_COST", 12),
+		BreachedPasswordFile: os.Getenv("BREACHED_PASSWORD_FILE"),
 	}
 }
+
+// getEnvInt อ่านค่าตัวเลขจาก environment ถ้าไม่มีหรือไม่ถูกต้องจะใช้ค่าเริ่มต้น
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,32 @@
+package entities
+
+// Activity บันทึกการเปลี่ยนแปลงแบบเพิ่มอย่างเดียว ใช้แสดงไทม์ไลน์ของโน้ตและฟีดกิจกรรมล่าสุด
+type Activity struct {
+	ActivityID uint   `json:"activity_id" gorm:"primaryKey"`
+	NoteID     uint   `json:"note_id" gorm:"index"` // 0 ถ้าไม่เกี่ยวกับโน้ตใดโดยตรง เช่น สร้างแท็ก
+	ActorID    uint   `json:"actor_id" gorm:"index"`
+	Action     string `json:"action"`      // เช่น note.color, todo.status, tag.add, share.remove
+	EntityType string `json:"entity_type"` // note, todo, tag, reminder, share, invitation, group_share
+	EntityID   uint   `json:"entity_id"`
+	Before     string `json:"before"` // JSON ของค่าก่อนเปลี่ยน
+	After      string `json:"after"`  // JSON ของค่าหลังเปลี่ยน
+	CreatedAt  string `json:"created_at"`
+}
+
+// ActivityEntry กิจกรรมพร้อมอีเมลของผู้กระทำ
+type ActivityEntry struct {
+	Activity
+	ActorEmail string `json:"actor_email"`
+}
+
+// NoteEvent เหตุการณ์การเปลี่ยนแปลงของโน้ตที่ส่งผ่าน SSE ใช้ ActivityID เป็น EventID เพื่อให้ต่อจาก Last-Event-ID ได้
+type NoteEvent struct {
+	EventID    uint   `json:"event_id"`
+	Type       string `json:"type"` // created, updated, deleted, restored, shared, unshared, tag_added, tag_removed, reset
+	NoteID     uint   `json:"note_id"`
+	Action     string `json:"action"`
+	EntityType string `json:"entity_type"`
+	EntityID   uint   `json:"entity_id"`
+	ActorID    uint   `json:"actor_id"`
+	CreatedAt  string `json:"created_at"`
+}
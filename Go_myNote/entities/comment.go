@@ -0,0 +1,29 @@
+package entities
+
+// Comment ความคิดเห็นบนโน้ต ระบุ TodoID เพื่อผูกกับรายการ ToDo และ ParentID เมื่อเป็นการตอบกลับในเธรด
+type Comment struct {
+	CommentID  uint   `json:"comment_id" gorm:"primaryKey"`
+	NoteID     uint   `json:"note_id" gorm:"index"`
+	TodoID     *uint  `json:"todo_id"`
+	ParentID   *uint  `json:"parent_id" gorm:"index"`
+	AuthorID   uint   `json:"author_id"`
+	Content    string `json:"content"`
+	Resolved   bool   `json:"resolved"`
+	ResolvedBy uint   `json:"resolved_by"`
+	ResolvedAt string `json:"resolved_at"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+	DeletedAt  string `json:"deleted_at"` // ลบแบบ soft delete เพื่อให้เธรดยังอยู่ครบ
+}
+
+// CommentEntry ความคิดเห็นพร้อมอีเมลของผู้เขียน
+type CommentEntry struct {
+	Comment
+	AuthorEmail string `json:"author_email"`
+}
+
+// CommentThread ความคิดเห็นหลักพร้อมคำตอบกลับทั้งหมด
+type CommentThread struct {
+	CommentEntry
+	Replies []CommentEntry `json:"replies"`
+}
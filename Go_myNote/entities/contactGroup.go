@@ -0,0 +1,42 @@
+package entities
+
+// ContactGroup กลุ่มผู้ติดต่อที่เจ้าของตั้งไว้สำหรับแชร์โน้ตทีละหลายคน
+type ContactGroup struct {
+	GroupID   uint   `json:"group_id" gorm:"primaryKey"`
+	OwnerID   uint   `json:"owner_id" gorm:"index"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ContactGroupMember struct {
+	GroupMemberID uint   `json:"group_member_id" gorm:"primaryKey"`
+	GroupID       uint   `json:"group_id" gorm:"uniqueIndex:idx_group_member"`
+	UserID        uint   `json:"user_id" gorm:"uniqueIndex:idx_group_member"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// ContactGroupMemberInfo ข้อมูลสมาชิกกลุ่มพร้อมอีเมล ใช้แสดงรายชื่อสมาชิก
+type ContactGroupMemberInfo struct {
+	UserID   uint   `json:"user_id"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+// GroupShare การแชร์โน้ตให้กลุ่ม สิทธิ์ของสมาชิกอ้างอิงจากสมาชิกภาพปัจจุบันของกลุ่ม
+type GroupShare struct {
+	GroupShareID uint   `json:"group_share_id" gorm:"primaryKey"`
+	NoteID       uint   `json:"note_id" gorm:"uniqueIndex:idx_group_share"`
+	GroupID      uint   `json:"group_id" gorm:"uniqueIndex:idx_group_share"`
+	Permission   string `json:"permission" gorm:"default:editor"` // editor หรือ viewer
+	CreatedAt    string `json:"created_at"`
+}
+
+// GroupShareResponse การตอบรับโน้ตที่แชร์ให้กลุ่มของสมาชิกแต่ละคน สมาชิกเข้าถึงโน้ตได้เมื่อตอบรับแล้วเท่านั้น
+// ถ้ายังไม่มีแถวคือรอตอบรับ declined คือปฏิเสธหรือออกจากโน้ตแล้ว
+type GroupShareResponse struct {
+	ResponseID uint   `json:"response_id" gorm:"primaryKey"`
+	NoteID     uint   `json:"note_id" gorm:"uniqueIndex:idx_group_share_response"`
+	UserID     uint   `json:"user_id" gorm:"uniqueIndex:idx_group_share_response"`
+	Status     string `json:"status"` // accepted หรือ declined
+	CreatedAt  string `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package entities
+
+// Mention การกล่าวถึงผู้ใช้ (@email หรือ @username) ในเนื้อหาโน้ตหรือความคิดเห็น
+type Mention struct {
+	MentionID uint   `json:"mention_id" gorm:"primaryKey"`
+	NoteID    uint   `json:"note_id" gorm:"index"`
+	CommentID uint   `json:"comment_id" gorm:"index"` // 0 ถ้ากล่าวถึงในเนื้อหาโน้ต
+	UserID    uint   `json:"user_id" gorm:"index"`    // ผู้ที่ถูกกล่าวถึง
+	AuthorID  uint   `json:"author_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+// MentionEntry การกล่าวถึงพร้อมชื่อโน้ต อีเมลผู้เขียน และข้อความของความคิดเห็น
+type MentionEntry struct {
+	Mention
+	NoteTitle      string `json:"note_title"`
+	AuthorEmail    string `json:"author_email"`
+	CommentContent string `json:"comment_content"`
+}
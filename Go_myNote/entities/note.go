@@ -3,6 +3,7 @@ package entities
 type Note struct {
 	NoteID     uint       `json:"note_id" gorm:"primaryKey"`
 	UserID     uint       `json:"user_id"`
+	WorkspaceID *uint     `json:"workspace_id" gorm:"index"` // nil ถ้าเป็นโน้ตส่วนตัว
 	Title      string     `json:"title"`
 	Content    string     `json:"content"`
 	Color      string     `json:"color"`
@@ -13,6 +14,8 @@ type Note struct {
 	CreatedAt  string     `json:"created_at"`
 	UpdatedAt  string     `json:"updated_at"`
 	DeletedAt  string     `json:"deleted_at"`
+	ArchivedAt string     `json:"archived_at" gorm:"default:''"` // ว่างถ้ายังไม่เก็บเข้าคลัง
+	CompletedAt string    `json:"completed_at" gorm:"default:''"` // เวลาที่รายการ ToDo เสร็จครบ
 	Tags       []Tag      `gorm:"many2many:note_tags;joinForeignKey:NoteID;joinReferences:TagID;constraint:OnDelete:CASCADE;"`
 	Reminder  []Reminder `gorm:"foreignKey:NoteID"`
 	Event      Event      `gorm:"foreignKey:NoteID;constraint:OnDelete:CASCADE;"`
@@ -23,6 +26,12 @@ type ToDo struct {
     NoteID    uint   `json:"note_id"`           // เชื่อมโยงกับ Note
     Content   string `json:"content"`           // เนื้อหาของ To-Do
     IsDone    bool   `json:"is_done"`           // สถานะเสร็จสิ้นหรือไม่
+    Position  int    `json:"position"`          // ลำดับในรายการ เริ่มจาก 0
+    DueDate   string `json:"due_date"`          // รูปแบบ 2006-01-02 ว่างถ้าไม่มีกำหนด
+    Priority  int    `json:"priority"`
+    ParentID  *uint  `json:"parent_id" gorm:"index"`   // รายการย่อยของรายการอื่นในโน้ตเดียวกัน
+    AssigneeID *uint `json:"assignee_id" gorm:"index"` // ผู้รับผิดชอบ ต้องเข้าถึงโน้ตได้
+    CompletedAt string `json:"completed_at" gorm:"default:''"` // ว่างถ้ายังไม่เสร็จ
     CreatedAt string `json:"created_at"`
     UpdatedAt string `json:"updated_at"`
 }
@@ -35,17 +44,40 @@ type Reminder struct {
 	Frequency    string `json:"frequency"`
 }
 
+// Tag ชื่อแท็กไม่ซ้ำกันภายในแท็กส่วนตัวของผู้ใช้แต่ละคน หรือภายในเวิร์กสเปซเดียวกัน
+// แท็กที่ติดอยู่บนโน้ตจะแสดงให้ทุกคนที่เห็นโน้ตนั้น แต่ไม่รวมอยู่ในรายการแท็กของผู้รับแชร์
 type Tag struct {
 	TagID   uint   `json:"tag_id" gorm:"primaryKey"`
-	TagName string `json:"tag_name" gorm:"unique"`
-	UserID  uint   `json:"user_id"`
+	TagName string `json:"tag_name" gorm:"uniqueIndex:idx_tags_user_tag_name,priority:2,where:workspace_id IS NULL;uniqueIndex:idx_tags_workspace_tag_name,priority:2,where:workspace_id IS NOT NULL"`
+	UserID  uint   `json:"user_id" gorm:"uniqueIndex:idx_tags_user_tag_name,priority:1"`
+	WorkspaceID *uint `json:"workspace_id" gorm:"index;uniqueIndex:idx_tags_workspace_tag_name,priority:1"` // แท็กที่ใช้ร่วมกันในเวิร์กสเปซ
+	ParentID *uint  `json:"parent_id" gorm:"index"` // แท็กแม่ ได้มาจากชื่อแบบลำดับชั้น เช่น work/projects/alpha
+	Color    string `json:"color"`
+	Icon     string `json:"icon"`
     Notes   []Note `gorm:"many2many:note_tags;joinForeignKey:TagID;joinReferences:NoteID;constraint:OnDelete:CASCADE;"`
 }
 
 type ShareNote struct {
-	ShareNoteID uint `json:"share_note_id" gorm:"primaryKey"`
-	NoteID      uint `json:"note_id"`
-	SharedWith  uint `json:"shared_with"`
+	ShareNoteID uint   `json:"share_note_id" gorm:"primaryKey"`
+	NoteID      uint   `json:"note_id"`
+	SharedWith  uint   `json:"shared_with"`
+	Permission  string `json:"permission" gorm:"default:editor"` // editor หรือ viewer
+	Status      string `json:"status" gorm:"default:accepted"`  // pending, accepted หรือ declined
+	CreatedAt   string `json:"created_at"`
+}
+
+// IncomingShare ข้อมูลโน้ตที่คนอื่นแชร์มาให้ ใช้แสดงในกล่องข้อความของผู้รับ
+type IncomingShare struct {
+	ShareNoteID uint   `json:"share_note_id"`
+	NoteID      uint   `json:"note_id"`
+	NoteTitle   string `json:"note_title"`
+	OwnerID     uint   `json:"owner_id"`
+	OwnerEmail  string `json:"owner_email"`
+	Permission  string `json:"permission"`
+	Status      string `json:"status"`
+	CreatedAt   string `json:"created_at"`
+	GroupID     uint   `json:"group_id,omitempty"`   // แชร์ผ่านกลุ่ม ตอบรับด้วย note_id แทน share_note_id
+	GroupName   string `json:"group_name,omitempty"`
 }
 
 type Event struct {
@@ -54,3 +86,55 @@ type Event struct {
 	StartTime string `json:"start_time"`
 	EndTime   string `json:"end_time"`
 }
+
+// ShareInvitation คำเชิญแชร์โน้ตให้อีเมลที่ยังไม่มีบัญชี จะถูกแปลงเป็น ShareNote เมื่ออีเมลนั้นสมัครสมาชิก
+type ShareInvitation struct {
+	InvitationID uint   `json:"invitation_id" gorm:"primaryKey"`
+	NoteID       uint   `json:"note_id" gorm:"index"`
+	InvitedBy    uint   `json:"invited_by"`
+	Email        string `json:"email" gorm:"index"`
+	Token        string `json:"-" gorm:"uniqueIndex"`
+	CreatedAt    string `json:"created_at"`
+	AcceptedAt   string `json:"accepted_at"`
+}
+
+// NoteBatchOperation คำสั่งหนึ่งรายการใน POST /note/batch
+type NoteBatchOperation struct {
+	Op       string  `json:"op"` // set_color, set_priority, add_tag, remove_tag, delete, restore, archive, unarchive
+	NoteID   uint    `json:"note_id"`
+	Color    *string `json:"color"`
+	Priority *int    `json:"priority"`
+	TagID    uint    `json:"tag_id"`
+}
+
+// NoteBatchResult ผลของแต่ละคำสั่ง
+type NoteBatchResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	NoteID uint   `json:"note_id"`
+	Status string `json:"status"` // applied, failed, rolled_back, skipped
+	Error  string `json:"error,omitempty"`
+}
+
+// TodoItemInput รายการ ToDo ที่ส่งมาทั้งรายการใน PUT /note/:noteid และ sync
+// ฟิลด์ที่ไม่ได้ส่งมาจะใช้ค่าเดิมของรายการที่มี ID เดียวกัน parent_id หรือ assignee_id เป็น 0 คือยกเลิก
+type TodoItemInput struct {
+	ID         uint    `json:"id"`
+	Content    string  `json:"content"`
+	IsDone     bool    `json:"is_done"`
+	DueDate    *string `json:"due_date"`
+	Priority   *int    `json:"priority"`
+	ParentID   *uint   `json:"parent_id"`
+	AssigneeID *uint   `json:"assignee_id"`
+}
+
+// TodoItemUpdate ค่าที่แก้ไขได้ของรายการ ToDo ส่งเฉพาะฟิลด์ที่ต้องการเปลี่ยน
+// parent_id หรือ assignee_id เป็น 0 คือยกเลิก due_date เป็น "" คือไม่มีกำหนด
+type TodoItemUpdate struct {
+	Content    *string `json:"content"`
+	IsDone     *bool   `json:"is_done"`
+	DueDate    *string `json:"due_date"`
+	Priority   *int    `json:"priority"`
+	ParentID   *uint   `json:"parent_id"`
+	AssigneeID *uint   `json:"assignee_id"`
+}
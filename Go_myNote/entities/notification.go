@@ -0,0 +1,15 @@
+package entities
+
+// Notification การแจ้งเตือนในแอปของผู้ใช้ (เตือนความจำ การแชร์ การกล่าวถึง)
+type Notification struct {
+	NotificationID uint   `json:"notification_id" gorm:"primaryKey"`
+	UserID         uint   `json:"user_id" gorm:"index"`
+	Type           string `json:"type"` // reminder, share, share_revoked, share_response, ownership_transferred, mention
+	Title          string `json:"title"`
+	Message        string `json:"message"`
+	NoteID         uint   `json:"note_id"`  // 0 ถ้าไม่ผูกกับโน้ต
+	ActorID        uint   `json:"actor_id"` // 0 ถ้าเกิดจากระบบ เช่น เตือนความจำ
+	IsRead         bool   `json:"is_read" gorm:"index"`
+	ReadAt         string `json:"read_at"`
+	CreatedAt      string `json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package entities
+
+// PublicLink ลิงก์สาธารณะสำหรับเปิดดูโน้ตแบบอ่านอย่างเดียวโดยไม่ต้องล็อกอิน
+type PublicLink struct {
+	LinkID       uint   `json:"link_id" gorm:"primaryKey"`
+	NoteID       uint   `json:"note_id" gorm:"index"`
+	Slug         string `json:"slug" gorm:"uniqueIndex"`
+	PasswordHash string `json:"-"`
+	ExpiresAt    string `json:"expires_at"` // ว่างหมายถึงไม่มีวันหมดอายุ
+	ViewCount    int    `json:"view_count"`
+	CreatedBy    uint   `json:"created_by"`
+	CreatedAt    string `json:"created_at"`
+	RevokedAt    string `json:"revoked_at"`
+}
@@ -0,0 +1,34 @@
+package entities
+
+// NoteRecurrence การทำซ้ำของโน้ต ToDo เมื่อถึงเวลาจะรีเซ็ตรายการ (reset) หรือคัดลอกเป็นโน้ตใหม่ (clone)
+type NoteRecurrence struct {
+	RecurrenceID uint   `json:"recurrence_id" gorm:"primaryKey"`
+	NoteID       uint   `json:"note_id" gorm:"uniqueIndex"` // โหมด clone จะย้ายไปโน้ตรอบล่าสุด
+	UserID       uint   `json:"user_id" gorm:"index"`
+	Frequency    string `json:"frequency"` // daily, weekly, monthly หรือ yearly
+	Mode         string `json:"mode"`      // reset หรือ clone
+	AnchorAt     string `json:"anchor_at"` // เวลาเริ่มต้นที่ใช้คำนวณทุกรอบ เพื่อไม่ให้วันเลื่อนไปเรื่อย ๆ
+	NextRunAt    string `json:"next_run_at"`
+	LastRunAt    string `json:"last_run_at"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
+}
+
+// RecurrenceInstance ประวัติของรอบที่จบไปแล้ว เก็บสถานะรายการ ToDo ก่อนรีเซ็ต
+type RecurrenceInstance struct {
+	InstanceID   uint             `json:"instance_id" gorm:"primaryKey"`
+	RecurrenceID uint             `json:"recurrence_id" gorm:"index"`
+	NoteID       uint             `json:"note_id" gorm:"index"` // โน้ตของรอบนั้น
+	NextNoteID   uint             `json:"next_note_id"`         // โหมด clone: โน้ตของรอบถัดไป
+	PeriodStart  string           `json:"period_start"`
+	PeriodEnd    string           `json:"period_end"`
+	TotalItems   int              `json:"total_items"`
+	DoneItems    int              `json:"done_items"`
+	Completed    bool             `json:"completed"`
+	Items        []RecurrenceItem `json:"items" gorm:"serializer:json"`
+}
+
+type RecurrenceItem struct {
+	Content string `json:"content"`
+	IsDone  bool   `json:"is_done"`
+}
@@ -0,0 +1,18 @@
+package entities
+
+// RateLimit เก็บสถานะการจำกัดจำนวนคำขอของแต่ละ key (เช่น "ip:1.2.3.4" หรือ "email:a@b.com")
+type RateLimit struct {
+	Key          string `json:"key" gorm:"primaryKey"`
+	Hits         int    `json:"hits"`
+	Strikes      int    `json:"strikes"` // จำนวนครั้งที่ถูกบล็อก ใช้คำนวณ backoff แบบ exponential
+	WindowStart  string `json:"window_start"`
+	BlockedUntil string `json:"blocked_until"`
+}
+
+type AuditLog struct {
+	AuditID   uint   `json:"audit_id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id"`
+	Action    string `json:"action"`
+	Detail    string `json:"detail"`
+	CreatedAt string `json:"created_at"`
+}
@@ -0,0 +1,53 @@
+package entities
+
+// UserStats สถิติของผู้ใช้สำหรับหน้าแดชบอร์ด นับเฉพาะโน้ตที่ผู้ใช้เป็นเจ้าของและไม่อยู่ในถังขยะ
+type UserStats struct {
+	From                 string            `json:"from"` // วันแรกของช่วงที่ใช้นับรายวัน/รายสัปดาห์
+	To                   string            `json:"to"`
+	TotalNotes           int64             `json:"total_notes"`
+	NotesCreatedPerDay   []PeriodCount     `json:"notes_created_per_day"`
+	NotesCreatedPerWeek  []PeriodCount     `json:"notes_created_per_week"` // period คือวันจันทร์ของสัปดาห์
+	TodosCompletedPerDay []PeriodCount     `json:"todos_completed_per_day"`
+	OverdueTodos         int64             `json:"overdue_todos"`
+	OverdueReminders     []OverdueReminder `json:"overdue_reminders"`
+	NotesByPriority      []PriorityCount   `json:"notes_by_priority"`
+	NotesByColor         []ColorCount      `json:"notes_by_color"`
+	TopTags              []TagUsage        `json:"top_tags"`
+	Shares               ShareStats        `json:"shares"`
+}
+
+type PeriodCount struct {
+	Period string `json:"period"`
+	Count  int64  `json:"count"`
+}
+
+type PriorityCount struct {
+	Priority int   `json:"priority"`
+	Count    int64 `json:"count"`
+}
+
+type ColorCount struct {
+	Color string `json:"color"`
+	Count int64  `json:"count"`
+}
+
+type TagUsage struct {
+	TagID   uint   `json:"tag_id"`
+	TagName string `json:"tag_name"`
+	Count   int64  `json:"count"`
+}
+
+// OverdueReminder การแจ้งเตือนแบบครั้งเดียวที่เลยเวลาแล้ว แต่โน้ตยังไม่เสร็จ
+type OverdueReminder struct {
+	ReminderID   uint   `json:"reminder_id"`
+	NoteID       uint   `json:"note_id"`
+	NoteTitle    string `json:"note_title"`
+	ReminderTime string `json:"reminder_time"`
+}
+
+type ShareStats struct {
+	NotesSharedByMe int64 `json:"notes_shared_by_me"`
+	Collaborators   int64 `json:"collaborators"` // จำนวนผู้ใช้ที่ไม่ซ้ำกันที่ฉันแชร์โน้ตให้
+	SharedWithMe    int64 `json:"shared_with_me"`
+	PendingInbox    int64 `json:"pending_inbox"` // คำขอแชร์ที่รอฉันตอบรับ
+}
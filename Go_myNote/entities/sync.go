@@ -0,0 +1,54 @@
+package entities
+
+// SyncTombstone บอกไคลเอนต์ว่าข้อมูลถูกลบหรือผู้ใช้ไม่มีสิทธิ์เข้าถึงแล้ว
+type SyncTombstone struct {
+	EntityType string `json:"entity_type"` // note, tag, reminder
+	EntityID   uint   `json:"entity_id"`
+	NoteID     uint   `json:"note_id"`
+	Reason     string `json:"reason"` // deleted, merged, trashed, archived, access_revoked
+}
+
+// SyncNoteShares รายชื่อผู้ที่โน้ตถูกแชร์ให้ ส่งเฉพาะโน้ตที่ผู้ใช้เป็นเจ้าของ
+type SyncNoteShares struct {
+	NoteID uint                `json:"note_id"`
+	Shares []map[string]string `json:"shares"`
+}
+
+// SyncChanges การเปลี่ยนแปลงตั้งแต่ cursor ที่ไคลเอนต์ส่งมา
+// Notes มีข้อมูลล่าสุดทั้งโน้ต (รวม todo และ reminder) ให้ไคลเอนต์แทนที่ของเดิม
+type SyncChanges struct {
+	Cursor     uint             `json:"cursor"`
+	HasMore    bool             `json:"has_more"`
+	FullSync   bool             `json:"full_sync"`
+	Notes      []Note           `json:"-"`
+	Tags       []Tag            `json:"tags"` // nil ถ้าแท็กไม่มีการเปลี่ยนแปลง
+	Shares     []SyncNoteShares `json:"shares"`
+	Tombstones []SyncTombstone  `json:"tombstones"`
+}
+
+// SyncMutation การแก้ไขหนึ่งรายการที่ไคลเอนต์ทำระหว่างออฟไลน์
+type SyncMutation struct {
+	ClientID    string  `json:"client_id"`
+	Op          string  `json:"op"` // create_note, update_note, delete_note, restore_note, archive_note, unarchive_note, todo_status, add_tag, remove_tag
+	NoteID      uint    `json:"note_id"`
+	Force       bool    `json:"force"` // เขียนทับแม้มีการเปลี่ยนแปลงอื่นหลัง cursor
+	Title       *string `json:"title"`
+	Content     *string `json:"content"`
+	TodoItems   []TodoItemInput `json:"todo_items"`
+	IsTodo      bool    `json:"is_todo"`
+	Color       *string `json:"color"`
+	Priority    *int    `json:"priority"`
+	WorkspaceID *uint   `json:"workspace_id"`
+	TodoID      uint    `json:"todo_id"`
+	IsDone      bool    `json:"is_done"`
+	TagID       uint    `json:"tag_id"`
+}
+
+// SyncResult ผลของแต่ละ mutation
+type SyncResult struct {
+	ClientID   string `json:"client_id"`
+	Status     string `json:"status"` // applied, conflict, error
+	NoteID     uint   `json:"note_id"`
+	Error      string `json:"error,omitempty"`
+	ServerNote *Note  `json:"-"` // ข้อมูลล่าสุดบนเซิร์ฟเวอร์ ใช้แก้ conflict
+}
@@ -0,0 +1,17 @@
+package entities
+
+// NoteTemplate แม่แบบโน้ตของผู้ใช้ ข้อความรองรับ placeholder เช่น {{date}} ซึ่งจะถูกแทนค่าตอนสร้างโน้ต
+type NoteTemplate struct {
+	TemplateID uint     `json:"template_id" gorm:"primaryKey"`
+	UserID     uint     `json:"user_id" gorm:"index"`
+	Name       string   `json:"name"`
+	Title      string   `json:"title"`
+	Content    string   `json:"content"`
+	IsTodo     bool     `json:"is_todo"`
+	TodoItems  []string `json:"todo_items" gorm:"serializer:json"` // เนื้อหาของรายการ ToDo ตามลำดับ
+	Color      string   `json:"color"`
+	Priority   int      `json:"priority"`
+	TagIDs     []uint   `json:"tag_ids" gorm:"serializer:json"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
+}
@@ -7,6 +7,9 @@ type User struct {
 	Email               string  `json:"email" gorm:"unique"`
 	Password            string  `json:"password"`
 	GoogleCalendarToken string  `json:"google_calendar_token"`
+	FailedLoginAttempts int     `json:"-"`
+	LockedUntil         string  `json:"-"`
+	ShareEmailsOptOut   bool    `json:"share_emails_opt_out"`
 	Notes               []Note  `gorm:"foreignKey:UserID"`
 	SharedNotes         []ShareNote `gorm:"foreignKey:SharedWith"`
 }
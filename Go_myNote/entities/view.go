@@ -0,0 +1,35 @@
+package entities
+
+// SavedView มุมมองที่ผู้ใช้บันทึกไว้ เก็บเงื่อนไขการค้นหาโน้ตเพื่อเรียกใช้ซ้ำ
+type SavedView struct {
+	ViewID    uint      `json:"view_id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	Name      string    `json:"name"`
+	Query     NoteQuery `json:"query" gorm:"serializer:json"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+}
+
+// NoteQuery เงื่อนไขการค้นหาโน้ต ฟิลด์ที่ว่างคือไม่กรองด้วยเงื่อนไขนั้น
+type NoteQuery struct {
+	Text            string          `json:"text"` // ค้นหาในชื่อ เนื้อหา และรายการ ToDo
+	TagIDs          []uint          `json:"tag_ids"`
+	MatchAllTags    bool            `json:"match_all_tags"` // true ต้องมีครบทุกแท็ก false มีแท็กใดแท็กหนึ่งก็พอ แท็กแม่นับรวมแท็กย่อย
+	Colors          []string        `json:"colors"`
+	MinPriority     *int            `json:"min_priority"`
+	MaxPriority     *int            `json:"max_priority"`
+	TodoStatus      string          `json:"todo_status"` // open, done, none หรือว่าง
+	ReminderWindow  *ReminderWindow `json:"reminder_window"`
+	IncludeArchived bool            `json:"include_archived"`
+	SortBy          string          `json:"sort_by"` // updated_at (ค่าเริ่มต้น), created_at, priority, title
+
+	// ช่วงเวลาแจ้งเตือนที่แปลงเป็นเวลาจริงแล้ว service คำนวณก่อนค้นหา ไม่ได้บันทึกลงฐานข้อมูล
+	ReminderFrom string `json:"-"`
+	ReminderTo   string `json:"-"`
+}
+
+// ReminderWindow ช่วงวันนับจากวันนี้ เช่น {0, 7} คือวันนี้ถึงอีก 7 วัน {-7, -1} คือสัปดาห์ที่แล้ว
+type ReminderWindow struct {
+	FromDays int `json:"from_days"`
+	ToDays   int `json:"to_days"`
+}
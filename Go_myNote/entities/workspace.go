@@ -0,0 +1,28 @@
+package entities
+
+// Workspace สมุดโน้ตของทีม โน้ตในเวิร์กสเปซทุกสมาชิกมองเห็นได้
+type Workspace struct {
+	WorkspaceID uint   `json:"workspace_id" gorm:"primaryKey"`
+	Name        string `json:"name"`
+	OwnerID     uint   `json:"owner_id" gorm:"index"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// WorkspaceMember สมาชิกของเวิร์กสเปซ role เป็น owner, admin, editor หรือ viewer
+type WorkspaceMember struct {
+	WorkspaceMemberID uint   `json:"workspace_member_id" gorm:"primaryKey"`
+	WorkspaceID       uint   `json:"workspace_id" gorm:"uniqueIndex:idx_workspace_member"`
+	UserID            uint   `json:"user_id" gorm:"uniqueIndex:idx_workspace_member"`
+	Role              string `json:"role"`
+	CreatedAt         string `json:"created_at"`
+}
+
+// WorkspaceMemberInfo ข้อมูลสมาชิกพร้อมอีเมล ใช้แสดงรายชื่อสมาชิก
+type WorkspaceMemberInfo struct {
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
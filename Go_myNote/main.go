@@ -7,6 +7,7 @@ import (
 	"log"
 	"miw/adapters/gormRepository"
 	"miw/adapters/httpHandler"
+	"miw/adapters/memoryRepository"
 	"miw/database"
 	"miw/entities"
 	"miw/middleware"
@@ -44,6 +45,11 @@ func main() {
 		log.Fatal("Failed to connect to the database:", err)
 	}
 
+	// ชื่อแท็กเปลี่ยนจากไม่ซ้ำทั้งระบบเป็นไม่ซ้ำต่อผู้ใช้ ต้องลบ constraint เดิมก่อน AutoMigrate
+	if err := gormRepository.MigrateTagNameUniqueness(database); err != nil {
+		log.Fatal("Failed to migrate tag names:", err)
+	}
+
 	// สร้างตารางอัตโนมัติโดยใช้ AutoMigrate
 	err = database.AutoMigrate(
 		&entities.User{},
@@ -53,6 +59,24 @@ func main() {
 		&entities.ShareNote{},
 		&entities.Event{},
 		&entities.ToDo{},
+		&entities.RateLimit{},
+		&entities.AuditLog{},
+		&entities.PublicLink{},
+		&entities.ShareInvitation{},
+		&entities.Workspace{},
+		&entities.WorkspaceMember{},
+		&entities.ContactGroup{},
+		&entities.ContactGroupMember{},
+		&entities.GroupShare{},
+		&entities.GroupShareResponse{},
+		&entities.Activity{},
+		&entities.Comment{},
+		&entities.Mention{},
+		&entities.Notification{},
+		&entities.NoteTemplate{},
+		&entities.NoteRecurrence{},
+		&entities.RecurrenceInstance{},
+		&entities.SavedView{},
 	)
 
 	if err != nil {
@@ -65,19 +89,76 @@ func main() {
 	tagRepo := gormRepository.NewGormTagRepository(database)
 	reminderRepo := gormRepository.NewGormReminderRepository(database)
 	sharenoteRepo := gormRepository.NewGormShareNoteRepository(database)
+	auditRepo := gormRepository.NewGormAuditRepository(database)
+	publicLinkRepo := gormRepository.NewGormPublicLinkRepository(database)
+	workspaceRepo := gormRepository.NewGormWorkspaceRepository(database)
+	contactGroupRepo := gormRepository.NewGormContactGroupRepository(database)
+	activityRepo := gormRepository.NewGormActivityRepository(database)
+	commentRepo := gormRepository.NewGormCommentRepository(database)
+	mentionRepo := gormRepository.NewGormMentionRepository(database)
+	notificationRepo := gormRepository.NewGormNotificationRepository(database)
+	templateRepo := gormRepository.NewGormTemplateRepository(database)
+	recurrenceRepo := gormRepository.NewGormRecurrenceRepository(database)
+	statsRepo := gormRepository.NewGormStatsRepository(database)
+	viewRepo := gormRepository.NewGormViewRepository(database)
+
+	// เลือกที่เก็บสถานะ rate limit ตามการตั้งค่า
+	var rateLimitRepo repository.RateLimitRepository
+	if cfg.RateLimitStore == "postgres" {
+		rateLimitRepo = gormRepository.NewGormRateLimitRepository(database)
+	} else {
+		rateLimitRepo = memoryRepository.NewMemoryRateLimitRepository()
+	}
 
-	userService := service.NewUserService(userRepo)
-	noteService := service.NewNoteService(noteRepo, sharenoteRepo)
-	tagService := service.NewTagService(tagRepo, noteRepo)
-	reminderService := service.NewReminderService(reminderRepo, noteRepo, userRepo)
-	sharenoteService := service.NewShareNoteService(sharenoteRepo, noteRepo)
+	passwordPolicy, err := service.NewPasswordPolicy(cfg.PasswordMinLength, cfg.PasswordHashCost, cfg.BreachedPasswordFile)
+	if err != nil {
+		log.Fatal("Failed to load password policy:", err)
+	}
+
+	scheduler := service.NewScheduler()
+	activityBroker := service.NewActivityBroker(activityRepo)
+	activityRecorder := service.NewActivityRecorder(activityRepo, activityBroker)
+	notificationService := service.NewNotificationService(notificationRepo, service.NewNotificationBroker())
+	mentionService := service.NewMentionService(mentionRepo, noteRepo, userRepo, notificationService)
+
+	reminderService := service.NewReminderService(reminderRepo, noteRepo, userRepo, scheduler, activityRecorder, notificationService)
+	sharenoteService := service.NewShareNoteService(sharenoteRepo, noteRepo, contactGroupRepo, activityRecorder, notificationService)
+	userService := service.NewUserService(userRepo, auditRepo, reminderService, passwordPolicy, sharenoteService)
+	noteService := service.NewNoteService(noteRepo, sharenoteService, workspaceRepo, activityRecorder, mentionService, notificationService)
+	tagService := service.NewTagService(tagRepo, noteRepo, workspaceRepo, activityRecorder)
+	rateLimitService := service.NewRateLimitService(rateLimitRepo, auditRepo)
+	publicLinkService := service.NewPublicLinkService(publicLinkRepo, noteRepo)
+	workspaceService := service.NewWorkspaceService(workspaceRepo, userRepo)
+	contactGroupService := service.NewContactGroupService(contactGroupRepo, userRepo)
+	activityService := service.NewActivityService(activityRepo, noteRepo)
+	eventService := service.NewEventService(activityRepo, noteRepo, activityBroker)
+	syncService := service.NewSyncService(noteService, noteRepo, tagRepo, sharenoteRepo, activityRepo)
+	commentService := service.NewCommentService(commentRepo, noteRepo, activityRecorder, mentionService)
+	templateService := service.NewTemplateService(templateRepo, noteRepo, noteService)
+	recurrenceService := service.NewRecurrenceService(recurrenceRepo, noteRepo, noteService, scheduler, activityRecorder, notificationService)
+	recurrenceService.ScheduleAll()
+	statsService := service.NewStatsService(statsRepo)
+	viewService := service.NewViewService(viewRepo, noteRepo)
 
 	// สร้าง Handlers สำหรับ HTTP
-	userHandler := httpHandler.NewHttpUserHandler(userService)
+	userHandler := httpHandler.NewHttpUserHandler(userService, rateLimitService)
 	noteHandler := httpHandler.NewHttpNoteHandler(noteService)
 	tagHandler := httpHandler.NewHttpTagHandler(tagService)
 	reminderHandler := httpHandler.NewHttpReminderHandler(reminderService)
 	sharenoteHandler := httpHandler.NewShareNoteHandler(sharenoteService)
+	publicLinkHandler := httpHandler.NewHttpPublicLinkHandler(publicLinkService)
+	workspaceHandler := httpHandler.NewHttpWorkspaceHandler(workspaceService)
+	contactGroupHandler := httpHandler.NewHttpContactGroupHandler(contactGroupService)
+	activityHandler := httpHandler.NewHttpActivityHandler(activityService)
+	commentHandler := httpHandler.NewHttpCommentHandler(commentService)
+	mentionHandler := httpHandler.NewHttpMentionHandler(mentionService)
+	notificationHandler := httpHandler.NewHttpNotificationHandler(notificationService)
+	eventHandler := httpHandler.NewHttpEventHandler(eventService)
+	syncHandler := httpHandler.NewHttpSyncHandler(syncService)
+	templateHandler := httpHandler.NewHttpTemplateHandler(templateService)
+	recurrenceHandler := httpHandler.NewHttpRecurrenceHandler(recurrenceService)
+	statsHandler := httpHandler.NewHttpStatsHandler(statsService)
+	viewHandler := httpHandler.NewHttpViewHandler(viewService)
 
 	// สร้าง Fiber App และเพิ่ม Middleware
 	app := fiber.New()
@@ -219,14 +300,18 @@ func main() {
 	//********************************************
 	// User
 	//********************************************
-	app.Post("/register", userHandler.Register)
-	app.Post("/login", userHandler.Login)
+	rateLimit := middleware.RateLimitMiddleware(rateLimitService, "auth")
+	app.Post("/register", rateLimit, userHandler.Register)
+	app.Post("/login", rateLimit, userHandler.Login)
 
-	app.Post("/forgot-password", userHandler.ForgotPassword)
+	app.Post("/forgot-password", rateLimit, userHandler.ForgotPassword)
 	app.Post("/reset-password", userHandler.ChangePassword)
 
 	app.Get("/user/:userid", middleware.AuthMiddleware, userHandler.GetUser)        // ดูข้อมูล user
 	app.Put("/user/:userid", middleware.AuthMiddleware, userHandler.ChangeUsername) // แก้ไข username
+	app.Delete("/user/:userid", middleware.AuthMiddleware, userHandler.DeleteAccount) // ลบบัญชี
+	app.Put("/user/:userid/password", middleware.AuthMiddleware, userHandler.UpdatePassword) // เปลี่ยนรหัสผ่าน
+	app.Put("/user/:userid/notification-settings", middleware.AuthMiddleware, userHandler.UpdateNotificationSettings) // ตั้งค่าการแจ้งเตือน
 
 	//********************************************
 	// Note
@@ -240,6 +325,22 @@ func main() {
 	app.Delete("/note/:noteid", middleware.AuthMiddleware, noteHandler.DeleteNoteHandler) // ลบ note
 	app.Put("/note/restore/:noteid", middleware.AuthMiddleware, noteHandler.RestoreNoteHandler)
 	app.Get("/note/deleted/:userid", middleware.AuthMiddleware, noteHandler.GetDeletedNotesHandler)
+	app.Put("/note/:noteid/workspace", middleware.AuthMiddleware, noteHandler.MoveNoteToWorkspaceHandler) // ย้ายโน้ตเข้า/ออกเวิร์กสเปซ
+	app.Put("/note/archive/:noteid", middleware.AuthMiddleware, noteHandler.ArchiveNoteHandler)
+	app.Put("/note/unarchive/:noteid", middleware.AuthMiddleware, noteHandler.UnarchiveNoteHandler)
+	app.Get("/note/archived/:userid", middleware.AuthMiddleware, noteHandler.GetArchivedNotesHandler)
+	app.Post("/note/batch", middleware.AuthMiddleware, noteHandler.BatchNoteHandler) // หลายคำสั่งใน transaction เดียว
+	app.Post("/note/:noteid/todo", middleware.AuthMiddleware, noteHandler.AddTodoItemHandler)
+	app.Put("/note/:noteid/todo/reorder", middleware.AuthMiddleware, noteHandler.ReorderTodoItemsHandler) // body {"todo_ids": [...]}
+	app.Put("/note/:noteid/todo/:todoid", middleware.AuthMiddleware, noteHandler.UpdateTodoItemHandler)
+	app.Put("/note/:noteid/todo/:todoid/status", middleware.AuthMiddleware, noteHandler.UpdateTodoStatusHandler)
+	app.Delete("/note/:noteid/todo/:todoid", middleware.AuthMiddleware, noteHandler.DeleteTodoItemHandler) // ลบพร้อมรายการย่อย
+	app.Post("/note/:noteid/duplicate", middleware.AuthMiddleware, noteHandler.DuplicateNoteHandler)
+	app.Post("/note/:noteid/save-as-template", middleware.AuthMiddleware, templateHandler.SaveNoteAsTemplateHandler)
+	app.Put("/note/:noteid/recurrence", middleware.AuthMiddleware, recurrenceHandler.SetRecurrenceHandler) // รีเซ็ต/คัดลอกรายการ ToDo ตามรอบ
+	app.Get("/note/:noteid/recurrence", middleware.AuthMiddleware, recurrenceHandler.GetRecurrenceHandler)
+	app.Delete("/note/:noteid/recurrence", middleware.AuthMiddleware, recurrenceHandler.DeleteRecurrenceHandler)
+	app.Get("/note/:noteid/recurrence/history", middleware.AuthMiddleware, recurrenceHandler.GetHistoryHandler)
 	//********************************************
 	// Add Tag to Note And Remove Tag from Note
 	//********************************************
@@ -259,8 +360,9 @@ func main() {
 	app.Get("/tag", middleware.AuthMiddleware, tagHandler.GetAllTagsHandler)           // ดู tag ทั้งหมด
 	app.Post("/tag", middleware.AuthMiddleware, tagHandler.CreateTagHandler)           // สร้าง tag
 	app.Get("/tag/:tagid", middleware.AuthMiddleware, tagHandler.GetTagHandler)        // ดู tag
-	app.Put("/tag/:tagid", middleware.AuthMiddleware, tagHandler.UpdateTagNameHandler) // แก้ไขชื่อ tag
+	app.Put("/tag/:tagid", middleware.AuthMiddleware, tagHandler.UpdateTagNameHandler) // แก้ไขชื่อ สี และไอคอนของ tag
 	app.Delete("/tag/:tagid", middleware.AuthMiddleware, tagHandler.DeleteTagHandler)  // ลบ tag
+	app.Post("/tag/:tagid/merge", middleware.AuthMiddleware, tagHandler.MergeTagsHandler) // รวม tag เข้ากับ target_tag_id
 
 	//********************************************
 	// sharenote
@@ -268,6 +370,106 @@ func main() {
 	app.Post("/note/share", middleware.AuthMiddleware, sharenoteHandler.ShareNoteHandler)
 	app.Get("/note/:noteid/shared-emails", middleware.AuthMiddleware, sharenoteHandler.GetSharedEmailsHandler)
 	app.Post("/note/remove-share", middleware.AuthMiddleware, sharenoteHandler.RemoveShareHandler)
+	app.Post("/note/transfer", middleware.AuthMiddleware, sharenoteHandler.TransferOwnershipHandler)
+	app.Get("/note/:noteid/invitations", middleware.AuthMiddleware, sharenoteHandler.GetInvitationsHandler)
+	app.Delete("/invitation/:invitationid", middleware.AuthMiddleware, sharenoteHandler.RevokeInvitationHandler)
+	app.Get("/invitation/:token", sharenoteHandler.GetInvitationInfoHandler) // ไม่ต้องล็อกอิน
+	app.Get("/share/inbox", middleware.AuthMiddleware, sharenoteHandler.GetIncomingSharesHandler)
+	app.Put("/share/:shareid/accept", middleware.AuthMiddleware, sharenoteHandler.AcceptShareHandler)
+	app.Put("/share/:shareid/decline", middleware.AuthMiddleware, sharenoteHandler.DeclineShareHandler)
+	app.Post("/note/:noteid/leave", middleware.AuthMiddleware, sharenoteHandler.LeaveSharedNoteHandler)
+	app.Post("/note/share-group", middleware.AuthMiddleware, sharenoteHandler.ShareNoteWithGroupHandler)
+	app.Put("/note/:noteid/group-share/accept", middleware.AuthMiddleware, sharenoteHandler.AcceptGroupShareHandler)
+	app.Put("/note/:noteid/group-share/decline", middleware.AuthMiddleware, sharenoteHandler.DeclineGroupShareHandler)
+	app.Post("/note/remove-share-group", middleware.AuthMiddleware, sharenoteHandler.RemoveGroupShareHandler)
+
+	//********************************************
+	// Public link
+	//********************************************
+	app.Post("/note/:noteid/public-link", middleware.AuthMiddleware, publicLinkHandler.CreatePublicLinkHandler)
+	app.Get("/note/:noteid/public-link", middleware.AuthMiddleware, publicLinkHandler.GetPublicLinksHandler)
+	app.Delete("/public-link/:linkid", middleware.AuthMiddleware, publicLinkHandler.RevokePublicLinkHandler)
+	publicRateLimit := middleware.RateLimitMiddleware(rateLimitService, "public")
+	app.Get("/public/:slug", publicRateLimit, publicLinkHandler.ViewPublicNoteHandler)  // ไม่ต้องล็อกอิน
+	app.Post("/public/:slug", publicRateLimit, publicLinkHandler.ViewPublicNoteHandler) // ส่งรหัสผ่านจากฟอร์ม
+
+	//********************************************
+	// Workspace
+	//********************************************
+	app.Post("/workspace", middleware.AuthMiddleware, workspaceHandler.CreateWorkspaceHandler)
+	app.Get("/workspace", middleware.AuthMiddleware, workspaceHandler.GetWorkspacesHandler)
+	app.Get("/workspace/:workspaceid", middleware.AuthMiddleware, workspaceHandler.GetWorkspaceHandler)
+	app.Put("/workspace/:workspaceid", middleware.AuthMiddleware, workspaceHandler.UpdateWorkspaceHandler)
+	app.Delete("/workspace/:workspaceid", middleware.AuthMiddleware, workspaceHandler.DeleteWorkspaceHandler)
+	app.Get("/workspace/:workspaceid/notes", middleware.AuthMiddleware, workspaceHandler.GetWorkspaceNotesHandler)
+	app.Post("/workspace/:workspaceid/members", middleware.AuthMiddleware, workspaceHandler.AddMemberHandler)
+	app.Put("/workspace/:workspaceid/members/:memberid", middleware.AuthMiddleware, workspaceHandler.UpdateMemberRoleHandler)
+	app.Delete("/workspace/:workspaceid/members/:memberid", middleware.AuthMiddleware, workspaceHandler.RemoveMemberHandler) // ออกจากเวิร์กสเปซใช้ memberid ของตัวเอง
+
+	//********************************************
+	// Contact group
+	//********************************************
+	app.Post("/group", middleware.AuthMiddleware, contactGroupHandler.CreateGroupHandler)
+	app.Get("/group", middleware.AuthMiddleware, contactGroupHandler.GetGroupsHandler)
+	app.Get("/group/:groupid", middleware.AuthMiddleware, contactGroupHandler.GetGroupHandler)
+	app.Put("/group/:groupid", middleware.AuthMiddleware, contactGroupHandler.RenameGroupHandler)
+	app.Delete("/group/:groupid", middleware.AuthMiddleware, contactGroupHandler.DeleteGroupHandler)
+	app.Post("/group/:groupid/members", middleware.AuthMiddleware, contactGroupHandler.AddMemberHandler)
+	app.Delete("/group/:groupid/members/:memberid", middleware.AuthMiddleware, contactGroupHandler.RemoveMemberHandler)
+
+	//********************************************
+	// Activity
+	//********************************************
+	app.Get("/note/:noteid/activity", middleware.AuthMiddleware, activityHandler.GetNoteActivityHandler) // ไทม์ไลน์ของโน้ต
+	app.Get("/activity", middleware.AuthMiddleware, activityHandler.GetRecentActivityHandler)            // กิจกรรมล่าสุดในโน้ตของฉัน
+	app.Get("/events", middleware.AuthMiddleware, eventHandler.StreamEventsHandler)                      // SSE การเปลี่ยนแปลงของโน้ต รองรับ Last-Event-ID
+	app.Get("/stats", middleware.AuthMiddleware, statsHandler.GetStatsHandler)                           // แดชบอร์ด รองรับ ?days=
+
+	//********************************************
+	// Sync
+	//********************************************
+	app.Get("/sync", middleware.AuthMiddleware, syncHandler.GetChangesHandler)       // ?cursor= จาก response ครั้งก่อน
+	app.Post("/sync", middleware.AuthMiddleware, syncHandler.ApplyMutationsHandler) // ส่ง mutation ที่ทำระหว่างออฟไลน์
+
+	//********************************************
+	// Comment
+	//********************************************
+	app.Get("/note/:noteid/comments", middleware.AuthMiddleware, commentHandler.GetCommentsHandler) // รองรับ ?todo_id=
+	app.Post("/note/:noteid/comments", middleware.AuthMiddleware, commentHandler.AddCommentHandler)
+	app.Put("/comment/:commentid", middleware.AuthMiddleware, commentHandler.UpdateCommentHandler)
+	app.Delete("/comment/:commentid", middleware.AuthMiddleware, commentHandler.DeleteCommentHandler)
+	app.Put("/comment/:commentid/resolve", middleware.AuthMiddleware, commentHandler.ResolveCommentHandler)
+	app.Get("/mentions", middleware.AuthMiddleware, mentionHandler.GetMyMentionsHandler) // รายการที่ฉันถูกกล่าวถึง
+
+	//********************************************
+	// Notification
+	//********************************************
+	app.Get("/notifications", middleware.AuthMiddleware, notificationHandler.GetNotificationsHandler) // รองรับ ?unread=true&limit=
+	app.Get("/notifications/unread-count", middleware.AuthMiddleware, notificationHandler.GetUnreadCountHandler)
+	app.Get("/notifications/stream", middleware.AuthMiddleware, notificationHandler.StreamNotificationsHandler) // server-sent events
+	app.Put("/notifications/read-all", middleware.AuthMiddleware, notificationHandler.MarkAllAsReadHandler)
+	app.Put("/notifications/:notificationid/read", middleware.AuthMiddleware, notificationHandler.MarkAsReadHandler)
+
+	//********************************************
+	// Template
+	//********************************************
+	app.Post("/template", middleware.AuthMiddleware, templateHandler.CreateTemplateHandler)
+	app.Get("/template", middleware.AuthMiddleware, templateHandler.GetTemplatesHandler)
+	app.Get("/template/:templateid", middleware.AuthMiddleware, templateHandler.GetTemplateHandler)
+	app.Put("/template/:templateid", middleware.AuthMiddleware, templateHandler.UpdateTemplateHandler)
+	app.Delete("/template/:templateid", middleware.AuthMiddleware, templateHandler.DeleteTemplateHandler)
+	app.Post("/template/:templateid/use", middleware.AuthMiddleware, templateHandler.UseTemplateHandler) // body {"variables": {...}}
+
+	//********************************************
+	// Saved view
+	//********************************************
+	app.Post("/view", middleware.AuthMiddleware, viewHandler.CreateViewHandler)
+	app.Get("/view", middleware.AuthMiddleware, viewHandler.GetViewsHandler)
+	app.Post("/view/search", middleware.AuthMiddleware, viewHandler.SearchNotesHandler) // ค้นหาด้วย query ที่ยังไม่ได้บันทึก
+	app.Get("/view/:viewid", middleware.AuthMiddleware, viewHandler.GetViewHandler)
+	app.Put("/view/:viewid", middleware.AuthMiddleware, viewHandler.UpdateViewHandler)
+	app.Delete("/view/:viewid", middleware.AuthMiddleware, viewHandler.DeleteViewHandler)
+	app.Get("/view/:viewid/notes", middleware.AuthMiddleware, viewHandler.ExecuteViewHandler)
 
 	// เริ่มเซิร์ฟเวอร์
 	if err := app.Listen(":8000"); err != nil {
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"miw/usecases/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	ipRequestLimit    = 20 // จำนวนคำขอสูงสุดต่อ IP ต่อนาที
+	emailRequestLimit = 5  // จำนวนคำขอสูงสุดต่ออีเมลต่อนาที ล็อกอินสำเร็จจะล้างตัวนับนี้
+)
+
+// RateLimitMiddleware จำกัดจำนวนคำขอโดยใช้ IP และอีเมลใน Body เป็น key
+// scope แยกตัวนับ IP ของแต่ละกลุ่ม route เช่น การเปิดลิงก์สาธารณะจะไม่ทำให้ล็อกอินจาก IP เดียวกันถูกบล็อก
+func RateLimitMiddleware(limiter service.RateLimitUseCase, scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		keys := map[string]int{"ip:" + scope + ":" + c.IP(): ipRequestLimit}
+
+		// ดึงอีเมลจาก Body (ถ้ามี) โดยไม่กระทบการอ่าน Body ของ handler
+		var body struct {
+			Email string `json:"email"`
+		}
+		if err := json.Unmarshal(c.Body(), &body); err == nil && body.Email != "" {
+			keys[service.EmailRateLimitKey(body.Email)] = emailRequestLimit
+		}
+
+		for key, limit := range keys {
+			wait, err := limiter.Allow(key, limit)
+			if err != nil {
+				// ถ้าตรวจสอบไม่ได้ให้ผ่านไปก่อน เพื่อไม่ให้ระบบล็อกอินใช้งานไม่ได้
+				log.Printf("Rate limiter error for %s: %v", key, err)
+				continue
+			}
+			if wait > 0 {
+				c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "Too many requests, please try again later"})
+			}
+		}
+
+		return c.Next()
+	}
+}
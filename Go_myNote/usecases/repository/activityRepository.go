@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type ActivityRepository interface {
+	CreateActivity(activity *entities.Activity) error
+	GetActivitiesByNoteID(noteID uint, limit int) ([]entities.ActivityEntry, error)
+	GetActivitiesByNoteIDs(noteIDs []uint, actorID uint, limit int) ([]entities.ActivityEntry, error)
+	GetActivitiesAfter(noteIDs []uint, userID uint, afterID uint, limit int) ([]entities.Activity, error)
+	GetActivityRecipientIDs(activity *entities.Activity) ([]uint, error)
+	GetLatestActivityID() (uint, error)
+	CountNoteActivitiesBetween(noteID uint, afterID uint, upToID uint) (int64, error)
+	RedactEntityActivities(entityType string, entityID uint) error
+}
@@ -0,0 +1,8 @@
+package repository
+
+import "miw/entities"
+
+type AuditRepository interface {
+	CreateAuditLog(entry *entities.AuditLog) error
+	GetAuditLogsByUserID(userID uint) ([]entities.AuditLog, error)
+}
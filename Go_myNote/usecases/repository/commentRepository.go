@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type CommentRepository interface {
+	CreateComment(comment *entities.Comment) error
+	GetCommentByID(commentID uint) (*entities.Comment, error)
+	GetCommentsByNoteID(noteID uint) ([]entities.CommentEntry, error)
+	UpdateCommentContent(commentID uint, content string) error
+	SetCommentResolved(commentID uint, resolved bool, userID uint) error
+	DeleteComment(commentID uint) error
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type ContactGroupRepository interface {
+	CreateGroup(group *entities.ContactGroup) error
+	GetGroupByID(groupID uint) (*entities.ContactGroup, error)
+	GetGroupsByOwnerID(ownerID uint) ([]entities.ContactGroup, error)
+	UpdateGroupName(groupID uint, name string) error
+	DeleteGroup(groupID uint) error
+	GetMembers(groupID uint) ([]entities.ContactGroupMemberInfo, error)
+	IsMember(groupID uint, userID uint) (bool, error)
+	AddMember(member *entities.ContactGroupMember) error
+	RemoveMember(groupID uint, userID uint) error
+}
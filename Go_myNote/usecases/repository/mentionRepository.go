@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type MentionRepository interface {
+	GetMentionsBySource(noteID uint, commentID uint) ([]entities.Mention, error)
+	CreateMention(mention *entities.Mention) error
+	DeleteMentions(noteID uint, commentID uint, userIDs []uint) error
+	GetMentionsForUser(userID uint) ([]entities.MentionEntry, error)
+}
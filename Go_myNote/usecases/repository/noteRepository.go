@@ -7,12 +7,21 @@ import (
 type NoteRepository interface {
 	CreateNote(note *entities.Note) error
 	GetAllNoteByUserId(userID uint) ([]entities.Note, error)
+	SearchNotes(userID uint, query entities.NoteQuery) ([]entities.Note, error)
 	GetNoteById(noteID uint) (*entities.Note, error)
 	UpdateNoteColor(noteID uint, userID uint, color string) error 
 	UpdateNotePriority(noteID uint, userID uint, priority int) error 
 	UpdateNoteTitleAndContent(note *entities.Note) error 
 	UpdateNoteStatus(noteID uint, userID uint, isTodo *bool, isAllDone *bool) error
 	UpdateTodoStatus(noteID uint, todoID uint, isDone bool) error
+	SetAllTodoItemsDone(noteID uint, isDone bool) error
+	SyncNoteCompletion(noteID uint) (bool, error)
+	GetAcceptedShareUserIDs(noteID uint) ([]uint, error)
+	GetTodoItem(noteID uint, todoID uint) (*entities.ToDo, error)
+	CreateTodoItem(todo *entities.ToDo) error
+	UpdateTodoItem(todo *entities.ToDo) error
+	DeleteTodoItems(noteID uint, todoIDs []uint) error
+	ReorderTodoItems(noteID uint, todoIDs []uint) error
 	DeleteNoteById(noteID uint) error
 	RestoreNoteById(noteID uint) error 
 	AddTagToNote(noteID uint, tagID uint, userID uint) error
@@ -20,5 +29,10 @@ type NoteRepository interface {
 	GetNoteByIdAndUser(noteID uint, userID uint) (*entities.Note, error)
 	IsNoteOwnedByUser(noteID uint, userID uint) (bool, error)
 	IsUserAllowedToAccessNote(noteID uint, userID uint) (bool, error)
+	GetAccessibleNoteIDs(userID uint) ([]uint, error)
 	GetDeletedNotesByUserID(userID uint) ([]entities.Note, error)
+	UpdateNoteWorkspace(noteID uint, workspaceID *uint) error
+	UpdateNoteArchivedAt(noteID uint, archivedAt string) error
+	GetArchivedNotesByUserID(userID uint) ([]entities.Note, error)
+	Transaction(fn func(txRepo NoteRepository) error) error
 }
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type NotificationRepository interface {
+	CreateNotification(notification *entities.Notification) error
+	GetNotificationsByUserID(userID uint, unreadOnly bool, limit int) ([]entities.Notification, error)
+	CountUnread(userID uint) (int64, error)
+	MarkAsRead(notificationID uint, userID uint) error
+	MarkAllAsRead(userID uint) (int64, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import "miw/entities"
+
+type PublicLinkRepository interface {
+	CreatePublicLink(link *entities.PublicLink) error
+	GetPublicLinkByID(linkID uint) (*entities.PublicLink, error)
+	GetPublicLinkBySlug(slug string) (*entities.PublicLink, error)
+	GetPublicLinksByNoteID(noteID uint) ([]entities.PublicLink, error)
+	RevokePublicLink(linkID uint) error
+	IncrementViewCount(linkID uint) error
+}
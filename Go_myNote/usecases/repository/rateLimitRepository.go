@@ -0,0 +1,9 @@
+package repository
+
+import "miw/entities"
+
+type RateLimitRepository interface {
+	GetRateLimit(key string) (*entities.RateLimit, error)
+	SaveRateLimit(rateLimit *entities.RateLimit) error
+	DeleteRateLimit(key string) error
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type RecurrenceRepository interface {
+	GetRecurrenceByID(recurrenceID uint) (*entities.NoteRecurrence, error)
+	GetRecurrenceByNoteID(noteID uint) (*entities.NoteRecurrence, error)
+	GetAllRecurrences() ([]entities.NoteRecurrence, error)
+	SaveRecurrence(recurrence *entities.NoteRecurrence) error
+	DeleteRecurrence(recurrenceID uint) error
+	CreateInstance(instance *entities.RecurrenceInstance) error
+	GetInstancesByRecurrenceID(recurrenceID uint) ([]entities.RecurrenceInstance, error)
+}
@@ -10,4 +10,24 @@ type ShareNoteRepository interface {
 	ShareNoteWithEmail(noteID uint, ownerID uint, email string) ([]map[string]string, error) 
 	RemoveShareByEmail(noteID uint, ownerID uint, email string) error
 	GetSharedEmailsByNoteID(noteID uint) ([]map[string]string, error)
+	TransferNoteOwnership(noteID uint, oldOwnerID uint, newOwnerID uint) error
+	CreateInvitation(invitation *entities.ShareInvitation) error
+	GetInvitationByID(invitationID uint) (*entities.ShareInvitation, error)
+	GetInvitationByToken(token string) (*entities.ShareInvitation, error)
+	GetPendingInvitationsByNoteID(noteID uint) ([]entities.ShareInvitation, error)
+	HasPendingInvitation(noteID uint, email string) (bool, error)
+	DeleteInvitation(invitationID uint) error
+	AcceptInvitationsForUser(email string, userID uint) error
+	GetUserByID(userID uint) (*entities.User, error)
+	GetShareByID(shareID uint) (*entities.ShareNote, error)
+	GetShare(noteID uint, userID uint) (*entities.ShareNote, error)
+	UpdateShareStatus(shareID uint, status string) error
+	DeleteShare(shareID uint) error
+	GetIncomingShares(userID uint, status string) ([]entities.IncomingShare, error)
+	ShareNoteWithGroup(noteID uint, groupID uint, permission string) error
+	IsNoteSharedWithGroup(noteID uint, groupID uint) (bool, error)
+	RemoveGroupShare(noteID uint, groupID uint) error
+	HasGroupShareAccess(noteID uint, userID uint) (bool, error)
+	HasPendingGroupShare(noteID uint, userID uint) (bool, error)
+	SetGroupShareResponse(noteID uint, userID uint, status string) error
 }
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+// StatsRepository คำนวณสถิติด้วย SQL aggregate โดยไม่ต้องโหลดโน้ตทั้งหมด
+type StatsRepository interface {
+	CountNotes(userID uint) (int64, error)
+	CountNotesCreatedPerDay(userID uint, from string) ([]entities.PeriodCount, error)
+	CountNotesCreatedPerWeek(userID uint, from string) ([]entities.PeriodCount, error)
+	CountTodosCompletedPerDay(userID uint, from string) ([]entities.PeriodCount, error)
+	CountOverdueTodos(userID uint, today string) (int64, error)
+	GetOverdueReminders(userID uint, now string) ([]entities.OverdueReminder, error)
+	CountNotesByPriority(userID uint) ([]entities.PriorityCount, error)
+	CountNotesByColor(userID uint) ([]entities.ColorCount, error)
+	GetTopTags(userID uint, limit int) ([]entities.TagUsage, error)
+	GetShareStats(userID uint) (*entities.ShareStats, error)
+}
@@ -9,6 +9,8 @@ type TagRepository interface {
 	GetAllTagsByUserId(userID uint) ([]entities.Tag, error)
 	GetTagById(tagID uint) (*entities.Tag, error) 
 	GetTagsByUser(userID uint) ([]entities.Tag, error)
-	UpdateTagName(tagID, userID uint, newName string) error
-	DeleteTag(tagID, userID uint) error
+	UpdateTagName(tagID, userID uint, workspaceID *uint, newName string) error
+	UpdateTagStyle(tagID, userID uint, workspaceID *uint, color, icon *string) error
+	MergeTags(sourceID, targetID uint) error
+	DeleteTag(tagID, userID uint, workspaceID *uint) error
 }
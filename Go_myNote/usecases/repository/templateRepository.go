@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type TemplateRepository interface {
+	CreateTemplate(template *entities.NoteTemplate) error
+	GetTemplateByID(templateID uint) (*entities.NoteTemplate, error)
+	GetTemplatesByUserID(userID uint) ([]entities.NoteTemplate, error)
+	UpdateTemplate(template *entities.NoteTemplate) error
+	DeleteTemplate(templateID uint) error
+}
@@ -9,5 +9,7 @@ type UserRepository interface {
 	UpdateUser(user *entities.User) error
 	GetUserById(userID uint) (*entities.User, error)
 	GetUserByEmail(email string) (*entities.User, error)
+	GetUsersByUsername(username string) ([]entities.User, error)
 	GetUserEmailByID(userID uint) (string, error)
+	DeleteUser(userID uint, newOwnerID uint) ([]uint, error)
 }
\ No newline at end of file
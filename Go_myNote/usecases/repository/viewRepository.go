@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type ViewRepository interface {
+	CreateView(view *entities.SavedView) error
+	GetViewByID(viewID uint) (*entities.SavedView, error)
+	GetViewsByUserID(userID uint) ([]entities.SavedView, error)
+	UpdateView(view *entities.SavedView) error
+	DeleteView(viewID uint) error
+}
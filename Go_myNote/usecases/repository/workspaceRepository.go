@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"miw/entities"
+)
+
+type WorkspaceRepository interface {
+	CreateWorkspace(workspace *entities.Workspace) error
+	GetWorkspaceByID(workspaceID uint) (*entities.Workspace, error)
+	GetWorkspacesByUserID(userID uint) ([]entities.Workspace, error)
+	UpdateWorkspaceName(workspaceID uint, name string) error
+	DeleteWorkspace(workspaceID uint) error
+	GetMember(workspaceID uint, userID uint) (*entities.WorkspaceMember, error)
+	GetMembers(workspaceID uint) ([]entities.WorkspaceMemberInfo, error)
+	AddMember(member *entities.WorkspaceMember) error
+	UpdateMemberRole(workspaceID uint, userID uint, role string) error
+	RemoveMember(workspaceID uint, userID uint) error
+	GetWorkspaceNotes(workspaceID uint) ([]entities.Note, error)
+}
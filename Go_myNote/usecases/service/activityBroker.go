@@ -0,0 +1,113 @@
+package service
+
+import (
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"sync"
+)
+
+// activityBufferSize จำนวนกิจกรรมที่รอส่งได้ต่อการเชื่อมต่อ ถ้าเต็มการเชื่อมต่อนั้นจะได้รับสัญญาณ reset
+const activityBufferSize = 64
+
+// activitySubscription การเชื่อมต่อ SSE หนึ่งตัวของผู้ใช้
+type activitySubscription struct {
+	ch    chan entities.Activity
+	reset chan struct{} // ปิดเมื่อส่งกิจกรรมไม่ทันหรือหาผู้รับไม่ได้ ไคลเอนต์ต้อง sync ใหม่
+	once  sync.Once
+}
+
+func (s *activitySubscription) signalReset() {
+	s.once.Do(func() { close(s.reset) })
+}
+
+// ActivityBroker กระจายกิจกรรมที่บันทึกแล้วไปยังการเชื่อมต่อ SSE ของผู้ที่ควรได้รับเท่านั้น
+// หาผู้รับครั้งเดียวต่อกิจกรรม ไม่ใช่ตรวจสิทธิ์ทีละการเชื่อมต่อ
+type ActivityBroker struct {
+	repo        repository.ActivityRepository
+	mu          sync.Mutex
+	subscribers map[uint]map[*activitySubscription]struct{}
+}
+
+func NewActivityBroker(repo repository.ActivityRepository) *ActivityBroker {
+	return &ActivityBroker{repo: repo, subscribers: make(map[uint]map[*activitySubscription]struct{})}
+}
+
+// Subscribe เปิดช่องรับกิจกรรมของผู้ใช้ ช่อง reset จะถูกปิดเมื่อมีกิจกรรมตกหล่น ต้องเรียก cancel เมื่อเลิกใช้
+func (b *ActivityBroker) Subscribe(userID uint) (<-chan entities.Activity, <-chan struct{}, func()) {
+	sub := &activitySubscription{
+		ch:    make(chan entities.Activity, activityBufferSize),
+		reset: make(chan struct{}),
+	}
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[*activitySubscription]struct{})
+	}
+	b.subscribers[userID][sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			b.remove(userID, sub)
+			b.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+	return sub.ch, sub.reset, cancel
+}
+
+// Publish ส่งกิจกรรมให้การเชื่อมต่อของผู้รับ โดยไม่รอผู้รับที่ช้า
+// การเชื่อมต่อที่รับไม่ทันจะถูกถอดออกและได้รับสัญญาณ reset แทนการทิ้งกิจกรรมเงียบ ๆ
+func (b *ActivityBroker) Publish(activity entities.Activity) {
+	b.mu.Lock()
+	empty := len(b.subscribers) == 0
+	b.mu.Unlock()
+	if empty {
+		return
+	}
+
+	recipients, err := b.repo.GetActivityRecipientIDs(&activity)
+	if err != nil {
+		// ไม่รู้ว่าใครควรได้รับ ให้ทุกการเชื่อมต่อ sync ใหม่แทน
+		log.Printf("Failed to resolve recipients of activity %d: %v", activity.ActivityID, err)
+		b.mu.Lock()
+		for userID, subs := range b.subscribers {
+			for sub := range subs {
+				b.remove(userID, sub)
+				sub.signalReset()
+			}
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seen := make(map[uint]bool, len(recipients))
+	for _, userID := range recipients {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		for sub := range b.subscribers[userID] {
+			select {
+			case sub.ch <- activity:
+			default:
+				b.remove(userID, sub)
+				sub.signalReset()
+			}
+		}
+	}
+}
+
+// remove ถอดการเชื่อมต่อออก ต้องถือ mu อยู่
+func (b *ActivityBroker) remove(userID uint, sub *activitySubscription) {
+	delete(b.subscribers[userID], sub)
+	if len(b.subscribers[userID]) == 0 {
+		delete(b.subscribers, userID)
+	}
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"time"
+)
+
+const (
+	defaultActivityLimit = 50  // จำนวนกิจกรรมที่ส่งกลับเมื่อไม่ระบุ
+	maxActivityLimit     = 200 // จำนวนกิจกรรมสูงสุดต่อคำขอ
+)
+
+type ActivityUseCase interface {
+	GetNoteActivity(noteID uint, userID uint, limit int) ([]entities.ActivityEntry, error)
+	GetRecentActivity(userID uint, limit int) ([]entities.ActivityEntry, error)
+}
+
+type ActivityService struct {
+	activityRepo repository.ActivityRepository
+	noteRepo     repository.NoteRepository
+}
+
+func NewActivityService(activityRepo repository.ActivityRepository, noteRepo repository.NoteRepository) *ActivityService {
+	return &ActivityService{
+		activityRepo: activityRepo,
+		noteRepo:     noteRepo,
+	}
+}
+
+// GetNoteActivity ไทม์ไลน์ของโน้ต ดูได้เฉพาะผู้ที่เข้าถึงโน้ตได้
+func (s *ActivityService) GetNoteActivity(noteID uint, userID uint, limit int) ([]entities.ActivityEntry, error) {
+	allowed, err := s.noteRepo.IsUserAllowedToAccessNote(noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check access permission: %v", err)
+	}
+	if !allowed {
+		return nil, fmt.Errorf("you are not authorized to view this note")
+	}
+
+	return s.activityRepo.GetActivitiesByNoteID(noteID, normalizeActivityLimit(limit))
+}
+
+// GetRecentActivity กิจกรรมล่าสุดในโน้ตทั้งหมดที่ผู้ใช้เข้าถึงได้
+func (s *ActivityService) GetRecentActivity(userID uint, limit int) ([]entities.ActivityEntry, error) {
+	notes, err := s.noteRepo.GetAllNoteByUserId(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notes: %v", err)
+	}
+
+	noteIDs := make([]uint, 0, len(notes))
+	for _, note := range notes {
+		noteIDs = append(noteIDs, note.NoteID)
+	}
+
+	return s.activityRepo.GetActivitiesByNoteIDs(noteIDs, userID, normalizeActivityLimit(limit))
+}
+
+func normalizeActivityLimit(limit int) int {
+	if limit <= 0 {
+		return defaultActivityLimit
+	}
+	if limit > maxActivityLimit {
+		return maxActivityLimit
+	}
+	return limit
+}
+
+// ActivityRecorder ใช้ร่วมกันระหว่าง Service ต่าง ๆ เพื่อบันทึกกิจกรรม
+type ActivityRecorder struct {
+	repo    repository.ActivityRepository
+	broker  *ActivityBroker
+	pending *[]entities.Activity // ไม่เป็น nil เมื่อเลื่อนการบันทึกไปหลัง commit
+}
+
+func NewActivityRecorder(repo repository.ActivityRepository, broker *ActivityBroker) *ActivityRecorder {
+	return &ActivityRecorder{repo: repo, broker: broker}
+}
+
+// Record บันทึกกิจกรรม ถ้าบันทึกไม่สำเร็จจะแค่ log ไว้ ไม่ทำให้การแก้ไขล้มเหลว
+func (r *ActivityRecorder) Record(noteID uint, actorID uint, action string, entityType string, entityID uint, before interface{}, after interface{}) {
+	activity := &entities.Activity{
+		NoteID:     noteID,
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     activityValue(before),
+		After:      activityValue(after),
+		CreatedAt:  time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if r.pending != nil {
+		*r.pending = append(*r.pending, *activity)
+		return
+	}
+	r.save(activity)
+}
+
+func (r *ActivityRecorder) save(activity *entities.Activity) {
+	if err := r.repo.CreateActivity(activity); err != nil {
+		log.Printf("Failed to record activity %s on note %d: %v", activity.Action, activity.NoteID, err)
+		return
+	}
+	r.broker.Publish(*activity)
+}
+
+// Redact ล้างค่าเดิมที่บันทึกไว้ของ entity ใช้เมื่อข้อมูลนั้นไม่ควรอ่านได้อีก ถ้าไม่สำเร็จจะแค่ log ไว้
+func (r *ActivityRecorder) Redact(entityType string, entityID uint) {
+	if err := r.repo.RedactEntityActivities(entityType, entityID); err != nil {
+		log.Printf("Failed to redact activities of %s %d: %v", entityType, entityID, err)
+	}
+}
+
+// deferred สร้าง recorder ที่เก็บกิจกรรมไว้ก่อน ใช้กับงานใน transaction แล้วเรียก flush หลัง commit
+// ถ้า rollback ก็แค่ไม่เรียก flush
+func (r *ActivityRecorder) deferred() *ActivityRecorder {
+	return &ActivityRecorder{repo: r.repo, broker: r.broker, pending: &[]entities.Activity{}}
+}
+
+func (r *ActivityRecorder) flush() {
+	if r.pending == nil {
+		return
+	}
+	for i := range *r.pending {
+		r.save(&(*r.pending)[i])
+	}
+	*r.pending = nil
+}
+
+// activityValue แปลงค่าเป็น JSON สำหรับเก็บใน Before/After
+func activityValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
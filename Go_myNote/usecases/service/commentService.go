@@ -0,0 +1,225 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"strings"
+	"time"
+)
+
+type CommentUseCase interface {
+	GetComments(noteID uint, userID uint, todoID *uint) ([]entities.CommentThread, error)
+	AddComment(noteID uint, userID uint, content string, todoID *uint, parentID *uint) (*entities.Comment, error)
+	UpdateComment(commentID uint, userID uint, content string) error
+	DeleteComment(commentID uint, userID uint) error
+	ResolveComment(commentID uint, userID uint, resolved bool) error
+}
+
+type CommentService struct {
+	commentRepo repository.CommentRepository
+	noteRepo    repository.NoteRepository
+	activity    *ActivityRecorder
+	mentions    *MentionService
+}
+
+func NewCommentService(commentRepo repository.CommentRepository, noteRepo repository.NoteRepository, activity *ActivityRecorder, mentions *MentionService) *CommentService {
+	return &CommentService{
+		commentRepo: commentRepo,
+		noteRepo:    noteRepo,
+		activity:    activity,
+		mentions:    mentions,
+	}
+}
+
+// checkNoteAccess ใช้การตรวจสิทธิ์เดียวกับการเปิดดูโน้ต
+func (s *CommentService) checkNoteAccess(noteID uint, userID uint) error {
+	allowed, err := s.noteRepo.IsUserAllowedToAccessNote(noteID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check access permission: %v", err)
+	}
+	if !allowed {
+		return fmt.Errorf("you are not authorized to view this note")
+	}
+	return nil
+}
+
+// GetComments ดึงความคิดเห็นของโน้ตแบบเป็นเธรด ระบุ todoID เพื่อกรองเฉพาะรายการ ToDo
+func (s *CommentService) GetComments(noteID uint, userID uint, todoID *uint) ([]entities.CommentThread, error) {
+	if err := s.checkNoteAccess(noteID, userID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.commentRepo.GetCommentsByNoteID(noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	threads := []entities.CommentThread{}
+	index := make(map[uint]int)
+	for _, comment := range comments {
+		if comment.ParentID != nil {
+			continue
+		}
+		if todoID != nil && (comment.TodoID == nil || *comment.TodoID != *todoID) {
+			continue
+		}
+		index[comment.CommentID] = len(threads)
+		threads = append(threads, entities.CommentThread{CommentEntry: comment, Replies: []entities.CommentEntry{}})
+	}
+
+	for _, comment := range comments {
+		if comment.ParentID == nil || comment.DeletedAt != "" {
+			continue
+		}
+		if i, ok := index[*comment.ParentID]; ok {
+			threads[i].Replies = append(threads[i].Replies, comment)
+		}
+	}
+
+	// ความคิดเห็นหลักที่ถูกลบและไม่มีคำตอบกลับแล้วไม่ต้องแสดง
+	result := []entities.CommentThread{}
+	for _, thread := range threads {
+		if thread.DeletedAt != "" && len(thread.Replies) == 0 {
+			continue
+		}
+		result = append(result, thread)
+	}
+
+	return result, nil
+}
+
+func (s *CommentService) AddComment(noteID uint, userID uint, content string, todoID *uint, parentID *uint) (*entities.Comment, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, fmt.Errorf("comment content is required")
+	}
+	if err := s.checkNoteAccess(noteID, userID); err != nil {
+		return nil, err
+	}
+
+	if parentID != nil {
+		parent, err := s.commentRepo.GetCommentByID(*parentID)
+		if err != nil || parent.NoteID != noteID {
+			return nil, fmt.Errorf("parent comment not found")
+		}
+		// เธรดมีระดับเดียว ตอบกลับคำตอบกลับจะถูกผูกกับความคิดเห็นหลัก
+		if parent.ParentID != nil {
+			parentID = parent.ParentID
+		} else {
+			parentID = &parent.CommentID
+		}
+		todoID = parent.TodoID
+	} else if todoID != nil {
+		note, err := s.noteRepo.GetNoteById(noteID)
+		if err != nil {
+			return nil, fmt.Errorf("note not found")
+		}
+		found := false
+		for _, todo := range note.TodoItems {
+			if todo.ID == *todoID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("todo item not found")
+		}
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	comment := &entities.Comment{
+		NoteID:    noteID,
+		TodoID:    todoID,
+		ParentID:  parentID,
+		AuthorID:  userID,
+		Content:   content,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.commentRepo.CreateComment(comment); err != nil {
+		return nil, err
+	}
+
+	// ไม่เก็บข้อความไว้ในกิจกรรม เพื่อให้ข้อความที่ถูกแก้ไขหรือลบไปแล้วอ่านย้อนหลังไม่ได้
+	s.activity.Record(noteID, userID, "comment.add", "comment", comment.CommentID, nil, nil)
+	s.mentions.ProcessMentions(noteID, comment.CommentID, userID, comment.Content)
+	return comment, nil
+}
+
+// getOwnComment ดึงความคิดเห็นที่ผู้ใช้เป็นผู้เขียนและยังเข้าถึงโน้ตได้
+func (s *CommentService) getOwnComment(commentID uint, userID uint) (*entities.Comment, error) {
+	comment, err := s.commentRepo.GetCommentByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.AuthorID != userID {
+		return nil, fmt.Errorf("you are not the author of this comment")
+	}
+	if err := s.checkNoteAccess(comment.NoteID, userID); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (s *CommentService) UpdateComment(commentID uint, userID uint, content string) error {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return fmt.Errorf("comment content is required")
+	}
+
+	comment, err := s.getOwnComment(commentID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.commentRepo.UpdateCommentContent(commentID, content); err != nil {
+		return err
+	}
+
+	s.activity.Redact("comment", commentID)
+	s.activity.Record(comment.NoteID, userID, "comment.edit", "comment", commentID, nil, nil)
+	s.mentions.ProcessMentions(comment.NoteID, commentID, userID, content)
+	return nil
+}
+
+func (s *CommentService) DeleteComment(commentID uint, userID uint) error {
+	comment, err := s.getOwnComment(commentID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.commentRepo.DeleteComment(commentID); err != nil {
+		return err
+	}
+
+	s.activity.Redact("comment", commentID)
+	s.activity.Record(comment.NoteID, userID, "comment.delete", "comment", commentID, nil, nil)
+	s.mentions.ProcessMentions(comment.NoteID, commentID, userID, "") // ลบการกล่าวถึงในความคิดเห็นนี้
+	return nil
+}
+
+// ResolveComment ผู้ที่เข้าถึงโน้ตได้ปิดหรือเปิดเธรดใหม่ได้ ทำได้เฉพาะความคิดเห็นหลัก
+func (s *CommentService) ResolveComment(commentID uint, userID uint, resolved bool) error {
+	comment, err := s.commentRepo.GetCommentByID(commentID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkNoteAccess(comment.NoteID, userID); err != nil {
+		return err
+	}
+	if comment.ParentID != nil {
+		return fmt.Errorf("only top-level comments can be resolved")
+	}
+
+	if err := s.commentRepo.SetCommentResolved(commentID, resolved, userID); err != nil {
+		return err
+	}
+
+	action := "comment.resolve"
+	if !resolved {
+		action = "comment.reopen"
+	}
+	s.activity.Record(comment.NoteID, userID, action, "comment", commentID, comment.Resolved, resolved)
+	return nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"strings"
+	"time"
+)
+
+type ContactGroupUseCase interface {
+	CreateGroup(ownerID uint, name string) (*entities.ContactGroup, error)
+	GetGroups(ownerID uint) ([]entities.ContactGroup, error)
+	GetGroup(groupID uint, ownerID uint) (*entities.ContactGroup, []entities.ContactGroupMemberInfo, error)
+	RenameGroup(groupID uint, ownerID uint, name string) error
+	DeleteGroup(groupID uint, ownerID uint) error
+	AddMember(groupID uint, ownerID uint, email string) ([]entities.ContactGroupMemberInfo, error)
+	RemoveMember(groupID uint, ownerID uint, memberID uint) error
+}
+
+type ContactGroupService struct {
+	groupRepo repository.ContactGroupRepository
+	userRepo  repository.UserRepository
+}
+
+func NewContactGroupService(groupRepo repository.ContactGroupRepository, userRepo repository.UserRepository) *ContactGroupService {
+	return &ContactGroupService{
+		groupRepo: groupRepo,
+		userRepo:  userRepo,
+	}
+}
+
+func (s *ContactGroupService) CreateGroup(ownerID uint, name string) (*entities.ContactGroup, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("group name is required")
+	}
+
+	group := &entities.ContactGroup{
+		OwnerID:   ownerID,
+		Name:      name,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := s.groupRepo.CreateGroup(group); err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (s *ContactGroupService) GetGroups(ownerID uint) ([]entities.ContactGroup, error) {
+	return s.groupRepo.GetGroupsByOwnerID(ownerID)
+}
+
+func (s *ContactGroupService) GetGroup(groupID uint, ownerID uint) (*entities.ContactGroup, []entities.ContactGroupMemberInfo, error) {
+	group, err := s.getOwnedGroup(groupID, ownerID)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := s.groupRepo.GetMembers(groupID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return group, members, nil
+}
+
+func (s *ContactGroupService) RenameGroup(groupID uint, ownerID uint, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("group name is required")
+	}
+	if _, err := s.getOwnedGroup(groupID, ownerID); err != nil {
+		return err
+	}
+	return s.groupRepo.UpdateGroupName(groupID, name)
+}
+
+func (s *ContactGroupService) DeleteGroup(groupID uint, ownerID uint) error {
+	if _, err := s.getOwnedGroup(groupID, ownerID); err != nil {
+		return err
+	}
+	return s.groupRepo.DeleteGroup(groupID)
+}
+
+// AddMember เพิ่มสมาชิก สมาชิกใหม่เข้าถึงโน้ตที่แชร์ให้กลุ่มได้ทันที
+func (s *ContactGroupService) AddMember(groupID uint, ownerID uint, email string) ([]entities.ContactGroupMemberInfo, error) {
+	if _, err := s.getOwnedGroup(groupID, ownerID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil || user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	if user.UserID == ownerID {
+		return nil, fmt.Errorf("cannot add the owner to the group")
+	}
+
+	isMember, err := s.groupRepo.IsMember(groupID, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if isMember {
+		return nil, fmt.Errorf("user is already a member of this group")
+	}
+
+	member := &entities.ContactGroupMember{
+		GroupID:   groupID,
+		UserID:    user.UserID,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := s.groupRepo.AddMember(member); err != nil {
+		return nil, err
+	}
+
+	return s.groupRepo.GetMembers(groupID)
+}
+
+// RemoveMember ลบสมาชิก สมาชิกที่ถูกลบจะเข้าถึงโน้ตผ่านกลุ่มไม่ได้อีก
+func (s *ContactGroupService) RemoveMember(groupID uint, ownerID uint, memberID uint) error {
+	if _, err := s.getOwnedGroup(groupID, ownerID); err != nil {
+		return err
+	}
+	return s.groupRepo.RemoveMember(groupID, memberID)
+}
+
+// getOwnedGroup ดึงกลุ่มที่เป็นของผู้ใช้ กลุ่มของคนอื่นถือว่าไม่พบ
+func (s *ContactGroupService) getOwnedGroup(groupID uint, ownerID uint) (*entities.ContactGroup, error) {
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group.OwnerID != ownerID {
+		return nil, fmt.Errorf("group not found")
+	}
+	return group, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"sync"
+)
+
+const (
+	maxReplayEvents = 500 // จำนวนเหตุการณ์สูงสุดที่เล่นย้อนหลังเมื่อเชื่อมต่อใหม่ ถ้าเกินจะส่ง reset แทน
+	eventBufferSize = 64
+)
+
+type EventUseCase interface {
+	StreamEvents(userID uint, lastEventID uint) (<-chan entities.NoteEvent, func(), error)
+}
+
+type EventService struct {
+	activityRepo repository.ActivityRepository
+	noteRepo     repository.NoteRepository
+	broker       *ActivityBroker
+}
+
+func NewEventService(activityRepo repository.ActivityRepository, noteRepo repository.NoteRepository, broker *ActivityBroker) *EventService {
+	return &EventService{
+		activityRepo: activityRepo,
+		noteRepo:     noteRepo,
+		broker:       broker,
+	}
+}
+
+// StreamEvents ส่งเหตุการณ์ของโน้ตที่ผู้ใช้เข้าถึงได้ ถ้าระบุ lastEventID จะเล่นเหตุการณ์ที่พลาดไปก่อน
+// ถ้าเหตุการณ์ที่พลาดมีมากเกินเล่นย้อนหลัง หรือรับเหตุการณ์สดไม่ทัน จะส่งเหตุการณ์ reset แล้วปิดการเชื่อมต่อ
+// ไคลเอนต์ต้อง sync ใหม่ทั้งหมด ต้องเรียก cancel เมื่อปิดการเชื่อมต่อ
+func (s *EventService) StreamEvents(userID uint, lastEventID uint) (<-chan entities.NoteEvent, func(), error) {
+	// สมัครรับก่อนเล่นย้อนหลัง เพื่อไม่ให้พลาดเหตุการณ์ที่เกิดระหว่างนั้น
+	live, reset, unsubscribe := s.broker.Subscribe(userID)
+
+	var replay []entities.Activity
+	truncated := false
+	if lastEventID > 0 {
+		noteIDs, err := s.noteRepo.GetAccessibleNoteIDs(userID)
+		if err != nil {
+			unsubscribe()
+			return nil, nil, fmt.Errorf("failed to fetch notes: %v", err)
+		}
+		// อ่านเกินหนึ่งรายการเพื่อรู้ว่ายังมีเหตุการณ์ที่เล่นย้อนหลังไม่หมด
+		replay, err = s.activityRepo.GetActivitiesAfter(noteIDs, userID, lastEventID, maxReplayEvents+1)
+		if err != nil {
+			unsubscribe()
+			return nil, nil, err
+		}
+		truncated = len(replay) > maxReplayEvents
+	}
+
+	events := make(chan entities.NoteEvent, eventBufferSize)
+	done := make(chan struct{})
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			close(done)
+			unsubscribe()
+		})
+	}
+
+	go func() {
+		defer close(events)
+
+		send := func(event entities.NoteEvent) bool {
+			select {
+			case events <- event:
+				return true
+			case <-done:
+				return false
+			}
+		}
+
+		lastSent := lastEventID
+		// sendReset ให้ไคลเอนต์ sync ใหม่ รหัสเหตุการณ์คือกิจกรรมล่าสุด เชื่อมต่อใหม่แล้วจึงรับต่อจากจุดนั้น
+		sendReset := func() {
+			eventID := lastSent
+			if latest, err := s.activityRepo.GetLatestActivityID(); err == nil && latest > eventID {
+				eventID = latest
+			}
+			send(entities.NoteEvent{EventID: eventID, Type: "reset"})
+		}
+
+		if truncated {
+			sendReset()
+			return
+		}
+		for _, activity := range replay {
+			if !send(toNoteEvent(activity)) {
+				return
+			}
+			lastSent = activity.ActivityID
+		}
+
+		for {
+			select {
+			case activity, ok := <-live:
+				if !ok {
+					return
+				}
+				// ข้ามเหตุการณ์ที่ส่งไปแล้วตอนเล่นย้อนหลัง
+				if activity.ActivityID <= lastSent {
+					continue
+				}
+				if !send(toNoteEvent(activity)) {
+					return
+				}
+				lastSent = activity.ActivityID
+			case <-reset:
+				sendReset()
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return events, cancel, nil
+}
+
+func toNoteEvent(activity entities.Activity) entities.NoteEvent {
+	return entities.NoteEvent{
+		EventID:    activity.ActivityID,
+		Type:       noteEventType(activity.Action),
+		NoteID:     activity.NoteID,
+		Action:     activity.Action,
+		EntityType: activity.EntityType,
+		EntityID:   activity.EntityID,
+		ActorID:    activity.ActorID,
+		CreatedAt:  activity.CreatedAt,
+	}
+}
+
+// noteEventType จัดกลุ่ม action ของกิจกรรมเป็นชนิดเหตุการณ์ที่ไคลเอนต์ใช้
+func noteEventType(action string) string {
+	switch action {
+	case "note.create":
+		return "created"
+	case "note.delete":
+		return "deleted"
+	case "note.restore":
+		return "restored"
+	case "note.complete":
+		return "completed"
+	case "share.add", "share.accepted", "group_share.add":
+		return "shared"
+	case "share.remove", "share.leave", "group_share.remove":
+		return "unshared"
+	case "tag.add":
+		return "tag_added"
+	case "tag.remove", "tag.delete": // ลบแท็กคือถอดแท็กออกจากโน้ตที่ติดอยู่
+		return "tag_removed"
+	}
+	return "updated"
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// mentionPattern จับ @email หรือ @username ที่ขึ้นต้นบรรทัดหรือตามหลังอักขระที่ไม่ใช่ตัวอักษร
+// \w ของ RE2 รับเฉพาะ ASCII ชื่อผู้ใช้จึงใช้ \p{L} \p{M} \p{N} เพื่อให้ชื่อภาษาไทย เช่น @สมศักดิ์ ใช้ได้
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{M}\p{N}_@.])@([\w.+-]+@[\w-]+(?:\.[\w-]+)+|[\p{L}\p{M}\p{N}_.-]+)`)
+
+type MentionUseCase interface {
+	GetMyMentions(userID uint) ([]entities.MentionEntry, error)
+}
+
+type MentionService struct {
+	mentionRepo   repository.MentionRepository
+	noteRepo      repository.NoteRepository
+	userRepo      repository.UserRepository
+	notifications *NotificationService
+}
+
+func NewMentionService(mentionRepo repository.MentionRepository, noteRepo repository.NoteRepository, userRepo repository.UserRepository, notifications *NotificationService) *MentionService {
+	return &MentionService{
+		mentionRepo:   mentionRepo,
+		noteRepo:      noteRepo,
+		userRepo:      userRepo,
+		notifications: notifications,
+	}
+}
+
+// GetMyMentions การกล่าวถึงผู้ใช้ในโน้ตที่ยังเข้าถึงได้อยู่
+func (s *MentionService) GetMyMentions(userID uint) ([]entities.MentionEntry, error) {
+	mentions, err := s.mentionRepo.GetMentionsForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[uint]bool)
+	result := []entities.MentionEntry{}
+	for _, mention := range mentions {
+		ok, checked := allowed[mention.NoteID]
+		if !checked {
+			ok, _ = s.noteRepo.IsUserAllowedToAccessNote(mention.NoteID, userID)
+			allowed[mention.NoteID] = ok
+		}
+		if ok {
+			result = append(result, mention)
+		}
+	}
+	return result, nil
+}
+
+// ProcessMentions อ่าน @mention จากข้อความ (commentID = 0 คือเนื้อหาโน้ต) เก็บเฉพาะผู้ที่เข้าถึงโน้ตได้
+// และแจ้งเตือนเฉพาะคนที่เพิ่งถูกกล่าวถึง ข้อผิดพลาดจะแค่ log ไว้ ไม่ทำให้การแก้ไขล้มเหลว
+func (s *MentionService) ProcessMentions(noteID uint, commentID uint, authorID uint, text string) {
+	mentioned := s.resolveMentions(noteID, authorID, text)
+
+	existing, err := s.mentionRepo.GetMentionsBySource(noteID, commentID)
+	if err != nil {
+		log.Printf("Failed to load mentions of note %d: %v", noteID, err)
+		return
+	}
+
+	// ลบคนที่ไม่ได้ถูกกล่าวถึงแล้ว
+	existingUsers := make(map[uint]bool)
+	var removed []uint
+	for _, mention := range existing {
+		existingUsers[mention.UserID] = true
+		if _, ok := mentioned[mention.UserID]; !ok {
+			removed = append(removed, mention.UserID)
+		}
+	}
+	if err := s.mentionRepo.DeleteMentions(noteID, commentID, removed); err != nil {
+		log.Printf("Failed to remove mentions of note %d: %v", noteID, err)
+	}
+
+	var newUsers []*entities.User
+	now := time.Now().Format("2006-01-02 15:04:05")
+	for userID, user := range mentioned {
+		if existingUsers[userID] {
+			continue
+		}
+		mention := &entities.Mention{
+			NoteID:    noteID,
+			CommentID: commentID,
+			UserID:    userID,
+			AuthorID:  authorID,
+			CreatedAt: now,
+		}
+		if err := s.mentionRepo.CreateMention(mention); err != nil {
+			log.Printf("Failed to save mention of user %d: %v", userID, err)
+			continue
+		}
+		newUsers = append(newUsers, user)
+	}
+
+	if len(newUsers) > 0 {
+		s.notifyMentioned(noteID, commentID, authorID, newUsers)
+	}
+}
+
+// resolveMentions แปลงชื่อใน @mention เป็นผู้ใช้ที่เข้าถึงโน้ตได้ ไม่รวมผู้เขียนเอง
+func (s *MentionService) resolveMentions(noteID uint, authorID uint, text string) map[uint]*entities.User {
+	users := make(map[uint]*entities.User)
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.TrimRight(match[1], ".-")
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+
+		var candidates []entities.User
+		if strings.Contains(name, "@") {
+			if user, err := s.userRepo.GetUserByEmail(name); err == nil {
+				candidates = append(candidates, *user)
+			}
+		} else if found, err := s.userRepo.GetUsersByUsername(name); err == nil {
+			candidates = found
+		}
+
+		// ชื่อผู้ใช้ซ้ำกันได้ จึงนับเฉพาะกรณีที่มีผู้เข้าถึงโน้ตได้เพียงคนเดียว
+		var matched []entities.User
+		for _, candidate := range candidates {
+			if candidate.UserID == authorID {
+				continue
+			}
+			if allowed, err := s.noteRepo.IsUserAllowedToAccessNote(noteID, candidate.UserID); err == nil && allowed {
+				matched = append(matched, candidate)
+			}
+		}
+		if len(matched) == 1 {
+			user := matched[0]
+			users[user.UserID] = &user
+		}
+	}
+	return users
+}
+
+func (s *MentionService) notifyMentioned(noteID uint, commentID uint, authorID uint, users []*entities.User) {
+	note, err := s.noteRepo.GetNoteById(noteID)
+	if err != nil {
+		log.Printf("Failed to load note %d for mention email: %v", noteID, err)
+		return
+	}
+	authorEmail, _ := s.userRepo.GetUserEmailByID(authorID)
+
+	place := "the content"
+	if commentID != 0 {
+		place = "a comment"
+	}
+
+	for _, user := range users {
+		s.notifications.Notify(user.UserID, "mention", "You were mentioned in a note", fmt.Sprintf("%s mentioned you in %s of \"%s\"", authorEmail, place, note.Title), noteID, authorID)
+
+		// อีเมลใช้การตั้งค่าเดียวกับอีเมลเรื่องการแชร์
+		if user.ShareEmailsOptOut {
+			continue
+		}
+		sendShareEmail(user.Email, mentionEmail, shareEmailData{
+			NoteTitle:  note.Title,
+			ActorEmail: authorEmail,
+			Action:     place,
+			Link:       "http://localhost:3000/mentions",
+		})
+	}
+}
+
+// noteMentionText รวมเนื้อหาโน้ตและรายการ ToDo เพื่อหา @mention
+func noteMentionText(note *entities.Note) string {
+	parts := []string{note.Content}
+	for _, todo := range note.TodoItems {
+		parts = append(parts, todo.Content)
+	}
+	return strings.Join(parts, "\n")
+}
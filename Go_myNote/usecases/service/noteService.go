@@ -2,6 +2,7 @@ package service
 
 import (
 	"fmt"
+	"log"
 	"miw/entities"
 	"miw/usecases/repository"
 	"time"
@@ -14,7 +15,7 @@ type NoteUseCase interface {
 	GetAllNote(userid uint) ([]entities.Note, error)
 	UpdateColor(noteID uint, userID uint, color string) error
 	UpdatePriority(noteID uint, userID uint, priority int) error
-	UpdateTitleAndContent(noteID uint, userID uint, title string, content string, todoItems []entities.ToDo) error
+	UpdateTitleAndContent(noteID uint, userID uint, title string, content string, todoInputs []entities.TodoItemInput) error
 	UpdateStatus(noteID uint, userID uint, isTodo *bool, isAllDone *bool) error
 	UpdateTodoStatus(noteID uint, todoID uint, userID uint, isDone bool) error
 	DeleteNoteById(noteID uint, userID uint) error
@@ -22,35 +23,83 @@ type NoteUseCase interface {
 	AddTagToNote(noteID uint, tagID uint, userID uint) error
 	RemoveTagFromNote(noteID uint, tagID uint, userID uint) error
 	GetDeletedNotes(userID uint) ([]entities.Note, error)
+	MoveNoteToWorkspace(noteID uint, userID uint, workspaceID *uint) error
+	ArchiveNote(noteID uint, userID uint) error
+	UnarchiveNote(noteID uint, userID uint) error
+	GetArchivedNotes(userID uint) ([]entities.Note, error)
+	BatchUpdate(userID uint, operations []entities.NoteBatchOperation) ([]entities.NoteBatchResult, error)
+	DuplicateNote(noteID uint, userID uint) (*entities.Note, error)
+	AddTodoItem(noteID uint, userID uint, todo *entities.ToDo) error
+	UpdateTodoItem(noteID uint, todoID uint, userID uint, update entities.TodoItemUpdate) (*entities.ToDo, error)
+	DeleteTodoItem(noteID uint, todoID uint, userID uint) error
+	ReorderTodoItems(noteID uint, userID uint, todoIDs []uint) error
 }
 
 type NoteService struct {
 	noteRepo         repository.NoteRepository
 	shareNoteService ShareNoteUseCase // เพิ่มฟิลด์นี้
+	workspaceRepo    repository.WorkspaceRepository
+	activity         *ActivityRecorder
+	mentions         *MentionService
+	notifications    *NotificationService
 }
 
-func NewNoteService(noteRepo repository.NoteRepository, shareNoteService ShareNoteUseCase) *NoteService {
+func NewNoteService(noteRepo repository.NoteRepository, shareNoteService ShareNoteUseCase, workspaceRepo repository.WorkspaceRepository, activity *ActivityRecorder, mentions *MentionService, notifications *NotificationService) *NoteService {
 	return &NoteService{
 		noteRepo:         noteRepo,
 		shareNoteService: shareNoteService,
+		workspaceRepo:    workspaceRepo,
+		activity:         activity,
+		mentions:         mentions,
+		notifications:    notifications,
 	}
 }
 
 func (s *NoteService) CreateNote(note *entities.Note) error {
+	// สร้างโน้ตในเวิร์กสเปซได้เฉพาะสมาชิกที่แก้ไขได้
+	if note.WorkspaceID != nil {
+		if err := s.checkWorkspaceEditor(*note.WorkspaceID, note.UserID); err != nil {
+			return err
+		}
+	}
+
+	for i := range note.TodoItems {
+		if err := s.validateTodoItem(note, &note.TodoItems[i], nil); err != nil {
+			return err
+		}
+	}
+
 	timeCreate := time.Now().Format("2006-01-02 15:04:05")
 	note.CreatedAt = timeCreate
 
 	// คำนวณ IsAllDone จาก TodoItems
-	note.IsAllDone = false
-	// for _, todo := range note.TodoItems {
-	// 	if !todo.IsDone {
-	// 		note.IsAllDone = false
-	// 		break
-	// 	}
-	// }
+	stampTodoCompletion(note.TodoItems, nil, timeCreate)
+	note.IsAllDone = len(note.TodoItems) > 0
+	for _, todo := range note.TodoItems {
+		if !todo.IsDone {
+			note.IsAllDone = false
+			break
+		}
+	}
+	note.CompletedAt = ""
+	if note.IsAllDone {
+		note.CompletedAt = timeCreate
+	}
 	fmt.Println("Note: ", note)
 
-	return s.noteRepo.CreateNote(note)
+	if err := s.noteRepo.CreateNote(note); err != nil {
+		return err
+	}
+
+	s.activity.Record(note.NoteID, note.UserID, "note.create", "note", note.NoteID, nil, map[string]interface{}{
+		"title":        note.Title,
+		"workspace_id": note.WorkspaceID,
+	})
+	s.mentions.ProcessMentions(note.NoteID, 0, note.UserID, noteMentionText(note))
+	for _, todo := range note.TodoItems {
+		s.notifyAssignee(note, &todo, nil, note.UserID)
+	}
+	return nil
 }
 
 func (s *NoteService) GetAllNote(userid uint) ([]entities.Note, error) {
@@ -82,6 +131,7 @@ func (s *NoteService) UpdateColor(noteID uint, userID uint, color string) error
 		return fmt.Errorf("failed to update note color: %v", err)
 	}
 
+	s.activity.Record(noteID, userID, "note.color", "note", noteID, note.Color, color)
 	return nil
 }
 
@@ -102,10 +152,15 @@ func (s *NoteService) UpdatePriority(noteID uint, userID uint, priority int) err
 	}
 
 	// ดำเนินการอัปเดต Priority
-	return s.noteRepo.UpdateNotePriority(noteID, note.UserID, priority)
+	if err := s.noteRepo.UpdateNotePriority(noteID, note.UserID, priority); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, userID, "note.priority", "note", noteID, note.Priority, priority)
+	return nil
 }
 
-func (s *NoteService) UpdateTitleAndContent(noteID uint, userID uint, title string, content string, todoItems []entities.ToDo) error {
+func (s *NoteService) UpdateTitleAndContent(noteID uint, userID uint, title string, content string, todoInputs []entities.TodoItemInput) error {
 	// ตรวจสอบว่าโน้ตนั้นมีอยู่จริง
 	note, err := s.noteRepo.GetNoteById(noteID)
 	if err != nil {
@@ -125,10 +180,14 @@ func (s *NoteService) UpdateTitleAndContent(noteID uint, userID uint, title stri
 	}
 
 	// Validation: ห้ามส่ง content และ todo_items พร้อมกัน
-	if len(todoItems) > 0 && content != "" {
+	if len(todoInputs) > 0 && content != "" {
 		return fmt.Errorf("note cannot have both content and todo_items")
 	}
 
+	// เก็บค่าก่อนแก้ไขไว้สำหรับบันทึกกิจกรรม
+	before := noteContentSnapshot(note)
+	hadTodos := len(note.TodoItems) > 0
+
 	// อัปเดต Title หากมีการส่งค่า
 	if title != "" {
 		note.Title = title
@@ -141,7 +200,18 @@ func (s *NoteService) UpdateTitleAndContent(noteID uint, userID uint, title stri
 	}
 
 	// ถ้ามี TodoItems ให้ลบ Content และอัปเดต TodoItems
-	if len(todoItems) > 0 {
+	previousAssignees := make(map[uint]*uint)
+	if len(todoInputs) > 0 {
+		previous := make(map[uint]entities.ToDo)
+		for _, todo := range note.TodoItems {
+			previousAssignees[todo.ID] = todo.AssigneeID
+			previous[todo.ID] = todo
+		}
+		todoItems := mergeTodoInputs(todoInputs, previous)
+		if err := s.validateTodoList(note, todoItems); err != nil {
+			return err
+		}
+		stampTodoCompletion(todoItems, previous, time.Now().Format("2006-01-02 15:04:05"))
 		note.TodoItems = todoItems
 		note.Content = "" // ลบ Content
 	}
@@ -150,7 +220,20 @@ func (s *NoteService) UpdateTitleAndContent(noteID uint, userID uint, title stri
 	note.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
 
 	// บันทึกการอัปเดต
-	return s.noteRepo.UpdateNoteTitleAndContent(note)
+	if err := s.noteRepo.UpdateNoteTitleAndContent(note); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, userID, "note.content", "note", noteID, before, noteContentSnapshot(note))
+	// โน้ตธรรมดาที่ไม่เคยมีรายการ ToDo ใช้สถานะที่กำหนดเองผ่าน /note/status
+	if hadTodos || len(note.TodoItems) > 0 {
+		s.syncCompletion(noteID, userID)
+	}
+	s.mentions.ProcessMentions(noteID, 0, userID, noteMentionText(note))
+	for _, todo := range note.TodoItems {
+		s.notifyAssignee(note, &todo, previousAssignees[todo.ID], userID)
+	}
+	return nil
 }
 
 func (s *NoteService) UpdateStatus(noteID uint, userID uint, isTodo *bool, isAllDone *bool) error {
@@ -172,18 +255,55 @@ func (s *NoteService) UpdateStatus(noteID uint, userID uint, isTodo *bool, isAll
 		return fmt.Errorf("you are not authorized to update this note") // ไม่มีสิทธิ์
 	}
 
+	// โน้ตที่มีรายการ ToDo: is_all_done หมายถึงทำเครื่องหมาย/ยกเลิกทุกรายการ แล้วคำนวณสถานะจากรายการ
+	derived := isAllDone != nil && len(note.TodoItems) > 0
+	if derived {
+		if err := s.noteRepo.SetAllTodoItemsDone(noteID, *isAllDone); err != nil {
+			return err
+		}
+		isAllDone = nil
+	}
+
 	// อัปเดตสถานะใน Repository Layer
-	return s.noteRepo.UpdateNoteStatus(noteID, note.UserID, isTodo, isAllDone)
+	if isTodo != nil || isAllDone != nil {
+		if err := s.noteRepo.UpdateNoteStatus(noteID, note.UserID, isTodo, isAllDone); err != nil {
+			return err
+		}
+	}
+
+	after := map[string]bool{"is_todo": note.IsTodo, "is_all_done": note.IsAllDone}
+	if isTodo != nil {
+		after["is_todo"] = *isTodo
+	}
+	if isAllDone != nil {
+		after["is_all_done"] = *isAllDone
+	}
+	s.activity.Record(noteID, userID, "note.status", "note", noteID, map[string]bool{"is_todo": note.IsTodo, "is_all_done": note.IsAllDone}, after)
+	if derived {
+		s.syncCompletion(noteID, userID)
+	} else if isAllDone != nil && *isAllDone && !note.IsAllDone {
+		s.onNoteCompleted(noteID, userID)
+	}
+	return nil
 }
 
 func (s *NoteService) UpdateTodoStatus(noteID uint, todoID uint, userID uint, isDone bool) error {
-	// ตรวจสอบว่า Note เป็นของ User หรือไม่
-	isOwner, err := s.noteRepo.IsUserAllowedToAccessNote(noteID, userID)
+	// ต้องมีสิทธิ์แก้ไขโน้ต ผู้ที่ดูได้อย่างเดียวเปลี่ยนสถานะไม่ได้ และโน้ตในถังขยะแก้ไขไม่ได้
+	note, err := s.editableNote(noteID, userID)
 	if err != nil {
-		return fmt.Errorf("failed to check note ownership: %v", err)
+		return err
 	}
-	if !isOwner {
-		return fmt.Errorf("you are not authorized to update this todo")
+
+	// เก็บสถานะเดิมไว้สำหรับบันทึกกิจกรรม
+	var before interface{}
+	for _, todo := range note.TodoItems {
+		if todo.ID == todoID {
+			before = todo.IsDone
+			break
+		}
+	}
+	if before == nil {
+		return fmt.Errorf("todo item not found")
 	}
 
 	// อัปเดตสถานะของ Todo
@@ -191,6 +311,8 @@ func (s *NoteService) UpdateTodoStatus(noteID uint, todoID uint, userID uint, is
 		return fmt.Errorf("failed to update todo status: %v", err)
 	}
 
+	s.activity.Record(noteID, userID, "todo.status", "todo", todoID, before, isDone)
+	s.syncCompletion(noteID, userID)
 	return nil
 }
 
@@ -205,6 +327,8 @@ func (s *NoteService) DeleteNoteById(noteID uint, userID uint) error {
 	if err := s.noteRepo.DeleteNoteById(noteID); err != nil {
 		return fmt.Errorf("failed to delete note: %v", err)
 	}
+
+	s.activity.Record(noteID, userID, "note.delete", "note", noteID, nil, nil)
 	return nil
 }
 
@@ -219,18 +343,681 @@ func (s *NoteService) RestoreNoteById(noteID uint, userID uint) error {
 	if err := s.noteRepo.RestoreNoteById(noteID); err != nil {
 		return fmt.Errorf("failed to restore note: %v", err)
 	}
+
+	s.activity.Record(noteID, userID, "note.restore", "note", noteID, nil, nil)
 	return nil
 }
 
 func (s *NoteService) AddTagToNote(noteID uint, tagID uint, userID uint) error {
-	return s.noteRepo.AddTagToNote(noteID, tagID, userID)
+	if err := s.noteRepo.AddTagToNote(noteID, tagID, userID); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, userID, "tag.add", "tag", tagID, nil, tagID)
+	return nil
 }
 
 func (s *NoteService) RemoveTagFromNote(noteID uint, tagID uint, userID uint) error {
-	return s.noteRepo.RemoveTagFromNote(noteID, tagID, userID)
+	if err := s.noteRepo.RemoveTagFromNote(noteID, tagID, userID); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, userID, "tag.remove", "tag", tagID, tagID, nil)
+	return nil
 }
 
 func (s *NoteService) GetDeletedNotes(userID uint) ([]entities.Note, error) {
 	return s.noteRepo.GetDeletedNotesByUserID(userID)
 }
 
+// MoveNoteToWorkspace ย้ายโน้ตเข้าเวิร์กสเปซ หรือกลับเป็นโน้ตส่วนตัวเมื่อ workspaceID เป็น nil
+func (s *NoteService) MoveNoteToWorkspace(noteID uint, userID uint, workspaceID *uint) error {
+	// เฉพาะเจ้าของโน้ตเท่านั้นที่ย้ายโน้ตได้
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+	if err != nil {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+
+	if workspaceID != nil {
+		if err := s.checkWorkspaceEditor(*workspaceID, userID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.noteRepo.UpdateNoteWorkspace(noteID, workspaceID); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, userID, "note.workspace", "note", noteID, note.WorkspaceID, workspaceID)
+	return nil
+}
+
+// noteContentSnapshot ค่าเนื้อหาของโน้ตสำหรับบันทึกกิจกรรม
+func noteContentSnapshot(note *entities.Note) map[string]interface{} {
+	todos := make([]map[string]interface{}, 0, len(note.TodoItems))
+	for _, todo := range note.TodoItems {
+		todos = append(todos, map[string]interface{}{
+			"content": todo.Content,
+			"is_done": todo.IsDone,
+		})
+	}
+	return map[string]interface{}{
+		"title":      note.Title,
+		"content":    note.Content,
+		"todo_items": todos,
+	}
+}
+
+// ArchiveNote เก็บโน้ตเข้าคลัง โน้ตจะไม่แสดงในรายการหลักแต่ยังไม่ถูกลบ ทำได้เฉพาะเจ้าของ
+func (s *NoteService) ArchiveNote(noteID uint, userID uint) error {
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+	if err != nil {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	if note.ArchivedAt != "" {
+		return fmt.Errorf("note is already archived")
+	}
+
+	archivedAt := time.Now().Format("2006-01-02 15:04:05")
+	if err := s.noteRepo.UpdateNoteArchivedAt(noteID, archivedAt); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, userID, "note.archive", "note", noteID, nil, archivedAt)
+	return nil
+}
+
+func (s *NoteService) UnarchiveNote(noteID uint, userID uint) error {
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+	if err != nil {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	if note.ArchivedAt == "" {
+		return fmt.Errorf("note is not archived")
+	}
+
+	if err := s.noteRepo.UpdateNoteArchivedAt(noteID, ""); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, userID, "note.unarchive", "note", noteID, note.ArchivedAt, nil)
+	return nil
+}
+
+func (s *NoteService) GetArchivedNotes(userID uint) ([]entities.Note, error) {
+	return s.noteRepo.GetArchivedNotesByUserID(userID)
+}
+
+// BatchUpdate ทำหลายคำสั่งใน transaction เดียว แต่ละคำสั่งตรวจสิทธิ์เหมือนเรียก endpoint ทีละตัว
+// ถ้ามีคำสั่งใดล้มเหลว จะ rollback ทั้งหมดและคืนผลรายรายการพร้อม error "batch operation failed"
+func (s *NoteService) BatchUpdate(userID uint, operations []entities.NoteBatchOperation) ([]entities.NoteBatchResult, error) {
+	results := make([]entities.NoteBatchResult, 0, len(operations))
+	activity := s.activity.deferred()
+	failed := false
+
+	err := s.noteRepo.Transaction(func(txRepo repository.NoteRepository) error {
+		txService := *s
+		txService.noteRepo = txRepo
+		txService.activity = activity
+
+		for i, operation := range operations {
+			result := entities.NoteBatchResult{Index: i, Op: operation.Op, NoteID: operation.NoteID}
+			if failed {
+				result.Status = "skipped"
+			} else if err := txService.applyBatchOperation(userID, operation); err != nil {
+				result.Status = "failed"
+				result.Error = err.Error()
+				failed = true
+			} else {
+				result.Status = "applied"
+			}
+			results = append(results, result)
+		}
+
+		if failed {
+			return fmt.Errorf("batch operation failed")
+		}
+		return nil
+	})
+
+	if failed {
+		for i := range results {
+			if results[i].Status == "applied" {
+				results[i].Status = "rolled_back"
+			}
+		}
+		return results, fmt.Errorf("batch operation failed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	activity.flush()
+	return results, nil
+}
+
+func (s *NoteService) applyBatchOperation(userID uint, operation entities.NoteBatchOperation) error {
+	switch operation.Op {
+	case "set_color":
+		if operation.Color == nil {
+			return fmt.Errorf("color is required")
+		}
+		return s.UpdateColor(operation.NoteID, userID, *operation.Color)
+	case "set_priority":
+		if operation.Priority == nil {
+			return fmt.Errorf("priority is required")
+		}
+		return s.UpdatePriority(operation.NoteID, userID, *operation.Priority)
+	case "add_tag":
+		return s.AddTagToNote(operation.NoteID, operation.TagID, userID)
+	case "remove_tag":
+		return s.RemoveTagFromNote(operation.NoteID, operation.TagID, userID)
+	case "delete":
+		return s.DeleteNoteById(operation.NoteID, userID)
+	case "restore":
+		return s.RestoreNoteById(operation.NoteID, userID)
+	case "archive":
+		return s.ArchiveNote(operation.NoteID, userID)
+	case "unarchive":
+		return s.UnarchiveNote(operation.NoteID, userID)
+	}
+	return fmt.Errorf("unsupported operation: %s", operation.Op)
+}
+
+// DuplicateNote คัดลอกโน้ตที่ผู้ใช้เข้าถึงได้เป็นโน้ตใหม่ของผู้ใช้ รายการ ToDo ถูกรีเซ็ตเป็นยังไม่เสร็จ
+// แท็กที่ผู้ใช้ไม่มีสิทธิ์ใช้ (เช่น แท็กส่วนตัวของเจ้าของโน้ตเดิม) จะไม่ถูกคัดลอก
+func (s *NoteService) DuplicateNote(noteID uint, userID uint) (*entities.Note, error) {
+	allowed, err := s.noteRepo.IsUserAllowedToAccessNote(noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check access permission: %v", err)
+	}
+	if !allowed {
+		return nil, fmt.Errorf("you are not authorized to view this note")
+	}
+
+	source, err := s.noteRepo.GetNoteById(noteID)
+	if err != nil || source.DeletedAt != "" {
+		return nil, fmt.Errorf("note not found")
+	}
+
+	duplicate := &entities.Note{
+		UserID:   userID,
+		Title:    source.Title,
+		Content:  source.Content,
+		Color:    source.Color,
+		Priority: source.Priority,
+		IsTodo:   source.IsTodo,
+	}
+	// เรียงตามลำดับเดิม และส่ง ID กับ ParentID ต้นฉบับไปให้ repository แปลงเป็นรายการย่อยของโน้ตใหม่
+	for _, todo := range source.TodoItems {
+		duplicate.TodoItems = append(duplicate.TodoItems, entities.ToDo{ID: todo.ID, Content: todo.Content, DueDate: todo.DueDate, Priority: todo.Priority, ParentID: todo.ParentID})
+	}
+	// อยู่ในเวิร์กสเปซเดิมได้ถ้าผู้ใช้แก้ไขในเวิร์กสเปซนั้นได้ ไม่เช่นนั้นเป็นโน้ตส่วนตัว
+	if source.WorkspaceID != nil && s.checkWorkspaceEditor(*source.WorkspaceID, userID) == nil {
+		duplicate.WorkspaceID = source.WorkspaceID
+	}
+
+	if err := s.CreateNote(duplicate); err != nil {
+		return nil, err
+	}
+
+	// โหลดรายการใหม่เพื่อให้ได้ ID และรายการแม่ที่บันทึกจริง
+	if created, err := s.noteRepo.GetNoteById(duplicate.NoteID); err == nil {
+		duplicate.TodoItems = created.TodoItems
+	}
+
+	for _, tag := range source.Tags {
+		if err := s.AddTagToNote(duplicate.NoteID, tag.TagID, userID); err != nil {
+			continue
+		}
+		duplicate.Tags = append(duplicate.Tags, tag)
+	}
+
+	s.activity.Record(duplicate.NoteID, userID, "note.duplicate", "note", duplicate.NoteID, noteID, duplicate.NoteID)
+	return duplicate, nil
+}
+
+// checkWorkspaceEditor ตรวจสอบว่าผู้ใช้เป็นสมาชิกเวิร์กสเปซที่แก้ไขโน้ตได้
+func (s *NoteService) checkWorkspaceEditor(workspaceID uint, userID uint) error {
+	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+	if err != nil || !canEditWorkspace(member.Role) {
+		return fmt.Errorf("you are not allowed to add notes to this workspace")
+	}
+	return nil
+}
+
+// validateTodoItem ตรวจรูปแบบวันครบกำหนดและผู้รับผิดชอบของรายการ ToDo
+// ผู้รับผิดชอบเดิม (previous) ไม่ต้องตรวจซ้ำ เพื่อให้แก้ไขรายการได้แม้ผู้นั้นถูกยกเลิกการแชร์ไปแล้ว
+func (s *NoteService) validateTodoItem(note *entities.Note, todo *entities.ToDo, previous *uint) error {
+	if todo.DueDate != "" {
+		if _, err := time.Parse("2006-01-02", todo.DueDate); err != nil {
+			return fmt.Errorf("invalid due date format")
+		}
+	}
+	if todo.AssigneeID != nil && *todo.AssigneeID == 0 {
+		todo.AssigneeID = nil
+	}
+	if todo.AssigneeID != nil && (previous == nil || *previous != *todo.AssigneeID) && !s.canAssign(note, *todo.AssigneeID) {
+		return fmt.Errorf("assignee does not have access to this note")
+	}
+	return nil
+}
+
+// validateTodoList ตรวจรายการ ToDo ทั้งชุดที่จะแทนที่ของเดิม รายการแม่ต้องเป็นรายการเดิมที่ยังอยู่ในชุดนี้
+func (s *NoteService) validateTodoList(note *entities.Note, items []entities.ToDo) error {
+	existing := make(map[uint]bool)
+	assignees := make(map[uint]*uint)
+	for _, todo := range note.TodoItems {
+		existing[todo.ID] = true
+		assignees[todo.ID] = todo.AssigneeID
+	}
+	parents := make(map[uint]uint)
+	for i := range items {
+		if existing[items[i].ID] {
+			parents[items[i].ID] = 0
+		}
+	}
+
+	for i := range items {
+		todo := &items[i]
+		if err := s.validateTodoItem(note, todo, assignees[todo.ID]); err != nil {
+			return err
+		}
+		if todo.ParentID != nil && *todo.ParentID == 0 {
+			todo.ParentID = nil
+		}
+		if todo.ParentID == nil {
+			continue
+		}
+		if _, ok := parents[*todo.ParentID]; !ok {
+			return fmt.Errorf("parent todo item not found")
+		}
+		if existing[todo.ID] {
+			parents[todo.ID] = *todo.ParentID
+		}
+	}
+
+	for id := range parents {
+		if createsTodoCycle(parents, id, parents[id]) {
+			return fmt.Errorf("todo item cannot be nested under itself")
+		}
+	}
+	return nil
+}
+
+// createsTodoCycle ตรวจว่าการให้ todoID อยู่ใต้ parentID ทำให้เกิดวงวนหรือไม่
+func createsTodoCycle(parents map[uint]uint, todoID uint, parentID uint) bool {
+	for steps := 0; parentID != 0 && steps <= len(parents); steps++ {
+		if parentID == todoID {
+			return true
+		}
+		parentID = parents[parentID]
+	}
+	return parentID != 0
+}
+
+// canAssign ผู้รับผิดชอบต้องเข้าถึงโน้ตได้ สำหรับโน้ตที่ยังไม่ได้สร้างใช้เจ้าของหรือสมาชิกเวิร์กสเปซ
+func (s *NoteService) canAssign(note *entities.Note, assigneeID uint) bool {
+	if assigneeID == note.UserID {
+		return true
+	}
+	if note.NoteID != 0 {
+		allowed, err := s.noteRepo.IsUserAllowedToAccessNote(note.NoteID, assigneeID)
+		return err == nil && allowed
+	}
+	if note.WorkspaceID != nil {
+		_, err := s.workspaceRepo.GetMember(*note.WorkspaceID, assigneeID)
+		return err == nil
+	}
+	return false
+}
+
+// notifyAssignee แจ้งผู้รับผิดชอบเมื่อถูกมอบหมายรายการใหม่ ไม่แจ้งถ้ามอบหมายให้ตัวเอง
+func (s *NoteService) notifyAssignee(note *entities.Note, todo *entities.ToDo, previous *uint, actorID uint) {
+	if todo.AssigneeID == nil || *todo.AssigneeID == actorID {
+		return
+	}
+	if previous != nil && *previous == *todo.AssigneeID {
+		return
+	}
+	s.notifications.Notify(*todo.AssigneeID, "todo_assigned", "A todo item was assigned to you", fmt.Sprintf("%s: %s", note.Title, todo.Content), note.NoteID, actorID)
+}
+
+// editableNote โน้ตที่ผู้ใช้แก้ไขได้และยังไม่อยู่ในถังขยะ
+func (s *NoteService) editableNote(noteID uint, userID uint) (*entities.Note, error) {
+	note, err := s.noteRepo.GetNoteById(noteID)
+	if err != nil || note.DeletedAt != "" {
+		return nil, fmt.Errorf("note not found")
+	}
+	isAllowed, err := s.shareNoteService.IsUserAllowedToEdit(noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permissions: %v", err)
+	}
+	if !isAllowed {
+		return nil, fmt.Errorf("you are not authorized to update this note")
+	}
+	return note, nil
+}
+
+// mergeTodoInputs แปลงรายการจาก client เป็น ToDo ฟิลด์ที่ไม่ได้ส่งมาใช้ค่าเดิมจาก previous
+// เพื่อไม่ให้ client ที่ส่งแค่ id, content, is_done ลบวันครบกำหนด ลำดับชั้น และผู้รับผิดชอบทิ้ง
+func mergeTodoInputs(inputs []entities.TodoItemInput, previous map[uint]entities.ToDo) []entities.ToDo {
+	todos := make([]entities.ToDo, 0, len(inputs))
+	for _, input := range inputs {
+		todo := entities.ToDo{ID: input.ID, Content: input.Content, IsDone: input.IsDone}
+		if old, ok := previous[input.ID]; ok && input.ID != 0 {
+			todo.DueDate = old.DueDate
+			todo.Priority = old.Priority
+			todo.ParentID = old.ParentID
+			todo.AssigneeID = old.AssigneeID
+		}
+		if input.DueDate != nil {
+			todo.DueDate = *input.DueDate
+		}
+		if input.Priority != nil {
+			todo.Priority = *input.Priority
+		}
+		if input.ParentID != nil {
+			todo.ParentID = nil
+			if *input.ParentID != 0 {
+				parentID := *input.ParentID
+				todo.ParentID = &parentID
+			}
+		}
+		if input.AssigneeID != nil {
+			todo.AssigneeID = nil
+			if *input.AssigneeID != 0 {
+				assigneeID := *input.AssigneeID
+				todo.AssigneeID = &assigneeID
+			}
+		}
+		todos = append(todos, todo)
+	}
+	return todos
+}
+
+// todoSnapshot ค่าของรายการ ToDo สำหรับบันทึกกิจกรรม
+func todoSnapshot(todo *entities.ToDo) map[string]interface{} {
+	return map[string]interface{}{
+		"content":     todo.Content,
+		"is_done":     todo.IsDone,
+		"position":    todo.Position,
+		"due_date":    todo.DueDate,
+		"priority":    todo.Priority,
+		"parent_id":   todo.ParentID,
+		"assignee_id": todo.AssigneeID,
+	}
+}
+
+// AddTodoItem เพิ่มรายการ ToDo ต่อท้ายรายการของโน้ต
+func (s *NoteService) AddTodoItem(noteID uint, userID uint, todo *entities.ToDo) error {
+	note, err := s.editableNote(noteID, userID)
+	if err != nil {
+		return err
+	}
+	if todo.Content == "" {
+		return fmt.Errorf("todo content is required")
+	}
+	if note.Content != "" {
+		return fmt.Errorf("note cannot have both content and todo_items")
+	}
+	if err := s.validateTodoItem(note, todo, nil); err != nil {
+		return err
+	}
+	if todo.ParentID != nil && *todo.ParentID == 0 {
+		todo.ParentID = nil
+	}
+
+	position := 0
+	parentFound := todo.ParentID == nil
+	for _, item := range note.TodoItems {
+		if item.Position >= position {
+			position = item.Position + 1
+		}
+		if todo.ParentID != nil && item.ID == *todo.ParentID {
+			parentFound = true
+		}
+	}
+	if !parentFound {
+		return fmt.Errorf("parent todo item not found")
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	todo.ID = 0
+	todo.NoteID = noteID
+	todo.Position = position
+	todo.CreatedAt = now
+	todo.UpdatedAt = now
+	todo.CompletedAt = ""
+	if todo.IsDone {
+		todo.CompletedAt = now
+	}
+	if err := s.noteRepo.CreateTodoItem(todo); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, userID, "todo.add", "todo", todo.ID, nil, todoSnapshot(todo))
+	s.syncCompletion(noteID, userID)
+	note.TodoItems = append(note.TodoItems, *todo)
+	s.mentions.ProcessMentions(noteID, 0, userID, noteMentionText(note))
+	s.notifyAssignee(note, todo, nil, userID)
+	return nil
+}
+
+func (s *NoteService) UpdateTodoItem(noteID uint, todoID uint, userID uint, update entities.TodoItemUpdate) (*entities.ToDo, error) {
+	note, err := s.editableNote(noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var todo *entities.ToDo
+	parents := make(map[uint]uint)
+	for i := range note.TodoItems {
+		item := &note.TodoItems[i]
+		if item.ID == todoID {
+			todo = item
+		}
+		parents[item.ID] = 0
+		if item.ParentID != nil {
+			parents[item.ID] = *item.ParentID
+		}
+	}
+	if todo == nil {
+		return nil, fmt.Errorf("todo item not found")
+	}
+
+	before := todoSnapshot(todo)
+	previousAssignee := todo.AssigneeID
+	contentChanged := false
+
+	if update.Content != nil {
+		if *update.Content == "" {
+			return nil, fmt.Errorf("todo content is required")
+		}
+		contentChanged = *update.Content != todo.Content
+		todo.Content = *update.Content
+	}
+	if update.IsDone != nil && *update.IsDone != todo.IsDone {
+		todo.IsDone = *update.IsDone
+		todo.CompletedAt = ""
+		if todo.IsDone {
+			todo.CompletedAt = time.Now().Format("2006-01-02 15:04:05")
+		}
+	}
+	if update.DueDate != nil {
+		todo.DueDate = *update.DueDate
+	}
+	if update.Priority != nil {
+		todo.Priority = *update.Priority
+	}
+	if update.AssigneeID != nil {
+		assigneeID := *update.AssigneeID
+		todo.AssigneeID = &assigneeID
+	}
+	if update.ParentID != nil {
+		if *update.ParentID == 0 {
+			todo.ParentID = nil
+		} else {
+			if _, ok := parents[*update.ParentID]; !ok {
+				return nil, fmt.Errorf("parent todo item not found")
+			}
+			if createsTodoCycle(parents, todoID, *update.ParentID) {
+				return nil, fmt.Errorf("todo item cannot be nested under itself")
+			}
+			parentID := *update.ParentID
+			todo.ParentID = &parentID
+		}
+	}
+	if err := s.validateTodoItem(note, todo, previousAssignee); err != nil {
+		return nil, err
+	}
+
+	todo.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+	if err := s.noteRepo.UpdateTodoItem(todo); err != nil {
+		return nil, err
+	}
+
+	s.activity.Record(noteID, userID, "todo.edit", "todo", todoID, before, todoSnapshot(todo))
+	s.syncCompletion(noteID, userID)
+	if contentChanged {
+		s.mentions.ProcessMentions(noteID, 0, userID, noteMentionText(note))
+	}
+	s.notifyAssignee(note, todo, previousAssignee, userID)
+	return todo, nil
+}
+
+// DeleteTodoItem ลบรายการ ToDo พร้อมรายการย่อยทั้งหมด
+func (s *NoteService) DeleteTodoItem(noteID uint, todoID uint, userID uint) error {
+	note, err := s.editableNote(noteID, userID)
+	if err != nil {
+		return err
+	}
+
+	var target *entities.ToDo
+	children := make(map[uint][]uint)
+	for i := range note.TodoItems {
+		item := &note.TodoItems[i]
+		if item.ID == todoID {
+			target = item
+		}
+		if item.ParentID != nil {
+			children[*item.ParentID] = append(children[*item.ParentID], item.ID)
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("todo item not found")
+	}
+
+	removed := map[uint]bool{todoID: true}
+	todoIDs := []uint{todoID}
+	for i := 0; i < len(todoIDs); i++ {
+		for _, childID := range children[todoIDs[i]] {
+			if !removed[childID] {
+				removed[childID] = true
+				todoIDs = append(todoIDs, childID)
+			}
+		}
+	}
+	if err := s.noteRepo.DeleteTodoItems(noteID, todoIDs); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, userID, "todo.delete", "todo", todoID, todoSnapshot(target), nil)
+	s.syncCompletion(noteID, userID)
+
+	remaining := note.TodoItems[:0]
+	for _, item := range note.TodoItems {
+		if !removed[item.ID] {
+			remaining = append(remaining, item)
+		}
+	}
+	note.TodoItems = remaining
+	s.mentions.ProcessMentions(noteID, 0, userID, noteMentionText(note))
+	return nil
+}
+
+// ReorderTodoItems จัดลำดับรายการ ToDo ใหม่ todoIDs ต้องมีทุกรายการของโน้ตครบ
+func (s *NoteService) ReorderTodoItems(noteID uint, userID uint, todoIDs []uint) error {
+	note, err := s.editableNote(noteID, userID)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[uint]bool)
+	before := make([]uint, 0, len(note.TodoItems))
+	for _, item := range note.TodoItems {
+		current[item.ID] = true
+		before = append(before, item.ID)
+	}
+	seen := make(map[uint]bool)
+	for _, todoID := range todoIDs {
+		if !current[todoID] || seen[todoID] {
+			return fmt.Errorf("todo_ids must list every todo item of the note")
+		}
+		seen[todoID] = true
+	}
+	if len(todoIDs) != len(note.TodoItems) {
+		return fmt.Errorf("todo_ids must list every todo item of the note")
+	}
+
+	if err := s.noteRepo.ReorderTodoItems(noteID, todoIDs); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, userID, "todo.reorder", "note", noteID, before, todoIDs)
+	return nil
+}
+
+// stampTodoCompletion ตั้งเวลาที่เสร็จของรายการ ToDo รายการที่เสร็จอยู่แล้วคงเวลาเดิมไว้
+func stampTodoCompletion(items []entities.ToDo, previous map[uint]entities.ToDo, now string) {
+	for i := range items {
+		todo := &items[i]
+		if !todo.IsDone {
+			todo.CompletedAt = ""
+			continue
+		}
+		if old, ok := previous[todo.ID]; ok && old.IsDone && old.CompletedAt != "" {
+			todo.CompletedAt = old.CompletedAt
+			continue
+		}
+		todo.CompletedAt = now
+	}
+}
+
+// syncCompletion คำนวณ is_all_done ใหม่หลังรายการ ToDo เปลี่ยน และส่งเหตุการณ์เมื่อเสร็จครบ
+func (s *NoteService) syncCompletion(noteID uint, actorID uint) {
+	completed, err := s.noteRepo.SyncNoteCompletion(noteID)
+	if err != nil {
+		log.Printf("Failed to update completion of note %d: %v", noteID, err)
+		return
+	}
+	if completed {
+		s.onNoteCompleted(noteID, actorID)
+	}
+}
+
+// onNoteCompleted บันทึกกิจกรรม note.complete (ส่งต่อไปยัง /events) และแจ้งเจ้าของ ผู้ร่วมแชร์ และผู้รับผิดชอบรายการ
+func (s *NoteService) onNoteCompleted(noteID uint, actorID uint) {
+	note, err := s.noteRepo.GetNoteById(noteID)
+	if err != nil {
+		return
+	}
+	s.activity.Record(noteID, actorID, "note.complete", "note", noteID, nil, note.CompletedAt)
+
+	recipients := map[uint]bool{note.UserID: true}
+	if sharedWith, err := s.noteRepo.GetAcceptedShareUserIDs(noteID); err == nil {
+		for _, userID := range sharedWith {
+			recipients[userID] = true
+		}
+	}
+	for _, todo := range note.TodoItems {
+		if todo.AssigneeID != nil {
+			recipients[*todo.AssigneeID] = true
+		}
+	}
+	delete(recipients, actorID)
+
+	for userID := range recipients {
+		s.notifications.Notify(userID, "completed", "Checklist completed", fmt.Sprintf("All items of \"%s\" are done", note.Title), noteID, actorID)
+	}
+}
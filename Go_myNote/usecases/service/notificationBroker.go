@@ -0,0 +1,58 @@
+package service
+
+import (
+	"miw/entities"
+	"sync"
+)
+
+// notificationBufferSize จำนวนการแจ้งเตือนที่รอส่งได้ต่อการเชื่อมต่อ ถ้าเต็มจะข้ามไป (ดึงย้อนหลังได้จาก inbox)
+const notificationBufferSize = 16
+
+// NotificationBroker กระจายการแจ้งเตือนไปยังการเชื่อมต่อ SSE ที่เปิดอยู่ของผู้ใช้แต่ละคน (เก็บในหน่วยความจำ)
+type NotificationBroker struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan entities.Notification]struct{}
+}
+
+func NewNotificationBroker() *NotificationBroker {
+	return &NotificationBroker{subscribers: make(map[uint]map[chan entities.Notification]struct{})}
+}
+
+// Subscribe เปิดช่องรับการแจ้งเตือนของผู้ใช้ ต้องเรียก cancel เมื่อเลิกใช้
+func (b *NotificationBroker) Subscribe(userID uint) (<-chan entities.Notification, func()) {
+	ch := make(chan entities.Notification, notificationBufferSize)
+
+	b.mu.Lock()
+	if b.subscribers[userID] == nil {
+		b.subscribers[userID] = make(map[chan entities.Notification]struct{})
+	}
+	b.subscribers[userID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[userID], ch)
+			if len(b.subscribers[userID]) == 0 {
+				delete(b.subscribers, userID)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// Publish ส่งการแจ้งเตือนให้ทุกการเชื่อมต่อของผู้รับ โดยไม่รอผู้รับที่ช้า
+func (b *NotificationBroker) Publish(notification entities.Notification) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[notification.UserID] {
+		select {
+		case ch <- notification:
+		default:
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"time"
+)
+
+const (
+	defaultNotificationLimit = 50  // จำนวนการแจ้งเตือนที่ส่งกลับเมื่อไม่ระบุ
+	maxNotificationLimit     = 200 // จำนวนการแจ้งเตือนสูงสุดต่อคำขอ
+)
+
+type NotificationUseCase interface {
+	GetNotifications(userID uint, unreadOnly bool, limit int) ([]entities.Notification, error)
+	GetUnreadCount(userID uint) (int64, error)
+	MarkAsRead(notificationID uint, userID uint) error
+	MarkAllAsRead(userID uint) (int64, error)
+	Subscribe(userID uint) (<-chan entities.Notification, func())
+}
+
+type NotificationService struct {
+	notificationRepo repository.NotificationRepository
+	broker           *NotificationBroker
+}
+
+func NewNotificationService(notificationRepo repository.NotificationRepository, broker *NotificationBroker) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		broker:           broker,
+	}
+}
+
+// Notify บันทึกการแจ้งเตือนและส่งต่อไปยังการเชื่อมต่อที่เปิดอยู่ ถ้าบันทึกไม่สำเร็จจะแค่ log ไว้
+func (s *NotificationService) Notify(userID uint, notificationType string, title string, message string, noteID uint, actorID uint) {
+	notification := &entities.Notification{
+		UserID:    userID,
+		Type:      notificationType,
+		Title:     title,
+		Message:   message,
+		NoteID:    noteID,
+		ActorID:   actorID,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := s.notificationRepo.CreateNotification(notification); err != nil {
+		log.Printf("Failed to create %s notification for user %d: %v", notificationType, userID, err)
+		return
+	}
+	s.broker.Publish(*notification)
+}
+
+func (s *NotificationService) GetNotifications(userID uint, unreadOnly bool, limit int) ([]entities.Notification, error) {
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+	return s.notificationRepo.GetNotificationsByUserID(userID, unreadOnly, limit)
+}
+
+func (s *NotificationService) GetUnreadCount(userID uint) (int64, error) {
+	return s.notificationRepo.CountUnread(userID)
+}
+
+func (s *NotificationService) MarkAsRead(notificationID uint, userID uint) error {
+	return s.notificationRepo.MarkAsRead(notificationID, userID)
+}
+
+func (s *NotificationService) MarkAllAsRead(userID uint) (int64, error) {
+	return s.notificationRepo.MarkAllAsRead(userID)
+}
+
+func (s *NotificationService) Subscribe(userID uint) (<-chan entities.Notification, func()) {
+	return s.broker.Subscribe(userID)
+}
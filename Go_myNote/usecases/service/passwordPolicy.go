@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultPasswordMinLength = 8
+	defaultPasswordHashCost  = 12
+)
+
+// PasswordPolicy กำหนดเงื่อนไขของรหัสผ่านและค่า cost ที่ใช้แฮช
+type PasswordPolicy struct {
+	MinLength int
+	HashCost  int
+	breached  map[string]struct{}
+}
+
+// NewPasswordPolicy สร้าง policy และโหลดรายการรหัสผ่านที่เคยรั่วไหลจากไฟล์ (บรรทัดละหนึ่งรหัส)
+// ถ้า breachedListPath ว่างจะไม่ตรวจสอบรายการนี้
+func NewPasswordPolicy(minLength int, hashCost int, breachedListPath string) (*PasswordPolicy, error) {
+	if minLength <= 0 {
+		minLength = defaultPasswordMinLength
+	}
+	if hashCost < bcrypt.MinCost || hashCost > bcrypt.MaxCost {
+		hashCost = defaultPasswordHashCost
+	}
+
+	policy := &PasswordPolicy{
+		MinLength: minLength,
+		HashCost:  hashCost,
+		breached:  make(map[string]struct{}),
+	}
+
+	if breachedListPath == "" {
+		return policy, nil
+	}
+
+	file, err := os.Open(breachedListPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			policy.breached[strings.ToLower(line)] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %v", err)
+	}
+
+	return policy, nil
+}
+
+// Validate ตรวจสอบรหัสผ่านตาม policy
+func (p *PasswordPolicy) Validate(password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if _, found := p.breached[strings.ToLower(password)]; found {
+		return fmt.Errorf("password has appeared in a data breach, please choose another one")
+	}
+	return nil
+}
+
+// Hash แฮชรหัสผ่านด้วย cost ปัจจุบันของ policy
+func (p *PasswordPolicy) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), p.HashCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// NeedsRehash คืนค่า true ถ้าแฮชเดิมใช้ cost ต่ำกว่าที่ policy กำหนด
+func (p *PasswordPolicy) NeedsRehash(hashed string) bool {
+	cost, err := bcrypt.Cost([]byte(hashed))
+	if err != nil {
+		return false
+	}
+	return cost < p.HashCost
+}
+
+// IsPasswordPolicyError ตรวจสอบว่า error มาจากการไม่ผ่าน policy หรือไม่
+func IsPasswordPolicyError(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "password ")
+}
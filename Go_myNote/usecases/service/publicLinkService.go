@@ -0,0 +1,141 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type PublicLinkUseCase interface {
+	CreatePublicLink(noteID uint, userID uint, expiresAt string, password string) (*entities.PublicLink, error)
+	GetPublicLinks(noteID uint, userID uint) ([]entities.PublicLink, error)
+	RevokePublicLink(linkID uint, userID uint) error
+	ViewPublicNote(slug string, password string) (*entities.Note, error)
+}
+
+type PublicLinkService struct {
+	linkRepo repository.PublicLinkRepository
+	noteRepo repository.NoteRepository
+}
+
+func NewPublicLinkService(linkRepo repository.PublicLinkRepository, noteRepo repository.NoteRepository) *PublicLinkService {
+	return &PublicLinkService{
+		linkRepo: linkRepo,
+		noteRepo: noteRepo,
+	}
+}
+
+// CreatePublicLink สร้างลิงก์สาธารณะของโน้ต (เฉพาะเจ้าของ) โดยกำหนดวันหมดอายุและรหัสผ่านได้
+func (s *PublicLinkService) CreatePublicLink(noteID uint, userID uint, expiresAt string, password string) (*entities.PublicLink, error) {
+	if _, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID); err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+
+	// ตรวจสอบวันหมดอายุ (ถ้ามี)
+	if expiresAt != "" {
+		expiry, ok := parseTime(expiresAt)
+		if !ok {
+			return nil, fmt.Errorf("invalid expiry time format")
+		}
+		if expiry.Before(time.Now()) {
+			return nil, fmt.Errorf("expiry time must be in the future")
+		}
+	}
+
+	slug, err := generateRandomToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate link: %v", err)
+	}
+
+	link := &entities.PublicLink{
+		NoteID:    noteID,
+		Slug:      slug,
+		ExpiresAt: expiresAt,
+		CreatedBy: userID,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	if password != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		link.PasswordHash = string(hashed)
+	}
+
+	if err := s.linkRepo.CreatePublicLink(link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (s *PublicLinkService) GetPublicLinks(noteID uint, userID uint) ([]entities.PublicLink, error) {
+	if _, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID); err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+	return s.linkRepo.GetPublicLinksByNoteID(noteID)
+}
+
+func (s *PublicLinkService) RevokePublicLink(linkID uint, userID uint) error {
+	link, err := s.linkRepo.GetPublicLinkByID(linkID)
+	if err != nil {
+		return err
+	}
+
+	// เฉพาะเจ้าของโน้ตเท่านั้นที่ยกเลิกลิงก์ได้
+	if _, err := s.noteRepo.GetNoteByIdAndUser(link.NoteID, userID); err != nil {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+
+	return s.linkRepo.RevokePublicLink(linkID)
+}
+
+// ViewPublicNote ดึงโน้ตจากลิงก์สาธารณะ พร้อมตรวจสอบสถานะลิงก์และรหัสผ่าน แล้วนับจำนวนการเข้าชม
+func (s *PublicLinkService) ViewPublicNote(slug string, password string) (*entities.Note, error) {
+	link, err := s.linkRepo.GetPublicLinkBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.RevokedAt != "" {
+		return nil, fmt.Errorf("link not found")
+	}
+	if expiry, ok := parseTime(link.ExpiresAt); ok && time.Now().After(expiry) {
+		return nil, fmt.Errorf("link has expired")
+	}
+
+	if link.PasswordHash != "" {
+		if password == "" {
+			return nil, fmt.Errorf("password required")
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)); err != nil {
+			return nil, fmt.Errorf("invalid password")
+		}
+	}
+
+	note, err := s.noteRepo.GetNoteById(link.NoteID)
+	if err != nil || note.DeletedAt != "" {
+		return nil, fmt.Errorf("link not found")
+	}
+
+	if err := s.linkRepo.IncrementViewCount(link.LinkID); err != nil {
+		log.Printf("Failed to count view for link %d: %v", link.LinkID, err)
+	}
+
+	return note, nil
+}
+
+// สร้าง token แบบสุ่มที่ใช้ใน URL ได้
+func generateRandomToken() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rateLimitWindow      = time.Minute      // ช่วงเวลาที่นับจำนวนคำขอ
+	rateLimitBaseBackoff = 30 * time.Second // ระยะเวลาบล็อกครั้งแรก
+	rateLimitMaxBackoff  = time.Hour        // ระยะเวลาบล็อกสูงสุด
+	rateLimitStrikeReset = 24 * time.Hour   // ล้างประวัติการถูกบล็อกหลังจากนี้
+)
+
+type RateLimitUseCase interface {
+	Allow(key string, limit int) (time.Duration, error)
+	Reset(key string) error
+}
+
+type RateLimitService struct {
+	repo      repository.RateLimitRepository
+	auditRepo repository.AuditRepository
+	mu        sync.Mutex
+}
+
+func NewRateLimitService(repo repository.RateLimitRepository, auditRepo repository.AuditRepository) *RateLimitService {
+	return &RateLimitService{
+		repo:      repo,
+		auditRepo: auditRepo,
+	}
+}
+
+// Allow นับคำขอของ key และคืนค่าระยะเวลาที่ต้องรอ (0 หมายถึงอนุญาต)
+// เมื่อเกิน limit ภายใน rateLimitWindow จะถูกบล็อกนานขึ้นเป็นเท่าตัวในแต่ละครั้ง
+func (s *RateLimitService) Allow(key string, limit int) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rateLimit, err := s.repo.GetRateLimit(key)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+
+	// ยังอยู่ในช่วงที่ถูกบล็อก
+	if blockedUntil, ok := parseTime(rateLimit.BlockedUntil); ok {
+		if now.Before(blockedUntil) {
+			return blockedUntil.Sub(now), nil
+		}
+		if now.Sub(blockedUntil) > rateLimitStrikeReset {
+			rateLimit.Strikes = 0
+		}
+		rateLimit.BlockedUntil = ""
+	}
+
+	// เริ่มช่วงเวลาใหม่ถ้าช่วงเดิมหมดแล้ว
+	windowStart, ok := parseTime(rateLimit.WindowStart)
+	if !ok || now.Sub(windowStart) > rateLimitWindow {
+		rateLimit.Hits = 0
+		rateLimit.WindowStart = now.Format("2006-01-02 15:04:05")
+	}
+
+	rateLimit.Hits++
+
+	var wait time.Duration
+	if rateLimit.Hits > limit {
+		rateLimit.Strikes++
+		wait = backoffDuration(rateLimit.Strikes)
+		rateLimit.BlockedUntil = now.Add(wait).Format("2006-01-02 15:04:05")
+		rateLimit.Hits = 0
+		rateLimit.WindowStart = ""
+
+		s.audit(0, "rate_limit_blocked", fmt.Sprintf("key=%s backoff=%s", key, wait))
+	}
+
+	if err := s.repo.SaveRateLimit(rateLimit); err != nil {
+		return 0, err
+	}
+
+	return wait, nil
+}
+
+func (s *RateLimitService) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.repo.DeleteRateLimit(key)
+}
+
+func (s *RateLimitService) audit(userID uint, action string, detail string) {
+	entry := &entities.AuditLog{
+		UserID:    userID,
+		Action:    action,
+		Detail:    detail,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := s.auditRepo.CreateAuditLog(entry); err != nil {
+		log.Printf("Failed to write audit log: %v", err)
+	}
+}
+
+// EmailRateLimitKey key ของตัวนับคำขอต่ออีเมล ไม่สนตัวพิมพ์เล็กใหญ่และช่องว่างรอบอีเมล
+func EmailRateLimitKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// คำนวณระยะเวลาบล็อก 30s, 1m, 2m, ... ไม่เกิน rateLimitMaxBackoff
+func backoffDuration(strikes int) time.Duration {
+	wait := rateLimitBaseBackoff
+	for i := 1; i < strikes; i++ {
+		wait *= 2
+		if wait >= rateLimitMaxBackoff {
+			return rateLimitMaxBackoff
+		}
+	}
+	return wait
+}
+
+// แปลงเวลาในรูปแบบที่ใช้เก็บในฐานข้อมูล คืนค่า false ถ้าค่าว่างหรือรูปแบบไม่ถูกต้อง
+func parseTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	parsed, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsed, true
+}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"miw/entities"
+	"miw/usecases/repository"
+	"time"
+)
+
+type RecurrenceUseCase interface {
+	SetRecurrence(noteID uint, userID uint, frequency string, mode string, startAt string) (*entities.NoteRecurrence, error)
+	GetRecurrence(noteID uint, userID uint) (*entities.NoteRecurrence, error)
+	DeleteRecurrence(noteID uint, userID uint) error
+	GetHistory(noteID uint, userID uint) ([]entities.RecurrenceInstance, error)
+	ScheduleAll()
+}
+
+type RecurrenceService struct {
+	recurrenceRepo repository.RecurrenceRepository
+	noteRepo       repository.NoteRepository
+	noteUseCase    NoteUseCase
+	scheduler      *Scheduler
+	activity       *ActivityRecorder
+	notifications  *NotificationService
+}
+
+func NewRecurrenceService(recurrenceRepo repository.RecurrenceRepository, noteRepo repository.NoteRepository, noteUseCase NoteUseCase, scheduler *Scheduler, activity *ActivityRecorder, notifications *NotificationService) *RecurrenceService {
+	return &RecurrenceService{
+		recurrenceRepo: recurrenceRepo,
+		noteRepo:       noteRepo,
+		noteUseCase:    noteUseCase,
+		scheduler:      scheduler,
+		activity:       activity,
+		notifications:  notifications,
+	}
+}
+
+// SetRecurrence ตั้งหรือแก้ไขการทำซ้ำของโน้ต (เจ้าของเท่านั้น) ถ้าไม่ระบุ startAt รอบแรกจะเริ่มหลังจากนี้หนึ่งรอบ
+func (s *RecurrenceService) SetRecurrence(noteID uint, userID uint, frequency string, mode string, startAt string) (*entities.NoteRecurrence, error) {
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+	if !note.IsTodo && len(note.TodoItems) == 0 {
+		return nil, fmt.Errorf("recurrence requires a todo note")
+	}
+
+	if mode == "" {
+		mode = "reset"
+	}
+	if mode != "reset" && mode != "clone" {
+		return nil, fmt.Errorf("invalid recurrence mode")
+	}
+
+	thLocation, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Thailand timezone: %v", err)
+	}
+	now := time.Now().In(thLocation)
+
+	// ไม่ระบุเวลาเริ่มให้นับจากตอนนี้ รอบแรกคืออีกหนึ่งรอบถัดไป
+	anchor := now
+	if startAt != "" {
+		if anchor, err = time.ParseInLocation("2006-01-02 15:04:05", startAt, thLocation); err != nil {
+			return nil, fmt.Errorf("invalid start time format")
+		}
+		if anchor.Before(now) {
+			return nil, fmt.Errorf("recurrence start time is in the past")
+		}
+	}
+	nextRun := anchor
+	if startAt == "" {
+		if nextRun, err = nextRecurrenceTime(anchor, now, frequency); err != nil {
+			return nil, err
+		}
+	} else if _, err := nextRecurrenceTime(anchor, now, frequency); err != nil {
+		return nil, err
+	}
+
+	recurrence, err := s.recurrenceRepo.GetRecurrenceByNoteID(noteID)
+	var before interface{}
+	if err != nil {
+		recurrence = &entities.NoteRecurrence{
+			NoteID:    noteID,
+			CreatedAt: now.Format("2006-01-02 15:04:05"),
+		}
+	} else {
+		before = recurrenceSnapshot(recurrence)
+	}
+	recurrence.UserID = userID
+	recurrence.Frequency = frequency
+	recurrence.Mode = mode
+	recurrence.AnchorAt = anchor.Format("2006-01-02 15:04:05")
+	recurrence.NextRunAt = nextRun.Format("2006-01-02 15:04:05")
+	recurrence.UpdatedAt = now.Format("2006-01-02 15:04:05")
+
+	if err := s.recurrenceRepo.SaveRecurrence(recurrence); err != nil {
+		return nil, err
+	}
+	s.schedule(recurrence)
+
+	s.activity.Record(noteID, userID, "recurrence.set", "recurrence", recurrence.RecurrenceID, before, recurrenceSnapshot(recurrence))
+	return recurrence, nil
+}
+
+func (s *RecurrenceService) GetRecurrence(noteID uint, userID uint) (*entities.NoteRecurrence, error) {
+	if err := s.checkAccess(noteID, userID); err != nil {
+		return nil, err
+	}
+	return s.recurrenceRepo.GetRecurrenceByNoteID(noteID)
+}
+
+func (s *RecurrenceService) DeleteRecurrence(noteID uint, userID uint) error {
+	if _, err := s.noteRepo.GetNoteByIdAndUser(noteID, userID); err != nil {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	recurrence, err := s.recurrenceRepo.GetRecurrenceByNoteID(noteID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.recurrenceRepo.DeleteRecurrence(recurrence.RecurrenceID); err != nil {
+		return err
+	}
+	s.scheduler.Cancel(recurrenceKey(recurrence.RecurrenceID))
+
+	s.activity.Record(noteID, userID, "recurrence.delete", "recurrence", recurrence.RecurrenceID, recurrenceSnapshot(recurrence), nil)
+	return nil
+}
+
+// GetHistory ประวัติรอบที่ผ่านมา ล่าสุดก่อน
+func (s *RecurrenceService) GetHistory(noteID uint, userID uint) ([]entities.RecurrenceInstance, error) {
+	if err := s.checkAccess(noteID, userID); err != nil {
+		return nil, err
+	}
+	recurrence, err := s.recurrenceRepo.GetRecurrenceByNoteID(noteID)
+	if err != nil {
+		return nil, err
+	}
+	return s.recurrenceRepo.GetInstancesByRecurrenceID(recurrence.RecurrenceID)
+}
+
+// ScheduleAll ตั้งเวลาการทำซ้ำทั้งหมดเมื่อเริ่มเซิร์ฟเวอร์ รอบที่เลยเวลาไปแล้วจะทำงานทันที
+func (s *RecurrenceService) ScheduleAll() {
+	recurrences, err := s.recurrenceRepo.GetAllRecurrences()
+	if err != nil {
+		log.Printf("Failed to load recurrences: %v", err)
+		return
+	}
+	for i := range recurrences {
+		s.schedule(&recurrences[i])
+	}
+}
+
+func (s *RecurrenceService) checkAccess(noteID uint, userID uint) error {
+	allowed, err := s.noteRepo.IsUserAllowedToAccessNote(noteID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check access permission: %v", err)
+	}
+	if !allowed {
+		return fmt.Errorf("you are not authorized to view this note")
+	}
+	return nil
+}
+
+func (s *RecurrenceService) schedule(recurrence *entities.NoteRecurrence) {
+	thLocation, _ := time.LoadLocation("Asia/Bangkok")
+	runAt, err := time.ParseInLocation("2006-01-02 15:04:05", recurrence.NextRunAt, thLocation)
+	if err != nil {
+		log.Printf("Invalid next run time of recurrence %d: %v", recurrence.RecurrenceID, err)
+		return
+	}
+
+	recurrenceID := recurrence.RecurrenceID
+	s.scheduler.Schedule(recurrenceKey(recurrenceID), runAt, func() {
+		s.run(recurrenceID)
+	})
+}
+
+func recurrenceKey(recurrenceID uint) string {
+	return fmt.Sprintf("recurrence:%d", recurrenceID)
+}
+
+// run จบรอบปัจจุบัน: บันทึกประวัติ แล้วรีเซ็ตรายการหรือคัดลอกเป็นโน้ตใหม่ จากนั้นตั้งเวลารอบถัดไป
+func (s *RecurrenceService) run(recurrenceID uint) {
+	// อ่านใหม่ทุกครั้ง เพราะอาจถูกแก้ไขหรือลบไปหลังตั้งเวลา
+	recurrence, err := s.recurrenceRepo.GetRecurrenceByID(recurrenceID)
+	if err != nil {
+		return
+	}
+
+	note, err := s.noteRepo.GetNoteById(recurrence.NoteID)
+	if err != nil {
+		// โน้ตถูกลบถาวรแล้ว
+		if err := s.recurrenceRepo.DeleteRecurrence(recurrenceID); err != nil {
+			log.Printf("Failed to delete recurrence %d: %v", recurrenceID, err)
+		}
+		return
+	}
+
+	thLocation, _ := time.LoadLocation("Asia/Bangkok")
+	now := time.Now().In(thLocation)
+
+	// โน้ตในถังขยะหรือในคลังจะข้ามรอบนี้ไป แต่ยังตั้งเวลารอบถัดไปไว้
+	if note.DeletedAt == "" && note.ArchivedAt == "" {
+		s.completePeriod(recurrence, note, now)
+	}
+
+	// รายการที่สร้างก่อนมี AnchorAt ใช้เวลารอบถัดไปที่บันทึกไว้เป็นจุดเริ่ม
+	anchorAt := recurrence.AnchorAt
+	if anchorAt == "" {
+		anchorAt = recurrence.NextRunAt
+	}
+	anchor, err := time.ParseInLocation("2006-01-02 15:04:05", anchorAt, thLocation)
+	if err != nil {
+		anchor = now
+	}
+	recurrence.AnchorAt = anchor.Format("2006-01-02 15:04:05")
+	// ข้ามรอบที่พลาดไประหว่างเซิร์ฟเวอร์หยุด ไม่ต้องรีเซ็ตซ้ำหลายครั้ง
+	runAt, err := nextRecurrenceTime(anchor, now, recurrence.Frequency)
+	if err != nil {
+		log.Printf("Recurrence %d has invalid frequency: %v", recurrenceID, err)
+		return
+	}
+	recurrence.LastRunAt = now.Format("2006-01-02 15:04:05")
+	recurrence.NextRunAt = runAt.Format("2006-01-02 15:04:05")
+	recurrence.UpdatedAt = recurrence.LastRunAt
+
+	if err := s.recurrenceRepo.SaveRecurrence(recurrence); err != nil {
+		log.Printf("Failed to save recurrence %d: %v", recurrenceID, err)
+		return
+	}
+	s.schedule(recurrence)
+}
+
+func (s *RecurrenceService) completePeriod(recurrence *entities.NoteRecurrence, note *entities.Note, now time.Time) {
+	periodStart := recurrence.LastRunAt
+	if periodStart == "" {
+		periodStart = recurrence.CreatedAt
+	}
+	instance := &entities.RecurrenceInstance{
+		RecurrenceID: recurrence.RecurrenceID,
+		NoteID:       note.NoteID,
+		PeriodStart:  periodStart,
+		PeriodEnd:    now.Format("2006-01-02 15:04:05"),
+		TotalItems:   len(note.TodoItems),
+		Items:        []entities.RecurrenceItem{},
+	}
+	for _, todo := range note.TodoItems {
+		instance.Items = append(instance.Items, entities.RecurrenceItem{Content: todo.Content, IsDone: todo.IsDone})
+		if todo.IsDone {
+			instance.DoneItems++
+		}
+	}
+	instance.Completed = instance.TotalItems > 0 && instance.DoneItems == instance.TotalItems
+
+	if recurrence.Mode == "clone" {
+		// สร้างโน้ตรอบใหม่ในนามเจ้าของปัจจุบัน แล้วเก็บโน้ตรอบเดิมเข้าคลัง
+		clone, err := s.noteUseCase.DuplicateNote(note.NoteID, note.UserID)
+		if err != nil {
+			log.Printf("Failed to clone note %d for recurrence: %v", note.NoteID, err)
+			return
+		}
+		if err := s.noteUseCase.ArchiveNote(note.NoteID, note.UserID); err != nil {
+			log.Printf("Failed to archive note %d after recurrence: %v", note.NoteID, err)
+		}
+		instance.NextNoteID = clone.NoteID
+		recurrence.NoteID = clone.NoteID
+	} else {
+		if err := s.noteRepo.SetAllTodoItemsDone(note.NoteID, false); err != nil {
+			log.Printf("Failed to reset note %d for recurrence: %v", note.NoteID, err)
+			return
+		}
+		if _, err := s.noteRepo.SyncNoteCompletion(note.NoteID); err != nil {
+			log.Printf("Failed to update status of note %d: %v", note.NoteID, err)
+		}
+		s.activity.Record(note.NoteID, 0, "note.recur", "note", note.NoteID, instance.Items, nil)
+	}
+
+	if err := s.recurrenceRepo.CreateInstance(instance); err != nil {
+		log.Printf("Failed to save recurrence history of note %d: %v", note.NoteID, err)
+	}
+
+	s.notifications.Notify(note.UserID, "recurrence", "New period: "+note.Title, fmt.Sprintf("%d of %d items were done last period", instance.DoneItems, instance.TotalItems), recurrence.NoteID, 0)
+}
+
+// recurrenceSnapshot ค่าของการทำซ้ำสำหรับบันทึกกิจกรรม
+func recurrenceSnapshot(recurrence *entities.NoteRecurrence) map[string]interface{} {
+	return map[string]interface{}{
+		"frequency":   recurrence.Frequency,
+		"mode":        recurrence.Mode,
+		"next_run_at": recurrence.NextRunAt,
+	}
+}
+
+// nextRecurrenceTime รอบแรกที่อยู่หลัง after โดยนับจาก anchor ทุกครั้ง (anchor, anchor+1 รอบ, anchor+2 รอบ, ...)
+// รายเดือนและรายปีถ้าเดือนนั้นไม่มีวันของ anchor จะใช้วันสุดท้ายของเดือน เช่น 31 ม.ค. -> 29 ก.พ. -> 31 มี.ค.
+func nextRecurrenceTime(anchor time.Time, after time.Time, frequency string) (time.Time, error) {
+	var days, months int
+	switch frequency {
+	case "daily":
+		days = 1
+	case "weekly":
+		days = 7
+	case "monthly":
+		months = 1
+	case "yearly":
+		months = 12
+	default:
+		return after, fmt.Errorf("invalid frequency")
+	}
+
+	// ประมาณจำนวนรอบที่ผ่านไปแล้วก่อน เพื่อไม่ต้องวนทีละรอบเมื่อหยุดไปนาน
+	periods := 0
+	if days > 0 {
+		periods = int(after.Sub(anchor).Hours()/24) / days
+	} else {
+		periods = ((after.Year()-anchor.Year())*12 + int(after.Month()-anchor.Month())) / months
+	}
+	if periods < 0 {
+		periods = 0
+	}
+
+	for {
+		var next time.Time
+		if days > 0 {
+			next = anchor.AddDate(0, 0, days*periods)
+		} else {
+			next = addMonthsClamped(anchor, months*periods)
+		}
+		if next.After(after) {
+			return next, nil
+		}
+		periods++
+	}
+}
+
+// addMonthsClamped เลื่อนไป n เดือนโดยไม่ล้นไปเดือนถัดไป วันที่เกินวันสุดท้ายของเดือนจะใช้วันสุดท้ายแทน
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	day := t.Day()
+	if lastDay := first.AddDate(0, 1, -1).Day(); day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -18,19 +18,26 @@ type ReminderUseCase interface {
 	GetReminderByNoteID(userID uint, noteID uint) ([]entities.Reminder, error)
 	UpdateReminder(userID uint, reminderID uint, reminderTime *string, recurring *bool, frequency *string) error
 	DeleteReminder(userID uint, reminderID uint) error
+	CancelReminders(reminderIDs []uint)
 }
 
 type ReminderService struct {
-	reminderRepo repository.ReminderRepository
-	noteRepo     repository.NoteRepository
-	userRepo     repository.UserRepository
+	reminderRepo  repository.ReminderRepository
+	noteRepo      repository.NoteRepository
+	userRepo      repository.UserRepository
+	scheduler     *Scheduler
+	activity      *ActivityRecorder
+	notifications *NotificationService
 }
 
-func NewReminderService(reminderRepo repository.ReminderRepository, noteRepo repository.NoteRepository, userRepo repository.UserRepository) *ReminderService {
+func NewReminderService(reminderRepo repository.ReminderRepository, noteRepo repository.NoteRepository, userRepo repository.UserRepository, scheduler *Scheduler, activity *ActivityRecorder, notifications *NotificationService) *ReminderService {
 	return &ReminderService{
-		reminderRepo: reminderRepo,
-		noteRepo:     noteRepo,
-		userRepo:     userRepo,
+		reminderRepo:  reminderRepo,
+		noteRepo:      noteRepo,
+		userRepo:      userRepo,
+		scheduler:     scheduler,
+		activity:      activity,
+		notifications: notifications,
 	}
 }
 
@@ -94,6 +101,8 @@ func (s *ReminderService) AddReminder(noteID uint, userID uint, reminder *entiti
 	// ตั้งค่าแจ้งเตือน
 	s.scheduleReminder(note, reminder, reminderTime)
 
+	s.activity.Record(noteID, userID, "reminder.create", "reminder", reminder.ReminderID, nil, reminderSnapshot(reminder))
+
 	// คืนค่า Reminder ที่สร้างใหม่
 	return reminder, nil
 }
@@ -114,6 +123,8 @@ func (s *ReminderService) UpdateReminder(userID uint, reminderID uint, reminderT
 		return fmt.Errorf("note not found or does not belong to the user")
 	}
 
+	before := reminderSnapshot(existingReminder)
+
 	// ตรวจสอบเวลาที่ส่งมา
 	if reminderTime != nil {
 		thLocation, _ := time.LoadLocation("Asia/Bangkok")
@@ -150,20 +161,39 @@ func (s *ReminderService) UpdateReminder(userID uint, reminderID uint, reminderT
 	parsedTime, _ := time.ParseInLocation("2006-01-02 15:04:05", existingReminder.ReminderTime, thLocation)
 	s.scheduleReminder(note, existingReminder, parsedTime)
 
+	s.activity.Record(existingReminder.NoteID, userID, "reminder.update", "reminder", reminderID, before, reminderSnapshot(existingReminder))
 	return nil
 }
 
+// reminderSnapshot ค่าของ Reminder สำหรับบันทึกกิจกรรม
+func reminderSnapshot(reminder *entities.Reminder) map[string]interface{} {
+	return map[string]interface{}{
+		"reminder_time": reminder.ReminderTime,
+		"recurring":     reminder.Recurring,
+		"frequency":     reminder.Frequency,
+	}
+}
+
 func (s *ReminderService) scheduleReminder(note *entities.Note, reminder *entities.Reminder, reminderTime time.Time) {
-	go func() {
-		durationUntilReminder := time.Until(reminderTime)
-		time.AfterFunc(durationUntilReminder, func() {
-			s.sendReminder(note, reminder)
+	// ใช้ key ตาม ReminderID เพื่อให้การตั้งเวลาใหม่แทนที่ของเดิม และยกเลิกได้ภายหลัง
+	s.scheduler.Schedule(reminderKey(reminder.ReminderID), reminderTime, func() {
+		s.sendReminder(note, reminder)
 
-			if reminder.Recurring {
-				s.scheduleRecurringReminder(note, reminder, reminderTime)
-			}
-		})
-	}()
+		if reminder.Recurring {
+			s.scheduleRecurringReminder(note, reminder, reminderTime)
+		}
+	})
+}
+
+// CancelReminders ยกเลิกการแจ้งเตือนที่ตั้งเวลาไว้ของ Reminder ที่ระบุ
+func (s *ReminderService) CancelReminders(reminderIDs []uint) {
+	for _, reminderID := range reminderIDs {
+		s.scheduler.Cancel(reminderKey(reminderID))
+	}
+}
+
+func reminderKey(reminderID uint) string {
+	return fmt.Sprintf("reminder:%d", reminderID)
 }
 
 func (s *ReminderService) scheduleRecurringReminder(note *entities.Note, reminder *entities.Reminder, reminderTime time.Time) {
@@ -187,6 +217,14 @@ func (s *ReminderService) scheduleRecurringReminder(note *entities.Note, reminde
 }
 
 func (s *ReminderService) sendReminder(note *entities.Note, reminder *entities.Reminder) {
+	// ดึงโน้ตล่าสุดตอนส่ง เพื่อให้ส่งถึงเจ้าของปัจจุบัน (กรณีโอนโน้ต) และเนื้อหาเป็นปัจจุบัน
+	if latest, err := s.noteRepo.GetNoteById(reminder.NoteID); err == nil {
+		note = latest
+	}
+
+	// แจ้งเตือนในแอปก่อน เพื่อให้ได้รับแม้ส่งอีเมลไม่สำเร็จ
+	s.notifications.Notify(note.UserID, "reminder", "Reminder: "+note.Title, fmt.Sprintf("Reminder time: %s", reminder.ReminderTime), note.NoteID, 0)
+
 	userEmail, err := s.userRepo.GetUserEmailByID(note.UserID)
 	if err != nil {
 		log.Printf("Failed to get user email: %v", err)
@@ -238,6 +276,12 @@ func (s *ReminderService) DeleteReminder(userID uint, reminderID uint) error {
 		return fmt.Errorf("note not found or does not belong to the user")
 	}
 
-	// ลบ Reminder
-	return s.reminderRepo.DeleteReminder(reminderID)
+	// ลบ Reminder และยกเลิกการแจ้งเตือนที่ตั้งเวลาไว้
+	if err := s.reminderRepo.DeleteReminder(reminderID); err != nil {
+		return err
+	}
+	s.CancelReminders([]uint{reminderID})
+
+	s.activity.Record(existingReminder.NoteID, userID, "reminder.delete", "reminder", reminderID, reminderSnapshot(existingReminder), nil)
+	return nil
 }
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// Scheduler เก็บ timer ของงานที่ตั้งเวลาไว้ เพื่อให้ยกเลิกหรือตั้งเวลาใหม่ได้
+type Scheduler struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{timers: make(map[string]*time.Timer)}
+}
+
+// Schedule ตั้งเวลาให้ fn ทำงานที่เวลา at ถ้า key เดิมมีงานอยู่แล้วจะถูกแทนที่
+func (s *Scheduler) Schedule(key string, at time.Time, fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.timers[key]; ok {
+		timer.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(at), func() {
+		s.mu.Lock()
+		// ลบออกเฉพาะกรณีที่ยังเป็น timer ตัวเดิม (ไม่ถูกตั้งเวลาใหม่ไปแล้ว)
+		if s.timers[key] == timer {
+			delete(s.timers, key)
+		}
+		s.mu.Unlock()
+
+		fn()
+	})
+	s.timers[key] = timer
+}
+
+// Cancel ยกเลิกงานที่ตั้งเวลาไว้ของ key
+func (s *Scheduler) Cancel(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.timers[key]; ok {
+		timer.Stop()
+		delete(s.timers, key)
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"log"
+	"miw/utils"
+	texttemplate "text/template"
+)
+
+// shareEmailData ข้อมูลที่ใช้เติมในเทมเพลตอีเมลเกี่ยวกับการแชร์
+type shareEmailData struct {
+	NoteTitle  string
+	ActorEmail string
+	Action     string
+	Link       string
+}
+
+type shareEmailTemplate struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+func newShareEmailTemplate(subject, text, html string) shareEmailTemplate {
+	return shareEmailTemplate{
+		subject: texttemplate.Must(texttemplate.New("subject").Parse(subject)),
+		text:    texttemplate.Must(texttemplate.New("text").Parse(text)),
+		html:    htmltemplate.Must(htmltemplate.New("html").Parse(html)),
+	}
+}
+
+var (
+	noteSharedEmail = newShareEmailTemplate(
+		`{{.ActorEmail}} shared a note with you`,
+		`{{.ActorEmail}} shared the note "{{.NoteTitle}}" with you on MyNote.
+
+Open your inbox to accept or decline:
+{{.Link}}
+`,
+		`<p><strong>{{.ActorEmail}}</strong> shared the note <strong>{{.NoteTitle}}</strong> with you on MyNote.</p>
+<p><a href="{{.Link}}">Open your inbox</a> to accept or decline.</p>`,
+	)
+
+	shareRevokedEmail = newShareEmailTemplate(
+		`Your access to "{{.NoteTitle}}" was removed`,
+		`{{.ActorEmail}} removed your access to the note "{{.NoteTitle}}" on MyNote.
+`,
+		`<p><strong>{{.ActorEmail}}</strong> removed your access to the note <strong>{{.NoteTitle}}</strong> on MyNote.</p>`,
+	)
+
+	shareResponseEmail = newShareEmailTemplate(
+		`{{.ActorEmail}} {{.Action}} your shared note`,
+		`{{.ActorEmail}} has {{.Action}} the note "{{.NoteTitle}}" that you shared.
+`,
+		`<p><strong>{{.ActorEmail}}</strong> has {{.Action}} the note <strong>{{.NoteTitle}}</strong> that you shared.</p>`,
+	)
+
+	shareInvitationEmail = newShareEmailTemplate(
+		`You have been invited to a note`,
+		`You have been invited to collaborate on the note "{{.NoteTitle}}" on MyNote.
+
+Sign up with this email address to get access:
+{{.Link}}
+`,
+		`<p>You have been invited to collaborate on the note <strong>{{.NoteTitle}}</strong> on MyNote.</p>
+<p><a href="{{.Link}}">Sign up with this email address</a> to get access.</p>`,
+	)
+
+	mentionEmail = newShareEmailTemplate(
+		`{{.ActorEmail}} mentioned you in "{{.NoteTitle}}"`,
+		`{{.ActorEmail}} mentioned you in {{.Action}} of the note "{{.NoteTitle}}" on MyNote.
+
+See all your mentions:
+{{.Link}}
+`,
+		`<p><strong>{{.ActorEmail}}</strong> mentioned you in {{.Action}} of the note <strong>{{.NoteTitle}}</strong> on MyNote.</p>
+<p><a href="{{.Link}}">See all your mentions</a>.</p>`,
+	)
+)
+
+// render เติมข้อมูลลงในหัวเรื่อง เนื้อหาข้อความ และเนื้อหา HTML
+func (t shareEmailTemplate) render(data shareEmailData) (string, string, string, error) {
+	var subject, text, html bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return "", "", "", err
+	}
+	if err := t.text.Execute(&text, data); err != nil {
+		return "", "", "", err
+	}
+	if err := t.html.Execute(&html, data); err != nil {
+		return "", "", "", err
+	}
+	return subject.String(), text.String(), html.String(), nil
+}
+
+// sendShareEmail ส่งอีเมลแบบไม่รอผล การแชร์ยังสำเร็จแม้ส่งอีเมลไม่ได้
+func sendShareEmail(to string, tmpl shareEmailTemplate, data shareEmailData) {
+	go func() {
+		subject, text, html, err := tmpl.render(data)
+		if err != nil {
+			log.Printf("Failed to render share email for %s: %v", to, err)
+			return
+		}
+		if err := utils.SendHTMLEmail(to, subject, text, html); err != nil {
+			log.Printf("Failed to send share email to %s: %v", to, err)
+		}
+	}()
+}
@@ -2,7 +2,10 @@ package service
 
 import (
 	"fmt"
+	"miw/entities"
 	"miw/usecases/repository"
+	"strings"
+	"time"
 )
 
 type ShareNoteUseCase interface {
@@ -10,17 +13,34 @@ type ShareNoteUseCase interface {
 	IsUserAllowedToEdit(noteID uint, userID uint) (bool, error)
 	RemoveShareByEmail(noteID uint, ownerID uint, email string) error 
 	GetSharedEmailsByNoteID(noteID uint) ([]map[string]string, error)
+	TransferOwnership(noteID uint, ownerID uint, email string) ([]map[string]string, error)
+	GetPendingInvitations(noteID uint, ownerID uint) ([]entities.ShareInvitation, error)
+	RevokeInvitation(invitationID uint, ownerID uint) error
+	GetInvitationInfo(token string) (map[string]string, error)
+	AcceptInvitations(email string, userID uint) error
+	GetIncomingShares(userID uint, status string) ([]entities.IncomingShare, error)
+	RespondToShare(shareID uint, userID uint, accept bool) error
+	LeaveSharedNote(noteID uint, userID uint) error
+	ShareNoteWithGroup(noteID uint, ownerID uint, groupID uint, permission string) ([]map[string]string, error)
+	RespondToGroupShare(noteID uint, userID uint, accept bool) error
+	RemoveGroupShare(noteID uint, ownerID uint, groupID uint) error
 }
 
 type ShareNoteService struct {
-	shareRepo repository.ShareNoteRepository
-	noteRepo  repository.NoteRepository
+	shareRepo     repository.ShareNoteRepository
+	noteRepo      repository.NoteRepository
+	groupRepo     repository.ContactGroupRepository
+	activity      *ActivityRecorder
+	notifications *NotificationService
 }
 
-func NewShareNoteService(shareRepo repository.ShareNoteRepository, noteRepo repository.NoteRepository) *ShareNoteService {
+func NewShareNoteService(shareRepo repository.ShareNoteRepository, noteRepo repository.NoteRepository, groupRepo repository.ContactGroupRepository, activity *ActivityRecorder, notifications *NotificationService) *ShareNoteService {
     return &ShareNoteService{
-        shareRepo: shareRepo,
-        noteRepo:  noteRepo,
+        shareRepo:     shareRepo,
+        noteRepo:      noteRepo,
+        groupRepo:     groupRepo,
+        activity:      activity,
+        notifications: notifications,
     }
 }
 
@@ -28,13 +48,17 @@ func NewShareNoteService(shareRepo repository.ShareNoteRepository, noteRepo repo
 // Share a note with another user by email
 func (s *ShareNoteService) ShareNoteWithEmail(noteID uint, ownerID uint, email string) ([]map[string]string, error) {
 	// ตรวจสอบว่า Note เป็นของ Owner
-	if _, err := s.noteRepo.GetNoteByIdAndUser(noteID, ownerID); err != nil {
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, ownerID)
+	if err != nil {
 		return nil, fmt.Errorf("note not found or does not belong to the user")
 	}
 
-	// ดึงข้อมูล User จาก Email
+	// ดึงข้อมูล User จาก Email ถ้ายังไม่มีบัญชีให้ส่งคำเชิญแทน
 	user, err := s.shareRepo.GetUserByEmail(email)
 	if err != nil {
+		if err.Error() == "email not found" {
+			return s.inviteByEmail(noteID, ownerID, email)
+		}
 		return nil, fmt.Errorf("email not found: %v", err)
 	}
 
@@ -57,6 +81,20 @@ func (s *ShareNoteService) ShareNoteWithEmail(noteID uint, ownerID uint, email s
 		return nil, fmt.Errorf("failed to share note: %v", err)
 	}
 
+	s.activity.Record(noteID, ownerID, "share.add", "share", user.UserID, nil, user.Email)
+
+	// แจ้งผู้รับในแอป และทางอีเมล ยกเว้นผู้ใช้ที่ปิดการแจ้งเตือนทางอีเมลไว้
+	if owner, err := s.shareRepo.GetUserByID(ownerID); err == nil {
+		s.notifications.Notify(user.UserID, "share", "A note was shared with you", fmt.Sprintf("%s shared \"%s\" with you", owner.Email, note.Title), noteID, ownerID)
+		if !user.ShareEmailsOptOut {
+			sendShareEmail(user.Email, noteSharedEmail, shareEmailData{
+				NoteTitle:  note.Title,
+				ActorEmail: owner.Email,
+				Link:       "http://localhost:3000/share/inbox",
+			})
+		}
+	}
+
 	// ดึงอีเมลที่แชร์ทั้งหมดหลังจากการแชร์สำเร็จ
 	sharedEmails, err := s.shareRepo.GetSharedEmailsByNoteID(noteID)
 	if err != nil {
@@ -76,15 +114,44 @@ func (s *ShareNoteService) IsUserAllowedToEdit(noteID uint, userID uint) (bool,
 		return true, nil
 	}
 
-	isShared, err := s.shareRepo.IsNoteSharedWithUser(noteID, userID)
+	canEdit, err := s.shareRepo.IsUserAllowedToEdit(noteID, userID)
 	if err != nil {
 		return false, err
 	}
-	return isShared, nil
+	return canEdit, nil
 }
 
 func (s *ShareNoteService) RemoveShareByEmail(noteID uint, ownerID uint, email string) error {
-    return s.shareRepo.RemoveShareByEmail(noteID, ownerID, email)
+	// เก็บข้อมูลการแชร์ไว้ก่อนลบ เพื่อแจ้งผู้รับว่าถูกยกเลิกสิทธิ์
+	var share *entities.ShareNote
+	user, err := s.shareRepo.GetUserByEmail(email)
+	if err == nil {
+		share, _ = s.shareRepo.GetShare(noteID, user.UserID)
+	}
+
+	if err := s.shareRepo.RemoveShareByEmail(noteID, ownerID, email); err != nil {
+		return err
+	}
+
+	if share != nil {
+		s.activity.Record(noteID, ownerID, "share.remove", "share", user.UserID, user.Email, nil)
+	}
+
+	// ไม่ต้องแจ้งผู้ที่ปฏิเสธการแชร์ไปแล้ว
+	if share != nil && share.Status != "declined" {
+		note, err := s.noteRepo.GetNoteById(noteID)
+		owner, ownerErr := s.shareRepo.GetUserByID(ownerID)
+		if err == nil && ownerErr == nil {
+			s.notifications.Notify(user.UserID, "share_revoked", "Your access to a note was removed", fmt.Sprintf("%s removed your access to \"%s\"", owner.Email, note.Title), noteID, ownerID)
+			if !user.ShareEmailsOptOut {
+				sendShareEmail(user.Email, shareRevokedEmail, shareEmailData{
+					NoteTitle:  note.Title,
+					ActorEmail: owner.Email,
+				})
+			}
+		}
+	}
+	return nil
 }
 
 
@@ -95,3 +162,335 @@ func (s *ShareNoteService) GetSharedEmailsByNoteID(noteID uint) ([]map[string]st
 	}
 	return emails, nil
 }
+
+// TransferOwnership โอนความเป็นเจ้าของโน้ตให้ผู้ร่วมแก้ไขที่มีอยู่แล้ว
+func (s *ShareNoteService) TransferOwnership(noteID uint, ownerID uint, email string) ([]map[string]string, error) {
+	// ตรวจสอบว่า Note เป็นของ Owner
+	if _, err := s.noteRepo.GetNoteByIdAndUser(noteID, ownerID); err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+
+	newOwner, err := s.shareRepo.GetUserByEmail(email)
+	if err != nil {
+		return nil, fmt.Errorf("email not found: %v", err)
+	}
+	if newOwner.UserID == ownerID {
+		return nil, fmt.Errorf("cannot transfer note to the owner")
+	}
+
+	// ผู้รับโอนต้องเป็นผู้ร่วมแก้ไขที่ตอบรับการแชร์แล้ว
+	share, err := s.shareRepo.GetShare(noteID, newOwner.UserID)
+	if err != nil || share.Status != "accepted" {
+		return nil, fmt.Errorf("new owner must be a collaborator of this note")
+	}
+
+	if err := s.shareRepo.TransferNoteOwnership(noteID, ownerID, newOwner.UserID); err != nil {
+		return nil, fmt.Errorf("failed to transfer note: %v", err)
+	}
+
+	s.activity.Record(noteID, ownerID, "note.transfer", "note", noteID, ownerID, newOwner.UserID)
+
+	if note, err := s.noteRepo.GetNoteById(noteID); err == nil {
+		if owner, err := s.shareRepo.GetUserByID(ownerID); err == nil {
+			s.notifications.Notify(newOwner.UserID, "ownership_transferred", "You are now the owner of a note", fmt.Sprintf("%s transferred \"%s\" to you", owner.Email, note.Title), noteID, ownerID)
+		}
+	}
+
+	return s.GetSharedEmailsByNoteID(noteID)
+}
+
+// inviteByEmail สร้างคำเชิญให้อีเมลที่ยังไม่มีบัญชี และส่งอีเมลเชิญให้สมัครสมาชิก
+func (s *ShareNoteService) inviteByEmail(noteID uint, ownerID uint, email string) ([]map[string]string, error) {
+	email = strings.TrimSpace(email)
+	if email == "" || !strings.Contains(email, "@") {
+		return nil, fmt.Errorf("invalid email")
+	}
+
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+
+	isInvited, err := s.shareRepo.HasPendingInvitation(noteID, email)
+	if err != nil {
+		return nil, err
+	}
+	if isInvited {
+		return nil, fmt.Errorf("this email has already been invited to the note")
+	}
+
+	token, err := generateRandomToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation: %v", err)
+	}
+
+	invitation := &entities.ShareInvitation{
+		NoteID:    noteID,
+		InvitedBy: ownerID,
+		Email:     email,
+		Token:     token,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := s.shareRepo.CreateInvitation(invitation); err != nil {
+		return nil, err
+	}
+
+	s.activity.Record(noteID, ownerID, "invitation.create", "invitation", invitation.InvitationID, nil, email)
+
+	// ส่งอีเมลเชิญแบบไม่รอผล คำเชิญยังอยู่แม้ส่งไม่สำเร็จ
+	sendShareEmail(email, shareInvitationEmail, shareEmailData{
+		NoteTitle: note.Title,
+		Link:      "http://localhost:3000/register?invite=" + token,
+	})
+
+	return s.shareRepo.GetSharedEmailsByNoteID(noteID)
+}
+
+func (s *ShareNoteService) GetPendingInvitations(noteID uint, ownerID uint) ([]entities.ShareInvitation, error) {
+	if _, err := s.noteRepo.GetNoteByIdAndUser(noteID, ownerID); err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+	return s.shareRepo.GetPendingInvitationsByNoteID(noteID)
+}
+
+func (s *ShareNoteService) RevokeInvitation(invitationID uint, ownerID uint) error {
+	invitation, err := s.shareRepo.GetInvitationByID(invitationID)
+	if err != nil {
+		return err
+	}
+	if invitation.AcceptedAt != "" {
+		return fmt.Errorf("invitation has already been accepted")
+	}
+
+	// เฉพาะเจ้าของโน้ตเท่านั้นที่ยกเลิกคำเชิญได้
+	if _, err := s.noteRepo.GetNoteByIdAndUser(invitation.NoteID, ownerID); err != nil {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+
+	if err := s.shareRepo.DeleteInvitation(invitationID); err != nil {
+		return err
+	}
+
+	s.activity.Record(invitation.NoteID, ownerID, "invitation.revoke", "invitation", invitationID, invitation.Email, nil)
+	return nil
+}
+
+// GetInvitationInfo ข้อมูลคำเชิญสำหรับแสดงในหน้าสมัครสมาชิก
+func (s *ShareNoteService) GetInvitationInfo(token string) (map[string]string, error) {
+	invitation, err := s.shareRepo.GetInvitationByToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := s.noteRepo.GetNoteById(invitation.NoteID)
+	if err != nil || note.DeletedAt != "" {
+		return nil, fmt.Errorf("invitation not found")
+	}
+
+	status := "pending"
+	if invitation.AcceptedAt != "" {
+		status = "accepted"
+	}
+
+	return map[string]string{
+		"email":      invitation.Email,
+		"note_title": note.Title,
+		"status":     status,
+	}, nil
+}
+
+// AcceptInvitations แปลงคำเชิญที่ค้างอยู่ของอีเมลเป็นการแชร์ เรียกหลังผู้ใช้สมัครสมาชิก
+func (s *ShareNoteService) AcceptInvitations(email string, userID uint) error {
+	return s.shareRepo.AcceptInvitationsForUser(email, userID)
+}
+
+// GetIncomingShares ดึงโน้ตที่ถูกแชร์มาให้ผู้ใช้ ค่าเริ่มต้นคือรายการที่รอตอบรับ
+func (s *ShareNoteService) GetIncomingShares(userID uint, status string) ([]entities.IncomingShare, error) {
+	if status == "" {
+		status = "pending"
+	}
+	if status != "pending" && status != "accepted" && status != "declined" {
+		return nil, fmt.Errorf("invalid status")
+	}
+	return s.shareRepo.GetIncomingShares(userID, status)
+}
+
+// RespondToShare ผู้รับตอบรับหรือปฏิเสธการแชร์
+func (s *ShareNoteService) RespondToShare(shareID uint, userID uint, accept bool) error {
+	share, err := s.shareRepo.GetShareByID(shareID)
+	if err != nil {
+		return err
+	}
+	// เฉพาะผู้รับการแชร์เท่านั้นที่ตอบได้
+	if share.SharedWith != userID {
+		return fmt.Errorf("share not found")
+	}
+	if share.Status != "pending" {
+		return fmt.Errorf("share has already been answered")
+	}
+
+	status := "declined"
+	if accept {
+		status = "accepted"
+	}
+	if err := s.shareRepo.UpdateShareStatus(shareID, status); err != nil {
+		return err
+	}
+
+	s.activity.Record(share.NoteID, userID, "share."+status, "share", userID, share.Status, status)
+
+	s.notifyOwner(share.NoteID, userID, status)
+	return nil
+}
+
+// LeaveSharedNote ผู้รับออกจากโน้ตที่ถูกแชร์มา
+func (s *ShareNoteService) LeaveSharedNote(noteID uint, userID uint) error {
+	// ผู้ใช้อาจได้รับแชร์ทั้งโดยตรงและผ่านกลุ่ม ต้องออกจากทั้งสองทางจึงจะไม่เห็นโน้ตอีก
+	share, err := s.shareRepo.GetShare(noteID, userID)
+	hasDirectShare := err == nil && share.Status != "declined"
+	hasGroupShare, err := s.shareRepo.HasGroupShareAccess(noteID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check group shares: %v", err)
+	}
+	if !hasDirectShare && !hasGroupShare {
+		return fmt.Errorf("share not found")
+	}
+
+	var before interface{} = "group"
+	if hasDirectShare {
+		if err := s.shareRepo.DeleteShare(share.ShareNoteID); err != nil {
+			return err
+		}
+		before = share.Status
+	}
+	// ออกจากโน้ตที่แชร์ผ่านกลุ่มโดยยังเป็นสมาชิกกลุ่มอยู่ จะกลับมารอตอบรับเมื่อเจ้าของแชร์ให้กลุ่มอีกครั้ง
+	if hasGroupShare {
+		if err := s.shareRepo.SetGroupShareResponse(noteID, userID, "declined"); err != nil {
+			return err
+		}
+	}
+
+	s.activity.Record(noteID, userID, "share.leave", "share", userID, before, nil)
+
+	s.notifyOwner(noteID, userID, "left")
+	return nil
+}
+
+// notifyOwner แจ้งเจ้าของโน้ตในแอปและทางอีเมลเมื่อผู้รับตอบรับ ปฏิเสธ หรือออกจากโน้ต
+func (s *ShareNoteService) notifyOwner(noteID uint, userID uint, action string) {
+	note, err := s.noteRepo.GetNoteById(noteID)
+	if err != nil {
+		return
+	}
+	owner, err := s.shareRepo.GetUserByID(note.UserID)
+	if err != nil {
+		return
+	}
+	recipient, err := s.shareRepo.GetUserByID(userID)
+	if err != nil {
+		return
+	}
+
+	s.notifications.Notify(owner.UserID, "share_response", "A collaborator "+action+" your note", fmt.Sprintf("%s has %s \"%s\"", recipient.Email, action, note.Title), noteID, userID)
+	if owner.ShareEmailsOptOut {
+		return
+	}
+
+	sendShareEmail(owner.Email, shareResponseEmail, shareEmailData{
+		NoteTitle:  note.Title,
+		ActorEmail: recipient.Email,
+		Action:     action,
+	})
+}
+
+// ShareNoteWithGroup แชร์โน้ตให้กลุ่มผู้ติดต่อของเจ้าของโน้ต สมาชิกแต่ละคนต้องตอบรับก่อน (เหมือนการแชร์รายคน)
+// permission เป็น editor (ค่าเริ่มต้น) หรือ viewer
+func (s *ShareNoteService) ShareNoteWithGroup(noteID uint, ownerID uint, groupID uint, permission string) ([]map[string]string, error) {
+	if permission == "" {
+		permission = "editor"
+	}
+	if permission != "editor" && permission != "viewer" {
+		return nil, fmt.Errorf("invalid permission")
+	}
+
+	note, err := s.noteRepo.GetNoteByIdAndUser(noteID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("note not found or does not belong to the user")
+	}
+
+	// แชร์ได้เฉพาะกลุ่มของตัวเอง
+	group, err := s.groupRepo.GetGroupByID(groupID)
+	if err != nil || group.OwnerID != ownerID {
+		return nil, fmt.Errorf("group not found")
+	}
+
+	isShared, err := s.shareRepo.IsNoteSharedWithGroup(noteID, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if note is already shared: %v", err)
+	}
+	if isShared {
+		return nil, fmt.Errorf("this group has already been shared with the note")
+	}
+
+	if err := s.shareRepo.ShareNoteWithGroup(noteID, groupID, permission); err != nil {
+		return nil, err
+	}
+
+	s.activity.Record(noteID, ownerID, "group_share.add", "group_share", groupID, nil, map[string]string{"group": group.Name, "permission": permission})
+
+	// แจ้งสมาชิกในกลุ่มในแอป และทางอีเมล ยกเว้นผู้ที่ปิดการแจ้งเตือนทางอีเมลไว้
+	if owner, err := s.shareRepo.GetUserByID(ownerID); err == nil {
+		if members, err := s.groupRepo.GetMembers(groupID); err == nil {
+			for _, member := range members {
+				s.notifications.Notify(member.UserID, "share", "A note was shared with your group", fmt.Sprintf("%s shared \"%s\" with the group %s", owner.Email, note.Title, group.Name), noteID, ownerID)
+				user, err := s.shareRepo.GetUserByID(member.UserID)
+				if err != nil || user.ShareEmailsOptOut {
+					continue
+				}
+				sendShareEmail(user.Email, noteSharedEmail, shareEmailData{
+					NoteTitle:  note.Title,
+					ActorEmail: owner.Email,
+					Link:       "http://localhost:3000/share/inbox",
+				})
+			}
+		}
+	}
+
+	return s.GetSharedEmailsByNoteID(noteID)
+}
+
+// RespondToGroupShare สมาชิกกลุ่มตอบรับหรือปฏิเสธโน้ตที่แชร์ผ่านกลุ่ม
+func (s *ShareNoteService) RespondToGroupShare(noteID uint, userID uint, accept bool) error {
+	pending, err := s.shareRepo.HasPendingGroupShare(noteID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check group shares: %v", err)
+	}
+	if !pending {
+		return fmt.Errorf("share not found")
+	}
+
+	status := "declined"
+	if accept {
+		status = "accepted"
+	}
+	if err := s.shareRepo.SetGroupShareResponse(noteID, userID, status); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, userID, "share."+status, "share", userID, "pending", status)
+
+	s.notifyOwner(noteID, userID, status)
+	return nil
+}
+
+func (s *ShareNoteService) RemoveGroupShare(noteID uint, ownerID uint, groupID uint) error {
+	if _, err := s.noteRepo.GetNoteByIdAndUser(noteID, ownerID); err != nil {
+		return fmt.Errorf("note not found or does not belong to the user")
+	}
+	if err := s.shareRepo.RemoveGroupShare(noteID, groupID); err != nil {
+		return err
+	}
+
+	s.activity.Record(noteID, ownerID, "group_share.remove", "group_share", groupID, groupID, nil)
+	return nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"miw/entities"
+	"miw/usecases/repository"
+	"time"
+)
+
+const (
+	defaultStatsDays = 30  // ช่วงของสถิติรายวันเมื่อไม่ระบุ
+	maxStatsDays     = 365 // ช่วงยาวที่สุดที่ให้ขอได้
+	topTagsLimit     = 10
+)
+
+type StatsUseCase interface {
+	GetStats(userID uint, days int) (*entities.UserStats, error)
+}
+
+type StatsService struct {
+	statsRepo repository.StatsRepository
+}
+
+func NewStatsService(statsRepo repository.StatsRepository) *StatsService {
+	return &StatsService{statsRepo: statsRepo}
+}
+
+// GetStats สถิติของผู้ใช้ days คือจำนวนวันย้อนหลังของสถิติรายวัน/รายสัปดาห์ (รวมวันนี้)
+func (s *StatsService) GetStats(userID uint, days int) (*entities.UserStats, error) {
+	if days <= 0 {
+		days = defaultStatsDays
+	}
+	if days > maxStatsDays {
+		days = maxStatsDays
+	}
+
+	today := time.Now()
+	fromDate := today.AddDate(0, 0, -(days - 1))
+	from := fromDate.Format("2006-01-02")
+
+	stats := &entities.UserStats{
+		From: from,
+		To:   today.Format("2006-01-02"),
+	}
+
+	var err error
+	if stats.TotalNotes, err = s.statsRepo.CountNotes(userID); err != nil {
+		return nil, err
+	}
+
+	perDay, err := s.statsRepo.CountNotesCreatedPerDay(userID, from)
+	if err != nil {
+		return nil, err
+	}
+	stats.NotesCreatedPerDay = fillDailySeries(perDay, fromDate, days)
+
+	if stats.NotesCreatedPerWeek, err = s.statsRepo.CountNotesCreatedPerWeek(userID, from); err != nil {
+		return nil, err
+	}
+
+	completed, err := s.statsRepo.CountTodosCompletedPerDay(userID, from)
+	if err != nil {
+		return nil, err
+	}
+	stats.TodosCompletedPerDay = fillDailySeries(completed, fromDate, days)
+
+	if stats.OverdueTodos, err = s.statsRepo.CountOverdueTodos(userID, stats.To); err != nil {
+		return nil, err
+	}
+
+	// เวลาแจ้งเตือนเก็บเป็นเวลาประเทศไทย
+	thLocation, _ := time.LoadLocation("Asia/Bangkok")
+	if stats.OverdueReminders, err = s.statsRepo.GetOverdueReminders(userID, time.Now().In(thLocation).Format("2006-01-02 15:04:05")); err != nil {
+		return nil, err
+	}
+
+	if stats.NotesByPriority, err = s.statsRepo.CountNotesByPriority(userID); err != nil {
+		return nil, err
+	}
+	if stats.NotesByColor, err = s.statsRepo.CountNotesByColor(userID); err != nil {
+		return nil, err
+	}
+	if stats.TopTags, err = s.statsRepo.GetTopTags(userID, topTagsLimit); err != nil {
+		return nil, err
+	}
+
+	shares, err := s.statsRepo.GetShareStats(userID)
+	if err != nil {
+		return nil, err
+	}
+	stats.Shares = *shares
+
+	return stats, nil
+}
+
+// fillDailySeries เติมวันที่ไม่มีข้อมูลด้วย 0 เพื่อให้ไคลเอนต์วาดกราฟได้ทันที
+func fillDailySeries(counts []entities.PeriodCount, from time.Time, days int) []entities.PeriodCount {
+	byDay := make(map[string]int64, len(counts))
+	for _, count := range counts {
+		byDay[count.Period] = count.Count
+	}
+
+	series := make([]entities.PeriodCount, 0, days)
+	for i := 0; i < days; i++ {
+		day := from.AddDate(0, 0, i).Format("2006-01-02")
+		series = append(series, entities.PeriodCount{Period: day, Count: byDay[day]})
+	}
+	return series
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+)
+
+// maxSyncActivities จำนวนกิจกรรมสูงสุดที่อ่านต่อการ sync หนึ่งครั้ง ถ้าเกินให้ไคลเอนต์เรียกต่อด้วย cursor ใหม่
+const maxSyncActivities = 1000
+
+type SyncUseCase interface {
+	GetChanges(userID uint, cursor uint) (*entities.SyncChanges, error)
+	ApplyMutations(userID uint, cursor uint, mutations []entities.SyncMutation) ([]entities.SyncResult, error)
+}
+
+type SyncService struct {
+	noteUseCase  NoteUseCase
+	noteRepo     repository.NoteRepository
+	tagRepo      repository.TagRepository
+	shareRepo    repository.ShareNoteRepository
+	activityRepo repository.ActivityRepository
+}
+
+func NewSyncService(noteUseCase NoteUseCase, noteRepo repository.NoteRepository, tagRepo repository.TagRepository, shareRepo repository.ShareNoteRepository, activityRepo repository.ActivityRepository) *SyncService {
+	return &SyncService{
+		noteUseCase:  noteUseCase,
+		noteRepo:     noteRepo,
+		tagRepo:      tagRepo,
+		shareRepo:    shareRepo,
+		activityRepo: activityRepo,
+	}
+}
+
+// GetChanges cursor = 0 คือ sync ครั้งแรก ส่งข้อมูลทั้งหมด ไม่เช่นนั้นส่งเฉพาะสิ่งที่เปลี่ยนหลัง cursor
+// cursor คือรหัสกิจกรรมใน activity log
+func (s *SyncService) GetChanges(userID uint, cursor uint) (*entities.SyncChanges, error) {
+	if cursor == 0 {
+		return s.fullSync(userID)
+	}
+
+	// รวมโน้ตที่ถูกย้ายลงถังขยะหรือเก็บเข้าคลัง และกิจกรรมที่ทำให้เสียสิทธิ์ เพื่อให้ได้ tombstone ของโน้ตเหล่านั้น
+	noteIDs, err := s.noteRepo.GetAccessibleNoteIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notes: %v", err)
+	}
+	activities, err := s.activityRepo.GetActivitiesAfter(noteIDs, userID, cursor, maxSyncActivities)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &entities.SyncChanges{
+		Cursor:     cursor,
+		HasMore:    len(activities) == maxSyncActivities,
+		Notes:      []entities.Note{},
+		Shares:     []entities.SyncNoteShares{},
+		Tombstones: []entities.SyncTombstone{},
+	}
+
+	changedNotes := make(map[uint]bool)
+	var noteOrder []uint
+	tagsChanged := false
+	for _, activity := range activities {
+		changes.Cursor = activity.ActivityID
+
+		switch activity.Action {
+		case "tag.delete":
+			changes.Tombstones = append(changes.Tombstones, entities.SyncTombstone{EntityType: "tag", EntityID: activity.EntityID, Reason: "deleted"})
+		case "tag.merge":
+			changes.Tombstones = append(changes.Tombstones, entities.SyncTombstone{EntityType: "tag", EntityID: activity.EntityID, Reason: "merged"})
+		case "reminder.delete":
+			changes.Tombstones = append(changes.Tombstones, entities.SyncTombstone{EntityType: "reminder", EntityID: activity.EntityID, NoteID: activity.NoteID, Reason: "deleted"})
+		}
+		if activity.EntityType == "tag" {
+			tagsChanged = true
+		}
+
+		if activity.NoteID != 0 && !changedNotes[activity.NoteID] {
+			changedNotes[activity.NoteID] = true
+			noteOrder = append(noteOrder, activity.NoteID)
+		}
+	}
+
+	for _, noteID := range noteOrder {
+		note, err := s.noteRepo.GetNoteById(noteID)
+		if err != nil {
+			changes.Tombstones = append(changes.Tombstones, entities.SyncTombstone{EntityType: "note", EntityID: noteID, NoteID: noteID, Reason: "deleted"})
+			continue
+		}
+		allowed, err := s.noteRepo.IsUserAllowedToAccessNote(noteID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check access permission: %v", err)
+		}
+		if !allowed {
+			changes.Tombstones = append(changes.Tombstones, entities.SyncTombstone{EntityType: "note", EntityID: noteID, NoteID: noteID, Reason: "access_revoked"})
+			continue
+		}
+		if note.DeletedAt != "" {
+			changes.Tombstones = append(changes.Tombstones, entities.SyncTombstone{EntityType: "note", EntityID: noteID, NoteID: noteID, Reason: "trashed"})
+			continue
+		}
+		// โน้ตในคลังไม่อยู่ในรายการหลัก (เหมือน full sync)
+		if note.ArchivedAt != "" {
+			changes.Tombstones = append(changes.Tombstones, entities.SyncTombstone{EntityType: "note", EntityID: noteID, NoteID: noteID, Reason: "archived"})
+			continue
+		}
+
+		changes.Notes = append(changes.Notes, *note)
+		if note.UserID == userID {
+			if err := s.appendShares(changes, noteID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if tagsChanged {
+		if changes.Tags, err = s.tagRepo.GetAllTagsByUserId(userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+func (s *SyncService) fullSync(userID uint) (*entities.SyncChanges, error) {
+	// อ่าน cursor ก่อนข้อมูล การเปลี่ยนแปลงที่เกิดระหว่างนี้จะถูกส่งซ้ำในรอบถัดไปแทนที่จะหายไป
+	latest, err := s.activityRepo.GetLatestActivityID()
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := s.noteRepo.GetAllNoteByUserId(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch notes: %v", err)
+	}
+	tags, err := s.tagRepo.GetAllTagsByUserId(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &entities.SyncChanges{
+		Cursor:     latest,
+		FullSync:   true,
+		Notes:      notes,
+		Tags:       tags,
+		Shares:     []entities.SyncNoteShares{},
+		Tombstones: []entities.SyncTombstone{},
+	}
+	for _, note := range notes {
+		if note.UserID != userID {
+			continue
+		}
+		if err := s.appendShares(changes, note.NoteID); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+func (s *SyncService) appendShares(changes *entities.SyncChanges, noteID uint) error {
+	shares, err := s.shareRepo.GetSharedEmailsByNoteID(noteID)
+	if err != nil {
+		return fmt.Errorf("failed to get shared emails: %v", err)
+	}
+	changes.Shares = append(changes.Shares, entities.SyncNoteShares{NoteID: noteID, Shares: shares})
+	return nil
+}
+
+// ApplyMutations ทำ mutation ตามลำดับ ถ้าโน้ตถูกแก้ไขหลัง cursor ที่ไคลเอนต์ใช้ จะรายงาน conflict
+// พร้อมข้อมูลล่าสุดแทนการเขียนทับ (ยกเว้นส่ง force) รายการหนึ่งผิดพลาดไม่กระทบรายการอื่น
+func (s *SyncService) ApplyMutations(userID uint, cursor uint, mutations []entities.SyncMutation) ([]entities.SyncResult, error) {
+	// mutation ในชุดนี้เองสร้างกิจกรรมใหม่ จึงตรวจ conflict เฉพาะกิจกรรมก่อนเริ่มชุด
+	batchStart, err := s.activityRepo.GetLatestActivityID()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]entities.SyncResult, 0, len(mutations))
+	for _, mutation := range mutations {
+		result := entities.SyncResult{ClientID: mutation.ClientID, NoteID: mutation.NoteID}
+
+		if mutation.Op != "create_note" && !mutation.Force {
+			count, err := s.activityRepo.CountNoteActivitiesBetween(mutation.NoteID, cursor, batchStart)
+			if err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				result.Status = "conflict"
+				result.Error = "note has been changed on the server"
+				result.ServerNote = s.serverNote(mutation.NoteID, userID)
+				results = append(results, result)
+				continue
+			}
+		}
+
+		noteID, err := s.applyMutation(userID, mutation)
+		if err != nil {
+			result.Status = "error"
+			result.Error = err.Error()
+		} else {
+			result.Status = "applied"
+			result.NoteID = noteID
+		}
+		result.ServerNote = s.serverNote(result.NoteID, userID)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (s *SyncService) applyMutation(userID uint, mutation entities.SyncMutation) (uint, error) {
+	switch mutation.Op {
+	case "create_note":
+		note := &entities.Note{
+			UserID:      userID,
+			WorkspaceID: mutation.WorkspaceID,
+			IsTodo:      mutation.IsTodo,
+			TodoItems:   mergeTodoInputs(mutation.TodoItems, nil),
+		}
+		if mutation.Title != nil {
+			note.Title = *mutation.Title
+		}
+		if mutation.Content != nil {
+			note.Content = *mutation.Content
+		}
+		if mutation.Color != nil {
+			note.Color = *mutation.Color
+		}
+		if mutation.Priority != nil {
+			note.Priority = *mutation.Priority
+		}
+		// ใช้กฎเดียวกับ POST /note
+		if len(note.TodoItems) > 0 && note.Content != "" {
+			return 0, fmt.Errorf("note cannot have both content and todo_items")
+		}
+		if len(note.TodoItems) == 0 && note.Content == "" {
+			return 0, fmt.Errorf("note must have either content or todo_items")
+		}
+		if err := s.noteUseCase.CreateNote(note); err != nil {
+			return 0, err
+		}
+		return note.NoteID, nil
+
+	case "update_note":
+		if mutation.Title != nil || mutation.Content != nil || len(mutation.TodoItems) > 0 {
+			var title, content string
+			if mutation.Title != nil {
+				title = *mutation.Title
+			}
+			if mutation.Content != nil {
+				content = *mutation.Content
+			}
+			if err := s.noteUseCase.UpdateTitleAndContent(mutation.NoteID, userID, title, content, mutation.TodoItems); err != nil {
+				return mutation.NoteID, err
+			}
+		}
+		if mutation.Color != nil {
+			if err := s.noteUseCase.UpdateColor(mutation.NoteID, userID, *mutation.Color); err != nil {
+				return mutation.NoteID, err
+			}
+		}
+		if mutation.Priority != nil {
+			if err := s.noteUseCase.UpdatePriority(mutation.NoteID, userID, *mutation.Priority); err != nil {
+				return mutation.NoteID, err
+			}
+		}
+		return mutation.NoteID, nil
+
+	case "delete_note":
+		return mutation.NoteID, s.noteUseCase.DeleteNoteById(mutation.NoteID, userID)
+	case "restore_note":
+		return mutation.NoteID, s.noteUseCase.RestoreNoteById(mutation.NoteID, userID)
+	case "todo_status":
+		// ต้องมีสิทธิ์แก้ไขเหมือน PUT /note/:noteid/todo/:todoid/status ผู้ที่ดูได้อย่างเดียวจะได้ status error
+		return mutation.NoteID, s.noteUseCase.UpdateTodoStatus(mutation.NoteID, mutation.TodoID, userID, mutation.IsDone)
+	case "add_tag":
+		return mutation.NoteID, s.noteUseCase.AddTagToNote(mutation.NoteID, mutation.TagID, userID)
+	case "remove_tag":
+		return mutation.NoteID, s.noteUseCase.RemoveTagFromNote(mutation.NoteID, mutation.TagID, userID)
+	case "archive_note":
+		return mutation.NoteID, s.noteUseCase.ArchiveNote(mutation.NoteID, userID)
+	case "unarchive_note":
+		return mutation.NoteID, s.noteUseCase.UnarchiveNote(mutation.NoteID, userID)
+	}
+	return mutation.NoteID, fmt.Errorf("unsupported operation: %s", mutation.Op)
+}
+
+// serverNote ข้อมูลโน้ตล่าสุดถ้าผู้ใช้ยังเข้าถึงได้
+func (s *SyncService) serverNote(noteID uint, userID uint) *entities.Note {
+	if noteID == 0 {
+		return nil
+	}
+	allowed, err := s.noteRepo.IsUserAllowedToAccessNote(noteID, userID)
+	if err != nil || !allowed {
+		return nil
+	}
+	note, err := s.noteRepo.GetNoteById(noteID)
+	if err != nil {
+		return nil
+	}
+	return note
+}
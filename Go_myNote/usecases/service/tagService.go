@@ -5,6 +5,7 @@ import (
 	"miw/usecases/repository"
 	"fmt"
 	"gorm.io/gorm"
+	"strings"
 )
 
 type TagUseCase interface {
@@ -12,28 +13,52 @@ type TagUseCase interface {
 	GetAllTagsByUserId(userID uint) ([]entities.Tag, error)
 	GetTagById(tagID, userID uint) (*entities.Tag, error) 
 	UpdateTagName(tagID, userID uint, newName string) error
+	UpdateTagStyle(tagID, userID uint, color, icon *string) error
 	DeleteTag(tagID, userID uint) error
+	MergeTags(sourceID, targetID, userID uint) (*entities.Tag, error)
 }
 
 type TagService struct {
 	repo repository.TagRepository
 	noteRepo  repository.NoteRepository
+	workspaceRepo repository.WorkspaceRepository
+	activity *ActivityRecorder
 }
 
-func NewTagService(repo repository.TagRepository,noteRepo repository.NoteRepository) *TagService {
+func NewTagService(repo repository.TagRepository,noteRepo repository.NoteRepository, workspaceRepo repository.WorkspaceRepository, activity *ActivityRecorder) *TagService {
 	return &TagService{
 		repo: repo, 
 		noteRepo: noteRepo,
+		workspaceRepo: workspaceRepo,
+		activity: activity,
 	}
 }
 
 // CreateTag: สร้าง Tag พร้อมตรวจสอบว่า User เป็นเจ้าของ
 func (s *TagService) CreateTag(tag *entities.Tag) error {
-	return s.repo.CreateTag(tag)
+	name, err := normalizeTagName(tag.TagName)
+	if err != nil {
+		return err
+	}
+	tag.TagName = name
+
+	// แท็กของเวิร์กสเปซสร้างได้เฉพาะสมาชิกที่แก้ไขได้
+	if tag.WorkspaceID != nil {
+		member, err := s.workspaceRepo.GetMember(*tag.WorkspaceID, tag.UserID)
+		if err != nil || !canEditWorkspace(member.Role) {
+			return fmt.Errorf("you are not allowed to create tags in this workspace")
+		}
+	}
+	if err := s.repo.CreateTag(tag); err != nil {
+		return err
+	}
+
+	s.activity.Record(0, tag.UserID, "tag.create", "tag", tag.TagID, nil, tag.TagName)
+	return nil
 }
 
 func (s *TagService) GetAllTagsByUserId(userID uint) ([]entities.Tag, error) {
-	// ดึงแท็กทั้งหมดที่เป็นของผู้ใช้และแท็กที่เกี่ยวข้องกับโน้ตที่แชร์
+	// ดึงแท็กส่วนตัวของผู้ใช้และแท็กของเวิร์กสเปซ แท็กบนโน้ตที่แชร์มาดูได้จากโน้ตนั้นเท่านั้น
 	return s.repo.GetAllTagsByUserId(userID)
 }
 
@@ -50,17 +75,30 @@ func (s *TagService) GetTagById(tagID, userID uint) (*entities.Tag, error) {
 
 	// ตรวจสอบว่า Tag เป็นของ User หรือโน้ตที่แชร์กับ User
 	isOwner := tag.UserID == userID
-	isShared := false
 
-	// ตรวจสอบว่าแท็กเกี่ยวข้องกับโน้ตที่แชร์ให้ User หรือไม่
+	// แสดงเฉพาะโน้ตที่ User เข้าถึงได้ ไม่เปิดเผยโน้ตอื่นของเจ้าของแท็ก
+	visibleNotes := []entities.Note{}
 	for _, note := range tag.Notes {
-		allowed, err := s.noteRepo.IsUserAllowedToAccessNote(note.NoteID, userID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check access permission: %v", err)
+		allowed := note.UserID == userID
+		if !allowed {
+			allowed, err = s.noteRepo.IsUserAllowedToAccessNote(note.NoteID, userID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to check access permission: %v", err)
+			}
 		}
 		if allowed {
+			visibleNotes = append(visibleNotes, note)
+		}
+	}
+	tag.Notes = visibleNotes
+
+	// แท็กที่ติดอยู่บนโน้ตที่แชร์ให้ User ดูได้ แต่แก้ไขไม่ได้
+	isShared := len(visibleNotes) > 0
+
+	// แท็กของเวิร์กสเปซ สมาชิกทุกคนดูได้
+	if !isOwner && !isShared && tag.WorkspaceID != nil {
+		if _, err := s.workspaceRepo.GetMember(*tag.WorkspaceID, userID); err == nil {
 			isShared = true
-			break
 		}
 	}
 
@@ -71,24 +109,124 @@ func (s *TagService) GetTagById(tagID, userID uint) (*entities.Tag, error) {
 	return tag, nil
 }
 
-// UpdateTagName: แก้ไขชื่อ Tag โดยต้องเป็นเจ้าของเท่านั้น
-func (s *TagService) UpdateTagName(tagID, userID uint, newName string) error {
-	// ตรวจสอบว่าผู้ใช้เป็นเจ้าของแท็กก่อนอัปเดต
+// editableTag แท็กที่ผู้ใช้แก้ไขได้ แท็กส่วนตัวต้องเป็นเจ้าของ แท็กของเวิร์กสเปซต้องเป็นสมาชิกที่แก้ไขได้
+func (s *TagService) editableTag(tagID, userID uint) (*entities.Tag, error) {
 	tag, err := s.GetTagById(tagID, userID)
 	if err != nil {
+		return nil, err
+	}
+	if tag.WorkspaceID != nil {
+		member, err := s.workspaceRepo.GetMember(*tag.WorkspaceID, userID)
+		if err != nil || !canEditWorkspace(member.Role) {
+			return nil, fmt.Errorf("you are not allowed to edit tags in this workspace")
+		}
+	} else if tag.UserID != userID {
+		return nil, fmt.Errorf("tag not found or does not belong to this user")
+	}
+	return tag, nil
+}
+
+// UpdateTagName: แก้ไขชื่อ Tag โดยต้องเป็นเจ้าของ หรือสมาชิกที่แก้ไขเวิร์กสเปซได้
+func (s *TagService) UpdateTagName(tagID, userID uint, newName string) error {
+	// ตรวจสอบสิทธิ์แก้ไขแท็กก่อนอัปเดต
+	tag, err := s.editableTag(tagID, userID)
+	if err != nil {
+		return err
+	}
+
+	newName, err = normalizeTagName(newName)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateTagName(tag.TagID, userID, tag.WorkspaceID, newName); err != nil {
+		return err
+	}
+
+	s.recordTagActivity(tag, userID, "tag.rename", tag.TagName, newName)
+	return nil
+}
+
+// UpdateTagStyle: เปลี่ยนสีหรือไอคอนของ Tag โดยต้องเป็นเจ้าของ หรือสมาชิกที่แก้ไขเวิร์กสเปซได้
+func (s *TagService) UpdateTagStyle(tagID, userID uint, color, icon *string) error {
+	tag, err := s.editableTag(tagID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdateTagStyle(tag.TagID, userID, tag.WorkspaceID, color, icon); err != nil {
 		return err
 	}
 
-	return s.repo.UpdateTagName(tag.TagID, userID, newName)
+	s.recordTagActivity(tag, userID, "tag.style", map[string]string{"color": tag.Color, "icon": tag.Icon}, map[string]interface{}{"color": color, "icon": icon})
+	return nil
 }
 
-// DeleteTag: ลบ Tag โดยต้องเป็นเจ้าของเท่านั้น
+// DeleteTag: ลบ Tag โดยต้องเป็นเจ้าของ หรือสมาชิกที่แก้ไขเวิร์กสเปซได้
 func (s *TagService) DeleteTag(tagID, userID uint) error {
-	// ตรวจสอบว่าผู้ใช้เป็นเจ้าของแท็กก่อนลบ
-	tag, err := s.GetTagById(tagID, userID)
+	// ตรวจสอบสิทธิ์แก้ไขแท็กก่อนลบ
+	tag, err := s.editableTag(tagID, userID)
 	if err != nil {
 		return err
 	}
 
-	return s.repo.DeleteTag(tag.TagID, userID)
+	if err := s.repo.DeleteTag(tag.TagID, userID, tag.WorkspaceID); err != nil {
+		return err
+	}
+
+	s.recordTagActivity(tag, userID, "tag.delete", tag.TagName, nil)
+	return nil
+}
+
+// recordTagActivity บันทึกกิจกรรมของแท็กลงไทม์ไลน์ของทุกโน้ตที่ใช้แท็กนี้
+func (s *TagService) recordTagActivity(tag *entities.Tag, userID uint, action string, before interface{}, after interface{}) {
+	if len(tag.Notes) == 0 {
+		s.activity.Record(0, userID, action, "tag", tag.TagID, before, after)
+		return
+	}
+	for _, note := range tag.Notes {
+		s.activity.Record(note.NoteID, userID, action, "tag", tag.TagID, before, after)
+	}
+}
+
+// MergeTags รวมแท็กต้นทางเข้ากับแท็กปลายทาง ผู้ใช้ต้องแก้ไขแท็กต้นทางได้
+// และทั้งสองแท็กต้องอยู่ในขอบเขตเดียวกัน (แท็กส่วนตัวของผู้ใช้ หรือเวิร์กสเปซเดียวกัน)
+func (s *TagService) MergeTags(sourceID, targetID, userID uint) (*entities.Tag, error) {
+	if sourceID == targetID {
+		return nil, fmt.Errorf("cannot merge a tag into itself")
+	}
+
+	source, err := s.editableTag(sourceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.GetTagById(targetID, userID)
+	if err != nil {
+		return nil, err
+	}
+	sameWorkspace := source.WorkspaceID != nil && target.WorkspaceID != nil && *source.WorkspaceID == *target.WorkspaceID
+	samePersonal := source.WorkspaceID == nil && target.WorkspaceID == nil && target.UserID == userID
+	if !sameWorkspace && !samePersonal {
+		return nil, fmt.Errorf("tags must belong to the same user or workspace")
+	}
+
+	if err := s.repo.MergeTags(source.TagID, target.TagID); err != nil {
+		return nil, err
+	}
+
+	s.recordTagActivity(source, userID, "tag.merge", source.TagName, target.TagName)
+	return s.repo.GetTagById(target.TagID)
+}
+
+// normalizeTagName ตัดช่องว่างรอบแต่ละระดับของชื่อแท็ก เช่น " work / alpha " เป็น "work/alpha"
+func normalizeTagName(name string) (string, error) {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = strings.TrimSpace(segment)
+		if segments[i] == "" {
+			return "", fmt.Errorf("invalid tag name")
+		}
+	}
+	return strings.Join(segments, "/"), nil
 }
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// templatePlaceholder จับ {{name}} โดยอนุญาตช่องว่างรอบชื่อ
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+type TemplateUseCase interface {
+	CreateTemplate(template *entities.NoteTemplate) error
+	SaveNoteAsTemplate(noteID uint, userID uint, name string) (*entities.NoteTemplate, error)
+	GetTemplates(userID uint) ([]entities.NoteTemplate, error)
+	GetTemplate(templateID uint, userID uint) (*entities.NoteTemplate, error)
+	UpdateTemplate(templateID uint, userID uint, update *entities.NoteTemplate) (*entities.NoteTemplate, error)
+	DeleteTemplate(templateID uint, userID uint) error
+	InstantiateTemplate(templateID uint, userID uint, variables map[string]string) (*entities.Note, error)
+}
+
+type TemplateService struct {
+	templateRepo repository.TemplateRepository
+	noteRepo     repository.NoteRepository
+	noteUseCase  NoteUseCase
+}
+
+func NewTemplateService(templateRepo repository.TemplateRepository, noteRepo repository.NoteRepository, noteUseCase NoteUseCase) *TemplateService {
+	return &TemplateService{
+		templateRepo: templateRepo,
+		noteRepo:     noteRepo,
+		noteUseCase:  noteUseCase,
+	}
+}
+
+func (s *TemplateService) CreateTemplate(template *entities.NoteTemplate) error {
+	if err := validateTemplate(template); err != nil {
+		return err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	template.TemplateID = 0
+	template.CreatedAt = now
+	template.UpdatedAt = now
+	return s.templateRepo.CreateTemplate(template)
+}
+
+// SaveNoteAsTemplate สร้างแม่แบบจากโน้ตที่ผู้ใช้เข้าถึงได้
+func (s *TemplateService) SaveNoteAsTemplate(noteID uint, userID uint, name string) (*entities.NoteTemplate, error) {
+	allowed, err := s.noteRepo.IsUserAllowedToAccessNote(noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check access permission: %v", err)
+	}
+	if !allowed {
+		return nil, fmt.Errorf("you are not authorized to view this note")
+	}
+
+	note, err := s.noteRepo.GetNoteById(noteID)
+	if err != nil || note.DeletedAt != "" {
+		return nil, fmt.Errorf("note not found")
+	}
+
+	if strings.TrimSpace(name) == "" {
+		name = note.Title
+	}
+	template := &entities.NoteTemplate{
+		UserID:    userID,
+		Name:      name,
+		Title:     note.Title,
+		Content:   note.Content,
+		IsTodo:    note.IsTodo,
+		Color:     note.Color,
+		Priority:  note.Priority,
+		TodoItems: []string{},
+		TagIDs:    []uint{},
+	}
+	for _, todo := range note.TodoItems {
+		template.TodoItems = append(template.TodoItems, todo.Content)
+	}
+	for _, tag := range note.Tags {
+		template.TagIDs = append(template.TagIDs, tag.TagID)
+	}
+
+	if err := s.CreateTemplate(template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *TemplateService) GetTemplates(userID uint) ([]entities.NoteTemplate, error) {
+	return s.templateRepo.GetTemplatesByUserID(userID)
+}
+
+// GetTemplate แม่แบบเป็นของส่วนตัว เจ้าของเท่านั้นที่เห็น
+func (s *TemplateService) GetTemplate(templateID uint, userID uint) (*entities.NoteTemplate, error) {
+	template, err := s.templateRepo.GetTemplateByID(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template.UserID != userID {
+		return nil, fmt.Errorf("template not found")
+	}
+	return template, nil
+}
+
+func (s *TemplateService) UpdateTemplate(templateID uint, userID uint, update *entities.NoteTemplate) (*entities.NoteTemplate, error) {
+	template, err := s.GetTemplate(templateID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateTemplate(update); err != nil {
+		return nil, err
+	}
+
+	template.Name = update.Name
+	template.Title = update.Title
+	template.Content = update.Content
+	template.IsTodo = update.IsTodo
+	template.TodoItems = update.TodoItems
+	template.Color = update.Color
+	template.Priority = update.Priority
+	template.TagIDs = update.TagIDs
+	template.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+
+	if err := s.templateRepo.UpdateTemplate(template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *TemplateService) DeleteTemplate(templateID uint, userID uint) error {
+	if _, err := s.GetTemplate(templateID, userID); err != nil {
+		return err
+	}
+	return s.templateRepo.DeleteTemplate(templateID)
+}
+
+// InstantiateTemplate สร้างโน้ตใหม่จากแม่แบบ แทนค่า placeholder ในชื่อ เนื้อหา และรายการ ToDo
+// แท็กที่ถูกลบไปแล้วหรือไม่มีสิทธิ์ใช้จะถูกข้าม
+func (s *TemplateService) InstantiateTemplate(templateID uint, userID uint, variables map[string]string) (*entities.Note, error) {
+	template, err := s.GetTemplate(templateID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	note := &entities.Note{
+		UserID:   userID,
+		Title:    expandPlaceholders(template.Title, now, variables),
+		Content:  expandPlaceholders(template.Content, now, variables),
+		IsTodo:   template.IsTodo,
+		Color:    template.Color,
+		Priority: template.Priority,
+	}
+	for _, item := range template.TodoItems {
+		note.TodoItems = append(note.TodoItems, entities.ToDo{Content: expandPlaceholders(item, now, variables)})
+	}
+
+	if err := s.noteUseCase.CreateNote(note); err != nil {
+		return nil, err
+	}
+
+	for _, tagID := range template.TagIDs {
+		if err := s.noteUseCase.AddTagToNote(note.NoteID, tagID, userID); err != nil {
+			continue
+		}
+	}
+
+	// โหลดใหม่เพื่อให้ได้แท็กและรายการ ToDo ที่บันทึกแล้ว
+	if created, err := s.noteRepo.GetNoteById(note.NoteID); err == nil {
+		return created, nil
+	}
+	return note, nil
+}
+
+func validateTemplate(template *entities.NoteTemplate) error {
+	template.Name = strings.TrimSpace(template.Name)
+	if template.Name == "" {
+		return fmt.Errorf("template name is required")
+	}
+	if len(template.TodoItems) > 0 && template.Content != "" {
+		return fmt.Errorf("template cannot have both content and todo_items")
+	}
+	if len(template.TodoItems) == 0 && template.Content == "" {
+		return fmt.Errorf("template must have either content or todo_items")
+	}
+	if template.TodoItems == nil {
+		template.TodoItems = []string{}
+	}
+	if template.TagIDs == nil {
+		template.TagIDs = []uint{}
+	}
+	return nil
+}
+
+// expandPlaceholders แทนค่า {{date}}, {{time}}, {{datetime}}, {{weekday}}, {{week}}, {{month}}, {{year}}
+// และตัวแปรที่ผู้ใช้ส่งมา (ตัวแปรของผู้ใช้มีลำดับก่อน) placeholder ที่ไม่รู้จักจะคงไว้ตามเดิม
+func expandPlaceholders(text string, now time.Time, variables map[string]string) string {
+	return templatePlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		name := templatePlaceholder.FindStringSubmatch(match)[1]
+		if value, ok := variables[name]; ok {
+			return value
+		}
+
+		switch strings.ToLower(name) {
+		case "date":
+			return now.Format("2006-01-02")
+		case "time":
+			return now.Format("15:04")
+		case "datetime":
+			return now.Format("2006-01-02 15:04")
+		case "weekday":
+			return now.Weekday().String()
+		case "week":
+			_, week := now.ISOWeek()
+			return strconv.Itoa(week)
+		case "month":
+			return now.Month().String()
+		case "year":
+			return strconv.Itoa(now.Year())
+		}
+		return match
+	})
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"log"
 	"miw/entities"
 	"miw/usecases/repository"
 	"os"
@@ -19,14 +21,32 @@ type UserUseCase interface {
 	SendResetPasswordEmail(email string) error
 	ResetPassword(tokenString string, newPassword string) error 
 	GetUser(userID uint) (*entities.User, error)
+	DeleteAccount(userID uint, password string, transferToEmail string) error
+	UpdatePassword(userID uint, currentPassword string, newPassword string) error
+	UpdateNotificationSettings(userID uint, shareEmailsOptOut bool) error
 }
 
+const (
+	maxFailedLoginAttempts = 5                // จำนวนครั้งที่ล็อกอินผิดได้ก่อนถูกล็อกบัญชี
+	accountLockoutDuration = 15 * time.Minute // ระยะเวลาที่บัญชีถูกล็อก
+)
+
 type UserService struct {
-	repo repository.UserRepository
+	repo            repository.UserRepository
+	auditRepo       repository.AuditRepository
+	reminderService ReminderUseCase
+	passwordPolicy  *PasswordPolicy
+	shareService    ShareNoteUseCase
 }
 
-func NewUserService(repo repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+func NewUserService(repo repository.UserRepository, auditRepo repository.AuditRepository, reminderService ReminderUseCase, passwordPolicy *PasswordPolicy, shareService ShareNoteUseCase) *UserService {
+	return &UserService{
+		repo:            repo,
+		auditRepo:       auditRepo,
+		reminderService: reminderService,
+		passwordPolicy:  passwordPolicy,
+		shareService:    shareService,
+	}
 }
 
 // Register a new user
@@ -34,15 +54,29 @@ func (s *UserService) Register(user *entities.User) error {
 	// ตรวจสอบให้แน่ใจว่า UserID ถูกรีเซ็ตเพื่อไม่ให้ผู้ใช้กำหนดเอง
 	user.UserID = 0
 
+	// ตรวจสอบรหัสผ่านตาม policy
+	if err := s.passwordPolicy.Validate(user.Password); err != nil {
+		return err
+	}
+
 	// แฮชรหัสผ่านก่อนบันทึก
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordPolicy.Hash(user.Password)
 	if err != nil {
 		return err
 	}
-	user.Password = string(hashedPassword)
+	user.Password = hashedPassword
 
 	// บันทึกข้อมูลผู้ใช้
-	return s.repo.CreateUser(user)
+	if err := s.repo.CreateUser(user); err != nil {
+		return err
+	}
+
+	// แปลงคำเชิญแชร์โน้ตที่ส่งถึงอีเมลนี้ไว้ก่อนสมัคร
+	if err := s.shareService.AcceptInvitations(user.Email, user.UserID); err != nil {
+		log.Printf("Failed to accept share invitations for %s: %v", user.Email, err)
+	}
+
+	return nil
 }
 
 // Login a user and return JWT token
@@ -52,13 +86,63 @@ func (s *UserService) Login(email, password string) (string, error) {
 		return "", errors.New("user not found")
 	}
 
+	// ตรวจสอบว่าบัญชียังถูกล็อกอยู่หรือไม่
+	if lockedUntil, ok := parseTime(user.LockedUntil); ok && time.Now().Before(lockedUntil) {
+		return "", errors.New("account is locked")
+	}
+
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		s.registerFailedLogin(user)
 		return "", errors.New("invalid credentials")
 	}
 
+	// ล็อกอินสำเร็จ ล้างจำนวนครั้งที่ผิด และแฮชรหัสผ่านใหม่ถ้า cost เดิมต่ำกว่าที่กำหนด
+	needsUpdate := user.FailedLoginAttempts > 0 || user.LockedUntil != ""
+	user.FailedLoginAttempts = 0
+	user.LockedUntil = ""
+	if s.passwordPolicy.NeedsRehash(user.Password) {
+		if hashedPassword, err := s.passwordPolicy.Hash(password); err == nil {
+			user.Password = hashedPassword
+			needsUpdate = true
+		} else {
+			log.Printf("Failed to upgrade password hash for user %d: %v", user.UserID, err)
+		}
+	}
+	if needsUpdate {
+		if err := s.repo.UpdateUser(user); err != nil {
+			return "", err
+		}
+	}
+
 	return s.generateToken(user.UserID)
 }
 
+// นับจำนวนครั้งที่ล็อกอินผิด และล็อกบัญชีชั่วคราวเมื่อครบกำหนด
+func (s *UserService) registerFailedLogin(user *entities.User) {
+	user.FailedLoginAttempts++
+	if user.FailedLoginAttempts >= maxFailedLoginAttempts {
+		user.FailedLoginAttempts = 0
+		user.LockedUntil = time.Now().Add(accountLockoutDuration).Format("2006-01-02 15:04:05")
+		s.audit(user.UserID, "account_locked", fmt.Sprintf("locked until %s after %d failed login attempts", user.LockedUntil, maxFailedLoginAttempts))
+	}
+
+	if err := s.repo.UpdateUser(user); err != nil {
+		log.Printf("Failed to record failed login for user %d: %v", user.UserID, err)
+	}
+}
+
+func (s *UserService) audit(userID uint, action string, detail string) {
+	entry := &entities.AuditLog{
+		UserID:    userID,
+		Action:    action,
+		Detail:    detail,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := s.auditRepo.CreateAuditLog(entry); err != nil {
+		log.Printf("Failed to write audit log: %v", err)
+	}
+}
+
 // Generate JWT token for user
 func (s *UserService) generateToken(userID uint) (string, error) {
 	jwtSecret := os.Getenv("JWT_SECRET")
@@ -107,6 +191,16 @@ func (s *UserService) ChangeUsername(userID uint, newUsername string) error {
 	return s.repo.UpdateUser(user)
 }
 
+// UpdateNotificationSettings เปิด/ปิดอีเมลแจ้งเตือนเกี่ยวกับการแชร์โน้ต
+func (s *UserService) UpdateNotificationSettings(userID uint, shareEmailsOptOut bool) error {
+	user, err := s.repo.GetUserById(userID)
+	if err != nil {
+		return err
+	}
+	user.ShareEmailsOptOut = shareEmailsOptOut
+	return s.repo.UpdateUser(user)
+}
+
 func (s *UserService) ResetPassword(tokenString string, newPassword string) error {
 	jwtSecret := os.Getenv("JWT_SECRET")
 
@@ -136,14 +230,19 @@ func (s *UserService) ResetPassword(tokenString string, newPassword string) erro
 		return errors.New("user not found")
 	}
 
+	// Validate the new password against the policy
+	if err := s.passwordPolicy.Validate(newPassword); err != nil {
+		return err
+	}
+
 	// Hash the new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hashedPassword, err := s.passwordPolicy.Hash(newPassword)
 	if err != nil {
 		return err
 	}
 
 	// Update user's password
-	user.Password = string(hashedPassword)
+	user.Password = hashedPassword
 	return s.repo.UpdateUser(user)
 }
 
@@ -152,3 +251,72 @@ func (s *UserService) ResetPassword(tokenString string, newPassword string) erro
 func (s *UserService) GetUser(userID uint) (*entities.User, error) {
 	return s.repo.GetUserById(userID)
 }
+
+// DeleteAccount ลบบัญชีผู้ใช้หลังยืนยันรหัสผ่าน
+// ถ้าระบุ transferToEmail โน้ตที่แชร์กับผู้ใช้คนนั้นจะถูกโอนให้แทนการลบ
+func (s *UserService) DeleteAccount(userID uint, password string, transferToEmail string) error {
+	user, err := s.repo.GetUserById(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return errors.New("invalid password")
+	}
+
+	var newOwnerID uint
+	if transferToEmail != "" {
+		newOwner, err := s.repo.GetUserByEmail(transferToEmail)
+		if err != nil {
+			return errors.New("transfer user not found")
+		}
+		if newOwner.UserID == userID {
+			return errors.New("cannot transfer notes to yourself")
+		}
+		newOwnerID = newOwner.UserID
+	}
+
+	reminderIDs, err := s.repo.DeleteUser(userID, newOwnerID)
+	if err != nil {
+		return err
+	}
+
+	// ยกเลิกการแจ้งเตือนที่ตั้งเวลาไว้ของโน้ตที่ถูกลบ
+	s.reminderService.CancelReminders(reminderIDs)
+
+	s.audit(userID, "account_deleted", fmt.Sprintf("account %s deleted", user.Email))
+	return nil
+}
+
+// UpdatePassword เปลี่ยนรหัสผ่านของผู้ใช้ที่ล็อกอินอยู่ โดยต้องยืนยันรหัสผ่านปัจจุบัน
+func (s *UserService) UpdatePassword(userID uint, currentPassword string, newPassword string) error {
+	user, err := s.repo.GetUserById(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return errors.New("invalid password")
+	}
+
+	if currentPassword == newPassword {
+		return errors.New("password must be different from the current password")
+	}
+
+	if err := s.passwordPolicy.Validate(newPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.passwordPolicy.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashedPassword
+	if err := s.repo.UpdateUser(user); err != nil {
+		return err
+	}
+
+	s.audit(userID, "password_changed", "password changed by user")
+	return nil
+}
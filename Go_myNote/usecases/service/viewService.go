@@ -0,0 +1,170 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"strings"
+	"time"
+)
+
+type ViewUseCase interface {
+	CreateView(view *entities.SavedView) error
+	GetViews(userID uint) ([]entities.SavedView, error)
+	GetView(viewID uint, userID uint) (*entities.SavedView, error)
+	UpdateView(viewID uint, userID uint, update *entities.SavedView) (*entities.SavedView, error)
+	DeleteView(viewID uint, userID uint) error
+	ExecuteView(viewID uint, userID uint) ([]entities.Note, error)
+	SearchNotes(userID uint, query entities.NoteQuery) ([]entities.Note, error)
+}
+
+type ViewService struct {
+	viewRepo repository.ViewRepository
+	noteRepo repository.NoteRepository
+}
+
+func NewViewService(viewRepo repository.ViewRepository, noteRepo repository.NoteRepository) *ViewService {
+	return &ViewService{
+		viewRepo: viewRepo,
+		noteRepo: noteRepo,
+	}
+}
+
+func (s *ViewService) CreateView(view *entities.SavedView) error {
+	if err := validateView(view); err != nil {
+		return err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	view.ViewID = 0
+	view.CreatedAt = now
+	view.UpdatedAt = now
+	return s.viewRepo.CreateView(view)
+}
+
+func (s *ViewService) GetViews(userID uint) ([]entities.SavedView, error) {
+	return s.viewRepo.GetViewsByUserID(userID)
+}
+
+// GetView มุมมองเป็นของส่วนตัว เจ้าของเท่านั้นที่เห็น
+func (s *ViewService) GetView(viewID uint, userID uint) (*entities.SavedView, error) {
+	view, err := s.viewRepo.GetViewByID(viewID)
+	if err != nil {
+		return nil, err
+	}
+	if view.UserID != userID {
+		return nil, fmt.Errorf("view not found")
+	}
+	return view, nil
+}
+
+func (s *ViewService) UpdateView(viewID uint, userID uint, update *entities.SavedView) (*entities.SavedView, error) {
+	view, err := s.GetView(viewID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateView(update); err != nil {
+		return nil, err
+	}
+
+	view.Name = update.Name
+	view.Query = update.Query
+	view.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+
+	if err := s.viewRepo.UpdateView(view); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+func (s *ViewService) DeleteView(viewID uint, userID uint) error {
+	if _, err := s.GetView(viewID, userID); err != nil {
+		return err
+	}
+	return s.viewRepo.DeleteView(viewID)
+}
+
+// ExecuteView ค้นหาโน้ตตามเงื่อนไขที่บันทึกไว้ ช่วงเวลาแจ้งเตือนคิดจากวันที่เรียกใช้
+func (s *ViewService) ExecuteView(viewID uint, userID uint) ([]entities.Note, error) {
+	view, err := s.GetView(viewID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.SearchNotes(userID, view.Query)
+}
+
+// SearchNotes ค้นหาโน้ตด้วยเงื่อนไขที่ยังไม่ได้บันทึก ใช้ทดลองก่อนบันทึกเป็นมุมมอง
+func (s *ViewService) SearchNotes(userID uint, query entities.NoteQuery) ([]entities.Note, error) {
+	if err := validateNoteQuery(&query); err != nil {
+		return nil, err
+	}
+
+	query.ReminderFrom, query.ReminderTo = "", ""
+	if query.ReminderWindow != nil {
+		// เวลาแจ้งเตือนเก็บเป็นเวลาประเทศไทย
+		thLocation, _ := time.LoadLocation("Asia/Bangkok")
+		now := time.Now().In(thLocation)
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, thLocation)
+		query.ReminderFrom = today.AddDate(0, 0, query.ReminderWindow.FromDays).Format("2006-01-02 15:04:05")
+		query.ReminderTo = today.AddDate(0, 0, query.ReminderWindow.ToDays+1).Add(-time.Second).Format("2006-01-02 15:04:05")
+	}
+
+	notes, err := s.noteRepo.SearchNotes(userID, query)
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func validateView(view *entities.SavedView) error {
+	view.Name = strings.TrimSpace(view.Name)
+	if view.Name == "" {
+		return fmt.Errorf("view name is required")
+	}
+	return validateNoteQuery(&view.Query)
+}
+
+// validateNoteQuery ตรวจสอบและจัดรูปแบบเงื่อนไข เช่น ตัดช่องว่างและแท็กที่ซ้ำ
+func validateNoteQuery(query *entities.NoteQuery) error {
+	query.Text = strings.TrimSpace(query.Text)
+
+	switch query.TodoStatus {
+	case "", "open", "done", "none":
+	default:
+		return fmt.Errorf("invalid todo status")
+	}
+
+	switch query.SortBy {
+	case "", "updated_at", "created_at", "priority", "title":
+	default:
+		return fmt.Errorf("invalid sort order")
+	}
+
+	if query.MinPriority != nil && query.MaxPriority != nil && *query.MinPriority > *query.MaxPriority {
+		return fmt.Errorf("invalid priority range")
+	}
+
+	if query.ReminderWindow != nil && query.ReminderWindow.FromDays > query.ReminderWindow.ToDays {
+		return fmt.Errorf("invalid reminder window")
+	}
+
+	seen := make(map[uint]bool, len(query.TagIDs))
+	tagIDs := []uint{}
+	for _, tagID := range query.TagIDs {
+		if tagID != 0 && !seen[tagID] {
+			seen[tagID] = true
+			tagIDs = append(tagIDs, tagID)
+		}
+	}
+	query.TagIDs = tagIDs
+
+	colors := []string{}
+	for _, color := range query.Colors {
+		if color = strings.TrimSpace(color); color != "" {
+			colors = append(colors, color)
+		}
+	}
+	query.Colors = colors
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"fmt"
+	"miw/entities"
+	"miw/usecases/repository"
+	"strings"
+	"time"
+)
+
+type WorkspaceUseCase interface {
+	CreateWorkspace(name string, ownerID uint) (*entities.Workspace, error)
+	GetWorkspaces(userID uint) ([]entities.Workspace, error)
+	GetWorkspace(workspaceID uint, userID uint) (*entities.Workspace, []entities.WorkspaceMemberInfo, error)
+	UpdateWorkspaceName(workspaceID uint, userID uint, name string) error
+	DeleteWorkspace(workspaceID uint, userID uint) error
+	GetWorkspaceNotes(workspaceID uint, userID uint) ([]entities.Note, error)
+	AddMember(workspaceID uint, userID uint, email string, role string) ([]entities.WorkspaceMemberInfo, error)
+	UpdateMemberRole(workspaceID uint, userID uint, memberID uint, role string) error
+	RemoveMember(workspaceID uint, userID uint, memberID uint) error
+}
+
+type WorkspaceService struct {
+	workspaceRepo repository.WorkspaceRepository
+	userRepo      repository.UserRepository
+}
+
+func NewWorkspaceService(workspaceRepo repository.WorkspaceRepository, userRepo repository.UserRepository) *WorkspaceService {
+	return &WorkspaceService{
+		workspaceRepo: workspaceRepo,
+		userRepo:      userRepo,
+	}
+}
+
+// canEditWorkspace บทบาทที่สร้างและแก้ไขโน้ตในเวิร์กสเปซได้
+func canEditWorkspace(role string) bool {
+	return role == "owner" || role == "admin" || role == "editor"
+}
+
+// canManageWorkspace บทบาทที่จัดการสมาชิกและตั้งค่าเวิร์กสเปซได้
+func canManageWorkspace(role string) bool {
+	return role == "owner" || role == "admin"
+}
+
+// isValidMemberRole บทบาทที่กำหนดให้สมาชิกได้ (owner มีได้คนเดียวคือผู้สร้าง)
+func isValidMemberRole(role string) bool {
+	return role == "admin" || role == "editor" || role == "viewer"
+}
+
+func (s *WorkspaceService) CreateWorkspace(name string, ownerID uint) (*entities.Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("workspace name is required")
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+	workspace := &entities.Workspace{
+		Name:      name,
+		OwnerID:   ownerID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.workspaceRepo.CreateWorkspace(workspace); err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+func (s *WorkspaceService) GetWorkspaces(userID uint) ([]entities.Workspace, error) {
+	return s.workspaceRepo.GetWorkspacesByUserID(userID)
+}
+
+func (s *WorkspaceService) GetWorkspace(workspaceID uint, userID uint) (*entities.Workspace, []entities.WorkspaceMemberInfo, error) {
+	if _, err := s.getMember(workspaceID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	workspace, err := s.workspaceRepo.GetWorkspaceByID(workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := s.workspaceRepo.GetMembers(workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return workspace, members, nil
+}
+
+func (s *WorkspaceService) UpdateWorkspaceName(workspaceID uint, userID uint, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("workspace name is required")
+	}
+
+	member, err := s.getMember(workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if !canManageWorkspace(member.Role) {
+		return fmt.Errorf("you are not allowed to manage this workspace")
+	}
+
+	return s.workspaceRepo.UpdateWorkspaceName(workspaceID, name)
+}
+
+// DeleteWorkspace ลบได้เฉพาะเจ้าของ โน้ตในเวิร์กสเปซจะกลับเป็นโน้ตส่วนตัวของผู้สร้าง
+func (s *WorkspaceService) DeleteWorkspace(workspaceID uint, userID uint) error {
+	member, err := s.getMember(workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role != "owner" {
+		return fmt.Errorf("only the workspace owner can delete the workspace")
+	}
+
+	return s.workspaceRepo.DeleteWorkspace(workspaceID)
+}
+
+func (s *WorkspaceService) GetWorkspaceNotes(workspaceID uint, userID uint) ([]entities.Note, error) {
+	if _, err := s.getMember(workspaceID, userID); err != nil {
+		return nil, err
+	}
+	return s.workspaceRepo.GetWorkspaceNotes(workspaceID)
+}
+
+func (s *WorkspaceService) AddMember(workspaceID uint, userID uint, email string, role string) ([]entities.WorkspaceMemberInfo, error) {
+	if !isValidMemberRole(role) {
+		return nil, fmt.Errorf("invalid role")
+	}
+
+	member, err := s.getMember(workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !canManageWorkspace(member.Role) {
+		return nil, fmt.Errorf("you are not allowed to manage this workspace")
+	}
+	// เฉพาะเจ้าของเท่านั้นที่แต่งตั้ง admin ได้
+	if role == "admin" && member.Role != "owner" {
+		return nil, fmt.Errorf("only the workspace owner can assign admins")
+	}
+
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err != nil || user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	if _, err := s.workspaceRepo.GetMember(workspaceID, user.UserID); err == nil {
+		return nil, fmt.Errorf("user is already a member of this workspace")
+	}
+
+	newMember := &entities.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      user.UserID,
+		Role:        role,
+		CreatedAt:   time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if err := s.workspaceRepo.AddMember(newMember); err != nil {
+		return nil, err
+	}
+
+	return s.workspaceRepo.GetMembers(workspaceID)
+}
+
+func (s *WorkspaceService) UpdateMemberRole(workspaceID uint, userID uint, memberID uint, role string) error {
+	if !isValidMemberRole(role) {
+		return fmt.Errorf("invalid role")
+	}
+
+	member, err := s.getMember(workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if !canManageWorkspace(member.Role) {
+		return fmt.Errorf("you are not allowed to manage this workspace")
+	}
+
+	target, err := s.workspaceRepo.GetMember(workspaceID, memberID)
+	if err != nil {
+		return err
+	}
+	if target.Role == "owner" {
+		return fmt.Errorf("cannot change the workspace owner")
+	}
+	// การแต่งตั้งหรือถอด admin ทำได้เฉพาะเจ้าของ
+	if (role == "admin" || target.Role == "admin") && member.Role != "owner" {
+		return fmt.Errorf("only the workspace owner can assign admins")
+	}
+
+	return s.workspaceRepo.UpdateMemberRole(workspaceID, memberID, role)
+}
+
+// RemoveMember ผู้ดูแลลบสมาชิกได้ และสมาชิกออกจากเวิร์กสเปซเองได้
+func (s *WorkspaceService) RemoveMember(workspaceID uint, userID uint, memberID uint) error {
+	member, err := s.getMember(workspaceID, userID)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.workspaceRepo.GetMember(workspaceID, memberID)
+	if err != nil {
+		return err
+	}
+	if target.Role == "owner" {
+		return fmt.Errorf("cannot change the workspace owner")
+	}
+
+	if memberID != userID {
+		if !canManageWorkspace(member.Role) {
+			return fmt.Errorf("you are not allowed to manage this workspace")
+		}
+		if target.Role == "admin" && member.Role != "owner" {
+			return fmt.Errorf("only the workspace owner can assign admins")
+		}
+	}
+
+	return s.workspaceRepo.RemoveMember(workspaceID, memberID)
+}
+
+// getMember ดึงสมาชิกภาพของผู้ใช้ คืนข้อผิดพลาดถ้าผู้ใช้ไม่ใช่สมาชิก
+func (s *WorkspaceService) getMember(workspaceID uint, userID uint) (*entities.WorkspaceMember, error) {
+	if _, err := s.workspaceRepo.GetWorkspaceByID(workspaceID); err != nil {
+		return nil, err
+	}
+	member, err := s.workspaceRepo.GetMember(workspaceID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("you are not a member of this workspace")
+	}
+	return member, nil
+}
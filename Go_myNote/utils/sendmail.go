@@ -27,3 +27,27 @@ func SendEmail(to, subject, body string) error {
 	log.Printf("Email successfully sent to %s", to)
 	return nil
 }
+
+// SendHTMLEmail ส่งอีเมลที่มีทั้งเนื้อหาแบบข้อความธรรมดาและ HTML
+func SendHTMLEmail(to, subject, textBody, htmlBody string) error {
+	message := gomail.NewMessage()
+	fromEmail := os.Getenv("MAIL_EMAIL")
+	fromPassword := os.Getenv("MAIL_PASSWORD")
+
+	message.SetHeader("From", fromEmail)
+	message.SetHeader("To", to)
+	message.SetHeader("Subject", subject)
+	message.SetBody("text/plain", textBody)
+	message.AddAlternative("text/html", htmlBody)
+
+	d := gomail.NewDialer("smtp.gmail.com", 587, fromEmail, fromPassword)
+
+	log.Printf("Sending email...\nFrom: %s\nTo: %s\nSubject: %s\n", fromEmail, to, subject)
+
+	if err := d.DialAndSend(message); err != nil {
+		log.Printf("Failed to send email to %s: %v", to, err)
+		return err
+	}
+	log.Printf("Email successfully sent to %s", to)
+	return nil
+}